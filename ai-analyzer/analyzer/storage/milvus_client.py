@@ -1,3 +1,6 @@
+import base64
+import gzip
+import json
 from datetime import datetime, timedelta
 from typing import List, Optional, Dict, Any
 from loguru import logger
@@ -12,6 +15,33 @@ from ..models.log import LogRecord, LogCluster, LogLevel
 from ..config.settings import Settings
 
 
+# Key of the wrapper the log ingestor stores gzip-compressed metadata in
+# (COMPRESS_METADATA): {"_gz": "<base64 gzip of the original JSON>"}
+COMPRESSED_METADATA_KEY = "_gz"
+
+
+def decode_metadata(metadata: Any) -> Dict[str, Any]:
+    """Parse stored metadata, decompressing records written with metadata compression"""
+    if isinstance(metadata, str):
+        try:
+            metadata = json.loads(metadata)
+        except json.JSONDecodeError:
+            return {}
+    if not isinstance(metadata, dict):
+        return {}
+
+    encoded = metadata.get(COMPRESSED_METADATA_KEY)
+    if len(metadata) != 1 or not isinstance(encoded, str):
+        return metadata
+
+    try:
+        decoded = json.loads(gzip.decompress(base64.b64decode(encoded)))
+    except (ValueError, OSError) as e:
+        logger.warning(f"Failed to decompress log metadata: {e}")
+        return {}
+    return decoded if isinstance(decoded, dict) else {}
+
+
 class MilvusConnectionError(Exception):
     """Raised when Milvus connection fails"""
     pass
@@ -120,13 +150,7 @@ class MilvusQueryEngine:
             for result in results:
                 try:
                     # Extract metadata and level
-                    metadata = result.get("metadata", {})
-                    if isinstance(metadata, str):
-                        import json
-                        try:
-                            metadata = json.loads(metadata)
-                        except json.JSONDecodeError:
-                            metadata = {}
+                    metadata = decode_metadata(result.get("metadata", {}))
 
                     # Extract level from metadata, with fallback logic
                     level = "INFO"  # default
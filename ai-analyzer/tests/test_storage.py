@@ -1,12 +1,15 @@
 """
 Unit tests for the storage.milvus_client module
 """
+import base64
+import gzip
+import json
 import pytest
 from datetime import datetime, timedelta
 from unittest.mock import patch, Mock, MagicMock
 
 from analyzer.storage.milvus_client import (
-    MilvusQueryEngine, MilvusConnectionError
+    MilvusQueryEngine, MilvusConnectionError, decode_metadata
 )
 from analyzer.config.settings import Settings
 from analyzer.models.log import LogRecord, LogCluster
@@ -192,6 +195,49 @@ def test_query_time_range_auto_connect(mock_collection, mock_utility, mock_conne
     assert logs[0].message == "Test log message"
 
 
+@patch('analyzer.storage.milvus_client.connections')
+@patch('analyzer.storage.milvus_client.utility')
+@patch('analyzer.storage.milvus_client.Collection')
+def test_query_time_range_compressed_metadata(mock_collection, mock_utility, mock_connections, milvus_engine):
+    """Test that metadata stored compressed by the ingestor is decompressed"""
+    mock_utility.has_collection.return_value = True
+    mock_collection_instance = Mock()
+    mock_collection.return_value = mock_collection_instance
+    mock_connections.has_connection.return_value = True
+
+    original = {"level": "ERROR", "namespace_name": "payments"}
+    compressed = base64.b64encode(gzip.compress(json.dumps(original).encode())).decode()
+    mock_collection_instance.query.return_value = [
+        {
+            "id": 1,
+            "timestamp": 1640995200000,
+            "message": "Payment failed",
+            "source": "payments-pod",
+            "metadata": {"_gz": compressed},
+            "embedding": [0.1] * 128,
+        }
+    ]
+
+    logs = milvus_engine.query_time_range(datetime(2022, 1, 1, 10, 0, 0), datetime(2022, 1, 1, 11, 0, 0))
+
+    assert len(logs) == 1
+    assert logs[0].metadata == original
+    assert logs[0].level == "ERROR"
+
+
+def test_decode_metadata():
+    """Test decoding of plain, compressed and malformed metadata"""
+    compressed = base64.b64encode(gzip.compress(b'{"level": "WARN"}')).decode()
+
+    assert decode_metadata({"level": "INFO"}) == {"level": "INFO"}
+    assert decode_metadata('{"level": "INFO"}') == {"level": "INFO"}
+    assert decode_metadata({"_gz": compressed}) == {"level": "WARN"}
+    # The marker among other keys is plain metadata
+    assert decode_metadata({"_gz": "abc", "level": "INFO"}) == {"_gz": "abc", "level": "INFO"}
+    assert decode_metadata({"_gz": "!!!"}) == {}
+    assert decode_metadata("not json") == {}
+
+
 @patch('analyzer.storage.milvus_client.connections')
 @patch('analyzer.storage.milvus_client.utility')
 @patch('analyzer.storage.milvus_client.Collection')
@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,13 +13,18 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 	"github.com/timberline/log-ingestor/internal/config"
+	"github.com/timberline/log-ingestor/internal/dlq"
 	"github.com/timberline/log-ingestor/internal/embedding"
+	"github.com/timberline/log-ingestor/internal/grpcserver"
 	"github.com/timberline/log-ingestor/internal/handlers"
 	"github.com/timberline/log-ingestor/internal/metrics"
 	"github.com/timberline/log-ingestor/internal/models"
 	"github.com/timberline/log-ingestor/internal/storage"
+	"github.com/timberline/log-ingestor/internal/tracing"
+	"golang.org/x/net/netutil"
 )
 
 const Version = "1.0.0"
@@ -32,9 +40,21 @@ func main() {
 	logger := logrus.WithField("component", "main")
 
 	logger.WithField("version", Version).Info("Starting log ingestor service")
+	metrics.RegisterBuildInfo(prometheus.DefaultRegisterer, Version)
+
+	// Export traces when an OTLP endpoint is configured; spans are no-ops otherwise
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.OTLPEndpoint, Version)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to set up tracing")
+	}
 
 	// Initialize embedding service
 	embeddingService := embedding.NewService(cfg.EmbeddingEndpoint, cfg.EmbeddingModel, cfg.EmbeddingDimension, logrus.StandardLogger())
+	embeddingService.SetMaxBatch(cfg.EmbeddingMaxBatch)
+	embeddingService.RegisterMetrics(prometheus.DefaultRegisterer)
+	if err := embeddingService.ConfigureTLS(cfg.EmbeddingCACert, cfg.EmbeddingSkipVerify); err != nil {
+		logger.WithError(err).Fatal("Failed to configure embedding service TLS")
+	}
 
 	// Test embedding service connection
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -43,8 +63,51 @@ func main() {
 	}
 	cancel()
 
+	// Optionally split long messages into chunks and bound concurrent embedding
+	// requests, then cache embeddings of repeated messages in front of the
+	// limit so cache hits never wait
+	var embedder embedding.Interface = embeddingService
+	if cfg.EmbedChunking {
+		embedder = embedding.NewChunkingService(embedder, cfg.EmbedChunkSize, cfg.EmbedChunkOverlap)
+	}
+	if cfg.EmbeddingMaxConcurrency > 0 {
+		embedder = embedding.NewLimitingService(embedder, cfg.EmbeddingMaxConcurrency)
+	}
+	if cfg.EmbeddingCacheSize > 0 {
+		embedder = embedding.NewCachingService(embedder, cfg.EmbeddingCacheSize, prometheus.DefaultRegisterer)
+	}
+
 	// Initialize storage
-	storageClient := storage.NewMilvusClient(cfg.MilvusAddress, embeddingService, cfg.EmbeddingDimension, cfg.SimilarityThreshold, cfg.MinExamplesBeforeExclusion, logrus.StandardLogger())
+	storageClient := storage.NewMilvusClient(cfg.MilvusAddress, embedder, cfg.EmbeddingDimension, cfg.SimilarityThreshold, cfg.MinExamplesBeforeExclusion, logrus.StandardLogger())
+	storageClient.SetNoEmbedSources(cfg.NoEmbedSources)
+	storageClient.SetScalarFields(cfg.ScalarFields())
+	storageClient.SetEmbedIncludeSource(cfg.EmbedIncludeSource)
+	storageClient.SetMessageTemplates(cfg.StoreMessageTemplate, cfg.EmbedMessageTemplate)
+
+	timestampBounds := models.TimestampBounds{MaxFutureSkew: cfg.MaxFutureSkew, MaxAge: cfg.MaxAge}
+	storageClient.SetTimestampBounds(timestampBounds)
+	storageClient.SetDuplicateFlushInterval(cfg.DuplicateFlushInterval)
+	storageClient.SetExactDuplicateCacheSize(cfg.ExactDedupCacheSize)
+	storageClient.SetDedupMaxMatchAge(cfg.DedupMaxMatchAge)
+	storageClient.SetDedupWindow(cfg.DedupWindow)
+	storageClient.SetDedupBypassLatency(cfg.DedupBypassLatency)
+	storageClient.SetDedupWarmup(cfg.DedupWarmup)
+	storageClient.SetRecordSimilarCount(cfg.RecordSimilarCount)
+	storageClient.SetTimestampBucket(cfg.TimestampBucket)
+	storageClient.SetHealthCheckCacheTTL(cfg.HealthCheckCacheTTL)
+	storageClient.SetSourceSimilarityThresholds(cfg.SimilarityThresholds)
+	storageClient.SetIdempotencyKeys(cfg.IdempotencyKeys)
+	storageClient.SetContentHash(cfg.StoreContentHash)
+	storageClient.SetCollectionLoadMode(storage.CollectionLoadMode(cfg.CollectionLoadMode))
+	storageClient.SetShardNum(int32(cfg.ShardNum))
+	storageClient.SetSearchEf(cfg.SearchEf)
+	storageClient.RegisterMetrics(prometheus.DefaultRegisterer)
+	storageClient.SetCompressMetadata(cfg.CompressMetadata)
+	coldSink, err := dlq.NewSink(cfg.ColdStorageEndpoint, cfg.ColdStorageMaxBytes, logrus.StandardLogger())
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to create cold storage sink")
+	}
+	storageClient.SetColdSink(coldSink)
 
 	// Connect to storage with retry
 	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
@@ -64,12 +127,69 @@ func main() {
 		logger.WithError(err).Fatal("Failed to create collection")
 	}
 
+	// An existing collection with another dimension, or without a field an
+	// enabled option writes, would reject every insert
+	verifyCtx, verifyCancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	if err := storageClient.VerifySchema(verifyCtx, cfg.AutoRecreate); err != nil {
+		if errors.Is(err, storage.ErrDimensionMismatch) {
+			logger.WithError(err).Fatal("Collection embedding dimension check failed; set AUTO_RECREATE=true to drop and recreate the collection")
+		}
+		logger.WithError(err).Fatal("Collection schema check failed")
+	}
+	verifyCancel()
+
+	// Optionally load the collection now so the first search is not a cold load
+	warmupCtx, warmupCancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	if err := storageClient.Warmup(warmupCtx); err != nil {
+		logger.WithError(err).Warn("Failed to preload collection, proceeding anyway")
+	}
+	warmupCancel()
+
 	// Create log processing channel
-	logChannel := make(chan *models.LogEntry, 10000) // Buffer size of 10000
+	logChannel := make(chan *models.LogEntry, cfg.QueueSize)
 
 	// Initialize handlers
-	streamHandler := handlers.NewStreamHandler(storageClient, cfg.BatchSize, logChannel)
+	streamHandler := handlers.NewStreamHandler(storageClient, cfg.BatchSize, cfg.BatchTimeout, logChannel)
+	streamHandler.SetTimestampBounds(timestampBounds)
+	streamHandler.SetClampFutureTimestamps(cfg.ClampFutureTimestamps)
+	streamHandler.SetAsyncStorage(cfg.AsyncStorage)
+	streamHandler.SetMaxLineSize(cfg.MaxLineSize)
+	streamHandler.SetMaxRequestSize(cfg.MaxRequestSize)
+	streamHandler.SetInvalidLineLogInterval(cfg.InvalidLineLogInterval)
+	streamHandler.SetFlattenMetadata(cfg.FlattenMetadata)
+	streamHandler.SetComponentFields(cfg.ComponentFields)
+	streamHandler.SetSkipEmptyMessages(cfg.SkipEmptyMessages)
+	streamHandler.SetDecodeBase64(cfg.DecodeBase64)
+	streamHandler.SetDetectStackTraces(cfg.DetectStackTraces)
+	streamHandler.SetSigningSecret(cfg.SigningSecret)
+	if cfg.LogSchemaFile != "" {
+		logSchema, err := handlers.LoadLogSchema(cfg.LogSchemaFile)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to load log schema")
+		}
+		streamHandler.SetLogSchema(logSchema, cfg.LogSchemaStrict)
+	}
+	streamHandler.SetRequiredMetadataKeys(cfg.RequiredMetadataKeys, cfg.LogSchemaStrict)
+	streamHandler.SetDropPatterns(cfg.DropPatterns())
+	deadLetter, err := dlq.NewSink(cfg.DLQEndpoint, cfg.DLQMaxBytes, logrus.StandardLogger())
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to create dead-letter sink")
+	}
+	streamHandler.SetDeadLetterSink(deadLetter)
 	healthHandler := handlers.NewHealthHandler(storageClient, Version, logrus.StandardLogger())
+	healthHandler.SetEmbeddingHealth(embeddingService)
+	logsHandler := handlers.NewLogsHandler(storageClient, logrus.StandardLogger())
+	adminHandler := handlers.NewAdminHandler(storageClient, cfg.AllowAdminPurge, logrus.StandardLogger())
+	adminHandler.SetQueueFlusher(streamHandler, cfg.AllowAdminFlush)
+	var auditSink dlq.Sink
+	switch cfg.AuditSink {
+	case "dlq":
+		auditSink = deadLetter
+	case "cold":
+		auditSink = coldSink
+	}
+	auditLogger := handlers.NewAuditLogger(logrus.StandardLogger(), cfg.AuditActorHeader, auditSink)
+	configHandler := handlers.NewConfigHandler(cfg, logrus.StandardLogger())
 
 	// Start worker goroutines for processing logs
 	workerCtx, workerCancel := context.WithCancel(context.Background())
@@ -80,39 +200,41 @@ func main() {
 		go streamHandler.StartWorker(workerCtx)
 	}
 
+	// Flush batched duplicate counts until shutdown (no-op unless DUPLICATE_FLUSH_INTERVAL is set)
+	duplicatesDone := make(chan struct{})
+	go func() {
+		storageClient.RunDuplicateFlusher(workerCtx)
+		close(duplicatesDone)
+	}()
+
 	// Setup HTTP router
 	router := mux.NewRouter()
 
 	// API routes
 	api := router.PathPrefix("/api/v1").Subrouter()
 	api.HandleFunc("/logs/stream", streamHandler.HandleStream).Methods("POST")
+	api.HandleFunc("/logs/recent", logsHandler.HandleRecent).Methods("GET")
+	api.HandleFunc("/logs/search", logsHandler.HandleSearch).Methods("POST")
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.Use(auditLogger.Middleware)
+	admin.HandleFunc("/collection", adminHandler.HandlePurgeCollection).Methods("DELETE")
+	admin.HandleFunc("/flush", adminHandler.HandleFlush).Methods("POST")
+	api.HandleFunc("/config", configHandler.HandleConfig).Methods("GET")
 	api.HandleFunc("/health", healthHandler.HandleHealth).Methods("GET")
 	api.HandleFunc("/healthz", healthHandler.HandleLiveness).Methods("GET")
 	api.HandleFunc("/ready", healthHandler.HandleReadiness).Methods("GET")
+	handlers.SetMethodNotAllowedHandler(router)
 
 	// Add middleware
 	router.Use(loggingMiddleware)
-	router.Use(func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Add CORS headers
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-			// Handle preflight requests
-			if r.Method == "OPTIONS" {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
-
-			next.ServeHTTP(w, r)
-		})
-	})
+	// CORS wraps the whole router: mux only runs Use middleware on matched
+	// routes, so preflight OPTIONS requests would otherwise get a 405
+	cors := handlers.CORSMiddleware(router, cfg.CORSAllowedOrigins, cfg.AuditActorHeader)
 
 	// Create main server
 	server := &http.Server{
 		Addr:         ":" + strconv.Itoa(cfg.ServerPort),
-		Handler:      router,
+		Handler:      cors(router),
 		ReadTimeout:  cfg.ReadTimeout,
 		WriteTimeout: cfg.WriteTimeout,
 		IdleTimeout:  15 * time.Second,
@@ -120,20 +242,47 @@ func main() {
 
 	// Start metrics server
 	metricsServer := metrics.NewServer(cfg.MetricsPort, logrus.StandardLogger())
-	go func() {
-		if err := metricsServer.Start(); err != nil {
-			logger.WithError(err).Error("Metrics server failed")
+	metricsServer.SetTimeouts(cfg.MetricsReadTimeout, cfg.MetricsWriteTimeout, cfg.MetricsIdleTimeout)
+	// Bind up front so a port conflict is logged, and fatal with METRICS_BIND_REQUIRED
+	if err := metricsServer.Listen(); err != nil {
+		if cfg.MetricsBindRequired {
+			logger.WithError(err).Fatal("Failed to start metrics server")
 		}
-	}()
+		logger.WithError(err).Warn("Failed to start metrics server, continuing without metrics")
+	} else {
+		go func() {
+			if err := metricsServer.Start(); err != nil {
+				logger.WithError(err).Error("Metrics server failed")
+			}
+		}()
+	}
 
 	// Start main server
+	listener, err := listen(server.Addr, cfg.MaxConnections)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to bind HTTP server")
+	}
 	go func() {
-		logger.WithField("address", server.Addr).Info("Starting HTTP server")
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.WithFields(logrus.Fields{
+			"address":         server.Addr,
+			"max_connections": cfg.MaxConnections,
+		}).Info("Starting HTTP server")
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
 			logger.WithError(err).Fatal("HTTP server failed")
 		}
 	}()
 
+	// Start gRPC server when enabled
+	var grpcServer *grpcserver.Server
+	if cfg.GRPCPort > 0 {
+		grpcServer = grpcserver.NewServer(cfg.GRPCPort, streamHandler, logrus.StandardLogger())
+		go func() {
+			if err := grpcServer.Start(); err != nil {
+				logger.WithError(err).Fatal("gRPC server failed")
+			}
+		}()
+	}
+
 	// Wait for interrupt signal
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
@@ -141,25 +290,51 @@ func main() {
 
 	logger.Info("Shutdown signal received")
 
-	// Graceful shutdown with timeout
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer shutdownCancel()
+	// Graceful shutdown, each phase with its own timeout
+	runShutdown(logger, cfg.ShutdownOrder, map[string]shutdownPhase{
+		"server": {timeout: cfg.ShutdownServerTimeout, run: func(ctx context.Context) error {
+			var errs []error
+			if err := server.Shutdown(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("HTTP server: %w", err))
+			}
+			if grpcServer != nil {
+				if err := grpcServer.Stop(ctx); err != nil {
+					errs = append(errs, fmt.Errorf("gRPC server: %w", err))
+				}
+			}
+			return errors.Join(errs...)
+		}},
+		"metrics": {timeout: cfg.ShutdownMetricsTimeout, run: metricsServer.Stop},
+		// Store the entries still queued
+		"storage": {timeout: cfg.ShutdownStorageTimeout, run: streamHandler.Drain},
+		// Stop the workers, then export the spans of the entries stored while draining
+		"worker": {timeout: cfg.ShutdownWorkerTimeout, run: func(ctx context.Context) error {
+			logger.Info("Stopping log processing workers")
+			workerCancel()
+			select {
+			case <-duplicatesDone:
+			case <-ctx.Done():
+				return fmt.Errorf("duplicate flusher: %w", ctx.Err())
+			}
+			return shutdownTracing(ctx)
+		}},
+	})
 
-	// Shutdown servers
-	if err := server.Shutdown(shutdownCtx); err != nil {
-		logger.WithError(err).Error("HTTP server shutdown failed")
-	}
+	logger.Info("Service stopped")
+}
 
-	if err := metricsServer.Stop(shutdownCtx); err != nil {
-		logger.WithError(err).Error("Metrics server shutdown failed")
+// listen binds addr for the HTTP server. When maxConnections is positive at most
+// that many connections are open at once; further connections wait in the
+// accept backlog until one closes.
+func listen(addr string, maxConnections int) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
 	}
-
-	// Stop workers
-	logger.Info("Stopping log processing workers")
-	workerCancel()
-	close(logChannel)
-
-	logger.Info("Service stopped")
+	if maxConnections > 0 {
+		listener = netutil.LimitListener(listener, maxConnections)
+	}
+	return listener, nil
 }
 
 func loggingMiddleware(next http.Handler) http.Handler {
@@ -167,27 +342,17 @@ func loggingMiddleware(next http.Handler) http.Handler {
 		start := time.Now()
 
 		// Wrap ResponseWriter to capture status code
-		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		wrapped := handlers.NewStatusRecorder(w)
 
 		next.ServeHTTP(wrapped, r)
 
 		logrus.WithFields(logrus.Fields{
 			"method":      r.Method,
 			"path":        r.URL.Path,
-			"status_code": wrapped.statusCode,
+			"status_code": wrapped.StatusCode,
 			"duration":    time.Since(start),
 			"user_agent":  r.UserAgent(),
 			"remote_addr": r.RemoteAddr,
 		}).Info("HTTP request")
 	})
 }
-
-type responseWriter struct {
-	http.ResponseWriter
-	statusCode int
-}
-
-func (rw *responseWriter) WriteHeader(code int) {
-	rw.statusCode = code
-	rw.ResponseWriter.WriteHeader(code)
-}
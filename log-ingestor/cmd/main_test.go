@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListen_MaxConnections(t *testing.T) {
+	listener, err := listen("127.0.0.1:0", 2)
+	require.NoError(t, err)
+
+	entered := make(chan struct{}, 3)
+	release := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})}
+	go func() { _ = server.Serve(listener) }()
+	defer func() { _ = server.Close() }()
+
+	// Each connection sends one request and asks the server to close it afterwards
+	for i := 0; i < 3; i++ {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+		_, err = fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: test\r\nConnection: close\r\n\r\n")
+		require.NoError(t, err)
+	}
+
+	waitEntered := func(timeout time.Duration) bool {
+		select {
+		case <-entered:
+			return true
+		case <-time.After(timeout):
+			return false
+		}
+	}
+
+	// Only two connections are accepted, the third waits
+	require.True(t, waitEntered(2*time.Second))
+	require.True(t, waitEntered(2*time.Second))
+	assert.False(t, waitEntered(200*time.Millisecond))
+
+	// Finishing a request closes its connection and lets the third in
+	release <- struct{}{}
+	assert.True(t, waitEntered(2*time.Second))
+	close(release)
+}
+
+func TestListen_Unlimited(t *testing.T) {
+	listener, err := listen("127.0.0.1:0", 0)
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+
+	_, plain := listener.(*net.TCPListener)
+	assert.True(t, plain, "expected the plain TCP listener without a limit")
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// shutdownPhase is one step of the graceful shutdown with its own time budget
+type shutdownPhase struct {
+	timeout time.Duration
+	run     func(ctx context.Context) error
+}
+
+// runShutdown runs the named phases in order, each under its own timeout, so a
+// phase that hangs cannot use up the budget of the phases after it. A phase
+// still running when its timeout expires is abandoned and the next one starts.
+// Names without a phase are skipped.
+func runShutdown(logger *logrus.Entry, order []string, phases map[string]shutdownPhase) {
+	for _, name := range order {
+		phase, ok := phases[name]
+		if !ok {
+			continue
+		}
+
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), phase.timeout)
+		done := make(chan error, 1)
+		go func() {
+			done <- phase.run(ctx)
+		}()
+
+		var err error
+		select {
+		case err = <-done:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		cancel()
+
+		entry := logger.WithFields(logrus.Fields{
+			"phase":    name,
+			"duration": time.Since(start),
+			"timeout":  phase.timeout,
+		})
+		if err != nil {
+			entry.WithError(err).Error("Shutdown phase failed")
+			continue
+		}
+		entry.Info("Shutdown phase completed")
+	}
+}
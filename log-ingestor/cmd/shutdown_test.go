@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunShutdown_HangingServerDoesNotBlockCleanup(t *testing.T) {
+	// A request that never finishes keeps server.Shutdown waiting until its deadline
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	})}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(listener) }()
+	go func() { _, _ = http.Get("http://" + listener.Addr().String()) }()
+	<-entered
+
+	serverErr := make(chan error, 1)
+	var storageRemaining, workerRemaining time.Duration
+	start := time.Now()
+	runShutdown(logrus.NewEntry(logrus.New()), []string{"server", "metrics", "storage", "worker"}, map[string]shutdownPhase{
+		"server": {timeout: 100 * time.Millisecond, run: func(ctx context.Context) error {
+			err := server.Shutdown(ctx)
+			serverErr <- err
+			return err
+		}},
+		"storage": {timeout: time.Second, run: func(ctx context.Context) error {
+			deadline, _ := ctx.Deadline()
+			storageRemaining = time.Until(deadline)
+			return nil
+		}},
+		"worker": {timeout: 500 * time.Millisecond, run: func(ctx context.Context) error {
+			deadline, _ := ctx.Deadline()
+			workerRemaining = time.Until(deadline)
+			return nil
+		}},
+	})
+
+	assert.ErrorIs(t, <-serverErr, context.DeadlineExceeded)
+	// Each later phase still gets (nearly) its whole budget
+	assert.Greater(t, storageRemaining, 900*time.Millisecond)
+	assert.Greater(t, workerRemaining, 400*time.Millisecond)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRunShutdown_AbandonsPhaseIgnoringTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	ran := make(chan string, 3)
+	runShutdown(logrus.NewEntry(logrus.New()), []string{"worker", "server", "storage"}, map[string]shutdownPhase{
+		"server": {timeout: 50 * time.Millisecond, run: func(ctx context.Context) error {
+			ran <- "server"
+			<-block // ignores ctx
+			return nil
+		}},
+		"storage": {timeout: time.Second, run: func(ctx context.Context) error {
+			ran <- "storage"
+			return nil
+		}},
+		"worker": {timeout: time.Second, run: func(ctx context.Context) error {
+			ran <- "worker"
+			return nil
+		}},
+	})
+
+	order := []string{<-ran, <-ran, <-ran}
+	assert.Equal(t, []string{"worker", "server", "storage"}, order)
+}
@@ -1,30 +1,110 @@
 package config
 
 import (
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/timberline/log-ingestor/internal/models"
 )
 
 type Config struct {
 	ServerPort                 int           `json:"server_port"`
 	LogLevel                   string        `json:"log_level"`
 	MilvusAddress              string        `json:"milvus_address"`
+	ShardNum                   int           `json:"shard_num"`
+	SearchEf                   int           `json:"search_ef"`
+	AutoRecreate               bool          `json:"auto_recreate"`
 	EmbeddingEndpoint          string        `json:"embedding_endpoint"`
 	EmbeddingModel             string        `json:"embedding_model"`
 	EmbeddingDimension         int           `json:"embedding_dimension"`
+	EmbeddingMaxBatch          int           `json:"embedding_max_batch"`
+	EmbeddingCacheSize         int           `json:"embedding_cache_size"`
+	EmbeddingCACert            string        `json:"embedding_ca_cert"`
+	EmbeddingSkipVerify        bool          `json:"embedding_insecure_skip_verify"`
+	EmbeddingMaxConcurrency    int           `json:"embedding_max_concurrency"`
+	EmbedChunking              bool          `json:"embed_chunking"`
+	EmbedChunkSize             int           `json:"embed_chunk_size"`
+	EmbedChunkOverlap          int           `json:"embed_chunk_overlap"`
 	BatchSize                  int           `json:"batch_size"`
 	BatchTimeout               time.Duration `json:"batch_timeout"`
 	MaxRequestSize             int64         `json:"max_request_size"`
+	MaxConnections             int           `json:"max_connections"`
+	MaxLineSize                int           `json:"max_line_size"`
+	InvalidLineLogInterval     time.Duration `json:"invalid_line_log_interval"`
+	QueueSize                  int           `json:"queue_size"`
+	AsyncStorage               bool          `json:"async_storage"`
 	MetricsPort                int           `json:"metrics_port"`
+	MetricsReadTimeout         time.Duration `json:"metrics_read_timeout"`
+	MetricsWriteTimeout        time.Duration `json:"metrics_write_timeout"`
+	MetricsIdleTimeout         time.Duration `json:"metrics_idle_timeout"`
+	MetricsBindRequired        bool          `json:"metrics_bind_required"`
 	ReadTimeout                time.Duration `json:"read_timeout"`
 	WriteTimeout               time.Duration `json:"write_timeout"`
+	ShutdownOrder              []string      `json:"shutdown_order"`
+	ShutdownServerTimeout      time.Duration `json:"shutdown_server_timeout"`
+	ShutdownMetricsTimeout     time.Duration `json:"shutdown_metrics_timeout"`
+	ShutdownStorageTimeout     time.Duration `json:"shutdown_storage_timeout"`
+	ShutdownWorkerTimeout      time.Duration `json:"shutdown_worker_timeout"`
 	RateLimitRPS               int           `json:"rate_limit_rps"`
 	SimilarityThreshold        float32       `json:"similarity_threshold"`
 	MinExamplesBeforeExclusion int           `json:"min_examples_before_exclusion"`
+	DuplicateFlushInterval     time.Duration `json:"duplicate_flush_interval"`
+	ExactDedupCacheSize        int           `json:"exact_dedup_cache_size"`
+	DedupMaxMatchAge           time.Duration `json:"dedup_max_match_age"`
+	DedupWindow                time.Duration `json:"dedup_window"`
+	DedupBypassLatency         time.Duration `json:"dedup_bypass_latency"`
+	DedupWarmup                time.Duration `json:"dedup_warmup"`
+	IdempotencyKeys            bool          `json:"idempotency_keys"`
+	StoreContentHash           bool          `json:"store_content_hash"`
+	CollectionLoadMode         string        `json:"collection_load_mode"`
 	NumWorkers                 int           `json:"num_workers"`
+	NoEmbedSources             []string      `json:"no_embed_sources"`
+	EmbedIncludeSource         bool          `json:"embed_include_source"`
+	StoreMessageTemplate       bool          `json:"store_message_template"`
+	EmbedMessageTemplate       bool          `json:"embed_message_template"`
+	RecordSimilarCount         bool          `json:"record_similar_count"`
+	TimestampBucket            time.Duration `json:"timestamp_bucket"`
+	HealthCheckCacheTTL        time.Duration `json:"health_check_cache_ttl"`
+	FlattenMetadata            bool          `json:"flatten_metadata"`
+	SkipEmptyMessages          bool          `json:"skip_empty_messages"`
+	LogSchemaFile              string        `json:"log_schema_file"`
+	LogSchemaStrict            bool          `json:"log_schema_strict"`
+	RequiredMetadataKeys       []string      `json:"required_metadata_keys"`
+	IngestDropPatterns         []string      `json:"ingest_drop_patterns"`
+	DecodeBase64               bool          `json:"decode_base64"`
+	DetectStackTraces          bool          `json:"detect_stack_traces"`
+	CompressMetadata           bool          `json:"compress_metadata"`
+	MetadataScalarFields       []string      `json:"metadata_scalar_fields"`
+	ComponentFields            []string      `json:"component_fields"`
+	EnvScalarField             bool          `json:"env_scalar_field"`
+	AllowAdminPurge            bool          `json:"allow_admin_purge"`
+	AllowAdminFlush            bool          `json:"allow_admin_flush"`
+	AuditActorHeader           string        `json:"audit_actor_header"`
+	AuditSink                  string        `json:"audit_sink"`
+	SigningSecret              string        `json:"signing_secret"`
+	ExposeConfig               bool          `json:"expose_config"`
+	CORSAllowedOrigins         []string      `json:"cors_allowed_origins"`
+	MaxFutureSkew              time.Duration `json:"max_future_skew"`
+	MaxAge                     time.Duration `json:"max_age"`
+	ClampFutureTimestamps      bool          `json:"clamp_future_timestamps"`
+	DLQEndpoint                string        `json:"dlq_endpoint"`
+	DLQMaxBytes                int64         `json:"dlq_max_bytes"`
+	ColdStorageEndpoint        string        `json:"cold_storage_endpoint"`
+	ColdStorageMaxBytes        int64         `json:"cold_storage_max_bytes"`
+	GRPCPort                   int           `json:"grpc_port"`
+	OTLPEndpoint               string        `json:"otlp_endpoint"`
+
+	// SimilarityThresholds overrides SimilarityThreshold for specific sources
+	SimilarityThresholds map[string]float32 `json:"similarity_thresholds,omitempty"`
+
+	// dropPatterns holds IngestDropPatterns compiled by Validate
+	dropPatterns []*regexp.Regexp
 }
 
 func NewConfig() *Config {
@@ -32,19 +112,89 @@ func NewConfig() *Config {
 		ServerPort:                 getEnvAsInt("SERVER_PORT", 8080),
 		LogLevel:                   getEnv("LOG_LEVEL", "info"),
 		MilvusAddress:              getEnv("MILVUS_ADDRESS", "milvus:19530"),
+		ShardNum:                   getEnvAsInt("SHARD_NUM", 1),
+		SearchEf:                   getEnvAsInt("SEARCH_EF", 0), // 0 = Milvus default
+		AutoRecreate:               getEnvAsBool("AUTO_RECREATE", false),
 		EmbeddingEndpoint:          getEnv("EMBEDDING_ENDPOINT", "http://embedding-service:8080/embed"),
 		EmbeddingModel:             getEnv("EMBEDDING_MODEL", "nomic-embed-text-v1.5"),
 		EmbeddingDimension:         getEnvAsInt("EMBEDDING_DIMENSION", 768),
+		EmbeddingMaxBatch:          getEnvAsInt("EMBEDDING_MAX_BATCH", 0),  // 0 = unlimited
+		EmbeddingCacheSize:         getEnvAsInt("EMBEDDING_CACHE_SIZE", 0), // 0 = disabled
+		EmbeddingCACert:            getEnv("EMBEDDING_CA_CERT", ""),
+		EmbeddingSkipVerify:        getEnvAsBool("EMBEDDING_INSECURE_SKIP_VERIFY", false),
+		EmbeddingMaxConcurrency:    getEnvAsInt("EMBEDDING_MAX_CONCURRENCY", 0), // 0 = unlimited
+		EmbedChunking:              getEnvAsBool("EMBED_CHUNKING", false),
+		EmbedChunkSize:             getEnvAsInt("EMBED_CHUNK_SIZE", 2000),
+		EmbedChunkOverlap:          getEnvAsInt("EMBED_CHUNK_OVERLAP", 200),
 		BatchSize:                  getEnvAsInt("BATCH_SIZE", 100),
 		BatchTimeout:               getEnvAsDuration("BATCH_TIMEOUT", 5*time.Second),
 		MaxRequestSize:             getEnvAsInt64("MAX_REQUEST_SIZE", 10*1024*1024), // 10MB
+		MaxConnections:             getEnvAsInt("MAX_CONNECTIONS", 0),               // 0 = unlimited
+		MaxLineSize:                getEnvAsInt("MAX_LINE_SIZE", 1024*1024),         // 1MB
+		InvalidLineLogInterval:     getEnvAsDuration("INVALID_LINE_LOG_INTERVAL", time.Second),
+		QueueSize:                  getEnvAsInt("QUEUE_SIZE", 10000),
+		AsyncStorage:               getEnvAsBool("ASYNC_STORAGE", false),
 		MetricsPort:                getEnvAsInt("METRICS_PORT", 9090),
+		MetricsReadTimeout:         getEnvAsDuration("METRICS_READ_TIMEOUT", 5*time.Second),
+		MetricsWriteTimeout:        getEnvAsDuration("METRICS_WRITE_TIMEOUT", 10*time.Second),
+		MetricsIdleTimeout:         getEnvAsDuration("METRICS_IDLE_TIMEOUT", 15*time.Second),
+		MetricsBindRequired:        getEnvAsBool("METRICS_BIND_REQUIRED", false),
 		ReadTimeout:                getEnvAsDuration("READ_TIMEOUT", 10*time.Second),
 		WriteTimeout:               getEnvAsDuration("WRITE_TIMEOUT", 10*time.Second),
+		ShutdownOrder:              getEnvAsStringSlice("SHUTDOWN_ORDER", append([]string(nil), ShutdownPhases...)),
+		ShutdownServerTimeout:      getEnvAsDuration("SHUTDOWN_SERVER_TIMEOUT", 10*time.Second),
+		ShutdownMetricsTimeout:     getEnvAsDuration("SHUTDOWN_METRICS_TIMEOUT", 5*time.Second),
+		ShutdownStorageTimeout:     getEnvAsDuration("SHUTDOWN_STORAGE_TIMEOUT", 10*time.Second),
+		ShutdownWorkerTimeout:      getEnvAsDuration("SHUTDOWN_WORKER_TIMEOUT", 5*time.Second),
 		RateLimitRPS:               getEnvAsInt("RATE_LIMIT_RPS", 1000),
 		SimilarityThreshold:        getEnvAsFloat32("SIMILARITY_THRESHOLD", 0.95),
 		MinExamplesBeforeExclusion: getEnvAsInt("MIN_EXAMPLES_BEFORE_EXCLUSION", 3),
+		DuplicateFlushInterval:     getEnvAsDuration("DUPLICATE_FLUSH_INTERVAL", 0), // 0 = update per duplicate
+		ExactDedupCacheSize:        getEnvAsInt("EXACT_DEDUP_CACHE_SIZE", 0),        // 0 = disabled
+		DedupMaxMatchAge:           getEnvAsDuration("DEDUP_MAX_MATCH_AGE", 0),      // 0 = no limit
+		DedupWindow:                getEnvAsDuration("DEDUP_WINDOW", 0),             // 0 = search all logs
+		DedupBypassLatency:         getEnvAsDuration("DEDUP_BYPASS_LATENCY", 0),     // 0 = disabled
+		DedupWarmup:                getEnvAsDuration("DEDUP_WARMUP", 0),             // 0 = disabled
+		IdempotencyKeys:            getEnvAsBool("IDEMPOTENCY_KEYS", false),
+		StoreContentHash:           getEnvAsBool("STORE_CONTENT_HASH", false),
+		CollectionLoadMode:         getEnv("COLLECTION_LOAD_MODE", defaultCollectionLoadMode()),
+		SimilarityThresholds:       getEnvAsFloat32Map("SIMILARITY_THRESHOLDS", nil),
 		NumWorkers:                 getEnvAsInt("NUM_WORKERS", 4),
+		NoEmbedSources:             getEnvAsStringSlice("NO_EMBED_SOURCES", nil),
+		EmbedIncludeSource:         getEnvAsBool("EMBED_INCLUDE_SOURCE", false),
+		StoreMessageTemplate:       getEnvAsBool("STORE_MESSAGE_TEMPLATE", false),
+		EmbedMessageTemplate:       getEnvAsBool("EMBED_MESSAGE_TEMPLATE", false),
+		RecordSimilarCount:         getEnvAsBool("RECORD_SIMILAR_COUNT", false),
+		TimestampBucket:            getEnvAsDuration("TIMESTAMP_BUCKET", 0),       // 0 = disabled
+		HealthCheckCacheTTL:        getEnvAsDuration("HEALTH_CHECK_CACHE_TTL", 0), // 0 = disabled
+		FlattenMetadata:            getEnvAsBool("FLATTEN_METADATA", false),
+		SkipEmptyMessages:          getEnvAsBool("SKIP_EMPTY_MESSAGES", true),
+		LogSchemaFile:              getEnv("LOG_SCHEMA_FILE", ""), // empty = disabled
+		LogSchemaStrict:            getEnvAsBool("LOG_SCHEMA_STRICT", false),
+		RequiredMetadataKeys:       getEnvAsStringSlice("REQUIRED_METADATA_KEYS", nil),
+		IngestDropPatterns:         getEnvAsLines("INGEST_DROP_PATTERNS"),
+		DecodeBase64:               getEnvAsBool("DECODE_BASE64", false),
+		DetectStackTraces:          getEnvAsBool("DETECT_STACK_TRACES", false),
+		CompressMetadata:           getEnvAsBool("COMPRESS_METADATA", false),
+		MetadataScalarFields:       getEnvAsStringSlice("METADATA_SCALAR_FIELDS", nil),
+		ComponentFields:            getEnvAsStringSlice("COMPONENT_FIELDS", nil),
+		EnvScalarField:             getEnvAsBool("ENV_SCALAR_FIELD", false),
+		AllowAdminPurge:            getEnvAsBool("ALLOW_ADMIN_PURGE", false),
+		AllowAdminFlush:            getEnvAsBool("ALLOW_ADMIN_FLUSH", false),
+		AuditActorHeader:           getEnv("AUDIT_ACTOR_HEADER", "X-Forwarded-User"),
+		AuditSink:                  getEnv("AUDIT_SINK", ""),     // empty = log only
+		SigningSecret:              getEnv("SIGNING_SECRET", ""), // empty = signatures not verified
+		ExposeConfig:               getEnvAsBool("EXPOSE_CONFIG", false),
+		CORSAllowedOrigins:         getEnvAsStringSlice("CORS_ALLOWED_ORIGINS", []string{"*"}),
+		MaxFutureSkew:              getEnvAsDuration("MAX_FUTURE_SKEW", time.Hour),
+		MaxAge:                     getEnvAsDuration("MAX_AGE", 10*365*24*time.Hour),
+		ClampFutureTimestamps:      getEnvAsBool("CLAMP_FUTURE_TIMESTAMPS", false),
+		DLQEndpoint:                getEnv("DLQ_ENDPOINT", ""),                    // empty = disabled
+		DLQMaxBytes:                getEnvAsInt64("DLQ_MAX_BYTES", 100*1024*1024), // 100MB
+		GRPCPort:                   getEnvAsInt("GRPC_PORT", 0),                   // 0 = disabled
+		OTLPEndpoint:               getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),     // empty = tracing disabled
+		ColdStorageEndpoint:        getEnv("COLD_STORAGE_ENDPOINT", ""),
+		ColdStorageMaxBytes:        getEnvAsInt64("COLD_STORAGE_MAX_BYTES", 1024*1024*1024),
 	}
 }
 
@@ -70,6 +220,12 @@ func (c *Config) Validate() error {
 	if c.EmbeddingDimension <= 0 {
 		return &ConfigError{Field: "EMBEDDING_DIMENSION", Message: "must be greater than 0"}
 	}
+	if c.EmbeddingMaxBatch < 0 {
+		return &ConfigError{Field: "EMBEDDING_MAX_BATCH", Message: "must be 0 (unlimited) or greater"}
+	}
+	if c.EmbeddingCacheSize < 0 {
+		return &ConfigError{Field: "EMBEDDING_CACHE_SIZE", Message: "must be 0 (disabled) or greater"}
+	}
 	if c.SimilarityThreshold < 0 || c.SimilarityThreshold > 1 {
 		return &ConfigError{Field: "SIMILARITY_THRESHOLD", Message: "must be between 0 and 1"}
 	}
@@ -79,10 +235,250 @@ func (c *Config) Validate() error {
 	if c.NumWorkers <= 0 {
 		return &ConfigError{Field: "NUM_WORKERS", Message: "must be greater than 0"}
 	}
+	if c.MaxFutureSkew <= 0 {
+		return &ConfigError{Field: "MAX_FUTURE_SKEW", Message: "must be greater than 0"}
+	}
+	if c.MaxAge <= 0 {
+		return &ConfigError{Field: "MAX_AGE", Message: "must be greater than 0"}
+	}
+	if c.MaxLineSize <= 0 {
+		return &ConfigError{Field: "MAX_LINE_SIZE", Message: "must be greater than 0"}
+	}
+	if c.DLQMaxBytes <= 0 {
+		return &ConfigError{Field: "DLQ_MAX_BYTES", Message: "must be greater than 0"}
+	}
+	if c.GRPCPort < 0 || c.GRPCPort > 65535 {
+		return &ConfigError{Field: "GRPC_PORT", Message: "must be 0 (disabled) or between 1 and 65535"}
+	}
+	if c.DuplicateFlushInterval < 0 {
+		return &ConfigError{Field: "DUPLICATE_FLUSH_INTERVAL", Message: "must be 0 (disabled) or greater"}
+	}
+	if c.QueueSize <= 0 {
+		return &ConfigError{Field: "QUEUE_SIZE", Message: "must be greater than 0"}
+	}
+	if c.ExactDedupCacheSize < 0 {
+		return &ConfigError{Field: "EXACT_DEDUP_CACHE_SIZE", Message: "must be 0 (disabled) or greater"}
+	}
+	if c.MetricsReadTimeout <= 0 {
+		return &ConfigError{Field: "METRICS_READ_TIMEOUT", Message: "must be greater than 0"}
+	}
+	if c.MetricsWriteTimeout <= 0 {
+		return &ConfigError{Field: "METRICS_WRITE_TIMEOUT", Message: "must be greater than 0"}
+	}
+	if c.MetricsIdleTimeout <= 0 {
+		return &ConfigError{Field: "METRICS_IDLE_TIMEOUT", Message: "must be greater than 0"}
+	}
+	if c.EmbeddingMaxConcurrency < 0 {
+		return &ConfigError{Field: "EMBEDDING_MAX_CONCURRENCY", Message: "must be 0 (unlimited) or greater"}
+	}
+	if c.DedupMaxMatchAge < 0 {
+		return &ConfigError{Field: "DEDUP_MAX_MATCH_AGE", Message: "must be 0 (no limit) or greater"}
+	}
+	for source, threshold := range c.SimilarityThresholds {
+		if threshold < 0 || threshold > 1 {
+			return &ConfigError{Field: "SIMILARITY_THRESHOLDS", Message: "threshold for source " + source + " must be between 0 and 1"}
+		}
+	}
+	if c.ShardNum < 1 {
+		return &ConfigError{Field: "SHARD_NUM", Message: "must be at least 1"}
+	}
+	if c.SearchEf < 0 {
+		return &ConfigError{Field: "SEARCH_EF", Message: "must be 0 (Milvus default) or greater"}
+	}
+	if c.ColdStorageMaxBytes <= 0 {
+		return &ConfigError{Field: "COLD_STORAGE_MAX_BYTES", Message: "must be greater than 0"}
+	}
+	if c.DedupBypassLatency < 0 {
+		return &ConfigError{Field: "DEDUP_BYPASS_LATENCY", Message: "must be 0 (disabled) or greater"}
+	}
+	if c.DedupWarmup < 0 {
+		return &ConfigError{Field: "DEDUP_WARMUP", Message: "must be 0 (disabled) or greater"}
+	}
+	if c.AuditSink != "" && c.AuditSink != "dlq" && c.AuditSink != "cold" {
+		return &ConfigError{Field: "AUDIT_SINK", Message: "must be empty, dlq or cold"}
+	}
+	if c.EmbedChunking {
+		if c.EmbedChunkSize <= 0 {
+			return &ConfigError{Field: "EMBED_CHUNK_SIZE", Message: "must be greater than 0"}
+		}
+		if c.EmbedChunkOverlap < 0 || c.EmbedChunkOverlap >= c.EmbedChunkSize {
+			return &ConfigError{Field: "EMBED_CHUNK_OVERLAP", Message: "must be 0 or greater and less than EMBED_CHUNK_SIZE"}
+		}
+	}
+	if c.MaxConnections < 0 {
+		return &ConfigError{Field: "MAX_CONNECTIONS", Message: "must be 0 (unlimited) or greater"}
+	}
+	if c.TimestampBucket != 0 && c.TimestampBucket < time.Millisecond {
+		return &ConfigError{Field: "TIMESTAMP_BUCKET", Message: "must be 0 (disabled) or at least 1ms"}
+	}
+	if c.HealthCheckCacheTTL < 0 {
+		return &ConfigError{Field: "HEALTH_CHECK_CACHE_TTL", Message: "must be 0 (disabled) or greater"}
+	}
+	if c.DedupWindow < 0 {
+		return &ConfigError{Field: "DEDUP_WINDOW", Message: "must be 0 (disabled) or greater"}
+	}
+	switch c.CollectionLoadMode {
+	case "eager", "lazy", "none":
+	default:
+		return &ConfigError{Field: "COLLECTION_LOAD_MODE", Message: "must be eager, lazy or none"}
+	}
+	if c.InvalidLineLogInterval < 0 {
+		return &ConfigError{Field: "INVALID_LINE_LOG_INTERVAL", Message: "must be 0 (log every line) or greater"}
+	}
+	if err := validateShutdownOrder(c.ShutdownOrder); err != nil {
+		return err
+	}
+	if c.ShutdownServerTimeout <= 0 {
+		return &ConfigError{Field: "SHUTDOWN_SERVER_TIMEOUT", Message: "must be greater than 0"}
+	}
+	if c.ShutdownMetricsTimeout <= 0 {
+		return &ConfigError{Field: "SHUTDOWN_METRICS_TIMEOUT", Message: "must be greater than 0"}
+	}
+	if c.ShutdownStorageTimeout <= 0 {
+		return &ConfigError{Field: "SHUTDOWN_STORAGE_TIMEOUT", Message: "must be greater than 0"}
+	}
+	if c.ShutdownWorkerTimeout <= 0 {
+		return &ConfigError{Field: "SHUTDOWN_WORKER_TIMEOUT", Message: "must be greater than 0"}
+	}
+	c.dropPatterns = nil
+	for _, pattern := range c.IngestDropPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return &ConfigError{Field: "INGEST_DROP_PATTERNS", Message: "invalid pattern " + strconv.Quote(pattern) + ": " + err.Error()}
+		}
+		c.dropPatterns = append(c.dropPatterns, re)
+	}
+	// gRPC entries carry no signature, so they would bypass verification
+	if c.SigningSecret != "" && c.GRPCPort != 0 {
+		return &ConfigError{Field: "GRPC_PORT", Message: "must be 0 when SIGNING_SECRET is set, as gRPC streams are not signed"}
+	}
 
 	return nil
 }
 
+// defaultCollectionLoadMode keeps PRELOAD_COLLECTION working for deployments
+// that do not set COLLECTION_LOAD_MODE
+func defaultCollectionLoadMode() string {
+	if getEnvAsBool("PRELOAD_COLLECTION", false) {
+		return "eager"
+	}
+	return "lazy"
+}
+
+// ShutdownPhases are the graceful-shutdown phases in their default order:
+// stop accepting requests, stop the metrics server, store what is still
+// queued, then stop the background workers
+var ShutdownPhases = []string{"server", "metrics", "storage", "worker"}
+
+// validateShutdownOrder requires order to name every shutdown phase exactly once
+func validateShutdownOrder(order []string) error {
+	seen := make(map[string]bool, len(order))
+	for _, phase := range order {
+		if !slices.Contains(ShutdownPhases, phase) || seen[phase] {
+			return &ConfigError{Field: "SHUTDOWN_ORDER", Message: "invalid or repeated phase " + strconv.Quote(phase)}
+		}
+		seen[phase] = true
+	}
+	if len(seen) != len(ShutdownPhases) {
+		return &ConfigError{Field: "SHUTDOWN_ORDER", Message: "must list each of " + strings.Join(ShutdownPhases, ", ")}
+	}
+	return nil
+}
+
+// DropPatterns returns INGEST_DROP_PATTERNS as compiled by Validate
+func (c *Config) DropPatterns() []*regexp.Regexp {
+	return c.dropPatterns
+}
+
+// ScalarFields returns the metadata keys promoted to Milvus scalar fields,
+// including the extracted component when component extraction is enabled and
+// the environment name when ENV_SCALAR_FIELD is set
+func (c *Config) ScalarFields() []string {
+	fields := append([]string(nil), c.MetadataScalarFields...)
+	if len(c.ComponentFields) > 0 {
+		fields = appendMissing(fields, models.ComponentKey)
+	}
+	if c.EnvScalarField {
+		fields = appendMissing(fields, models.EnvKey)
+	}
+	return fields
+}
+
+// appendMissing appends field unless fields already contains it
+func appendMissing(fields []string, field string) []string {
+	for _, existing := range fields {
+		if existing == field {
+			return fields
+		}
+	}
+	return append(fields, field)
+}
+
+// redactedValue replaces credentials in redacted configuration
+const redactedValue = "REDACTED"
+
+// Redacted returns a copy of the configuration that is safe to expose, with
+// credentials embedded in connection addresses (user info and sensitive query
+// parameters such as api_key or token) replaced by REDACTED
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	redacted.MilvusAddress = redactCredentials(c.MilvusAddress)
+	redacted.DLQEndpoint = redactCredentials(c.DLQEndpoint)
+	redacted.ColdStorageEndpoint = redactCredentials(c.ColdStorageEndpoint)
+	redacted.OTLPEndpoint = redactCredentials(c.OTLPEndpoint)
+	if c.SigningSecret != "" {
+		redacted.SigningSecret = redactedValue
+	}
+
+	endpoints := strings.Split(c.EmbeddingEndpoint, ",")
+	for i, endpoint := range endpoints {
+		endpoints[i] = redactCredentials(endpoint)
+	}
+	redacted.EmbeddingEndpoint = strings.Join(endpoints, ",")
+
+	return &redacted
+}
+
+// redactCredentials strips credentials from an address, which may be a URL or
+// a bare host:port optionally prefixed with user:password@
+func redactCredentials(address string) string {
+	if !strings.Contains(address, "://") {
+		if i := strings.LastIndex(address, "@"); i >= 0 {
+			return redactedValue + address[i:]
+		}
+		return address
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return redactedValue
+	}
+	if u.User != nil {
+		u.User = url.User(redactedValue)
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			if isSensitiveKey(key) {
+				query.Set(key, redactedValue)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// isSensitiveKey reports whether a query parameter name looks like a credential
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, marker := range []string{"key", "token", "secret", "password", "auth"} {
+		if strings.Contains(key, marker) {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Config) SetupLogging() {
 	level, err := logrus.ParseLevel(c.LogLevel)
 	if err != nil {
@@ -151,3 +547,68 @@ func getEnvAsFloat32(key string, defaultValue float32) float32 {
 	}
 	return defaultValue
 }
+
+// getEnvAsFloat32Map parses a comma-separated list of key=value pairs,
+// skipping malformed items
+func getEnvAsFloat32Map(key string, defaultValue map[string]float32) map[string]float32 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	items := make(map[string]float32)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, raw, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if ok && name != "" {
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(raw), 32); err == nil {
+				items[name] = float32(parsed)
+				continue
+			}
+		}
+		logrus.WithField("key", key).WithField("value", item).Warn("Invalid key=value item, skipping")
+	}
+	return items
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+		logrus.WithField("key", key).WithField("value", value).Warn("Invalid boolean value, using default")
+	}
+	return defaultValue
+}
+
+// getEnvAsLines splits a newline-separated value, for lists whose items may
+// contain commas; blank lines are ignored
+func getEnvAsLines(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), "\n") {
+		if trimmed := strings.TrimSpace(item); trimmed != "" {
+			items = append(items, trimmed)
+		}
+	}
+	return items
+}
+
+// getEnvAsStringSlice parses a comma-separated list, ignoring empty items
+func getEnvAsStringSlice(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if trimmed := strings.TrimSpace(item); trimmed != "" {
+			items = append(items, trimmed)
+		}
+	}
+	return items
+}
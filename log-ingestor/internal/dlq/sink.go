@@ -0,0 +1,181 @@
+package dlq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+// DefaultMaxBytes bounds how much data a dead-letter sink accepts when no limit is configured
+const DefaultMaxBytes = 100 * 1024 * 1024
+
+// Sink receives log entries that could not be stored. Entries are written as
+// NDJSON in the same format accepted by the stream endpoint, so a dead-letter
+// file can be replayed by POSTing it back to /api/v1/logs/stream.
+type Sink interface {
+	Write(ctx context.Context, entries []*models.LogEntry) error
+}
+
+// NewSink creates a sink for endpoint. HTTP(S) URLs are posted to; anything else
+// (optionally prefixed with file://) is treated as a file path that is appended to.
+// An empty endpoint disables dead-lettering and returns a nil Sink.
+func NewSink(endpoint string, maxBytes int64, logger *logrus.Logger) (Sink, error) {
+	if endpoint == "" {
+		return nil, nil
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+
+	if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
+		return &HTTPSink{
+			url:      endpoint,
+			client:   &http.Client{Timeout: 10 * time.Second},
+			maxBytes: maxBytes,
+			logger:   logger,
+		}, nil
+	}
+
+	path := strings.TrimPrefix(endpoint, "file://")
+	written := int64(0)
+	if info, err := os.Stat(path); err == nil {
+		written = info.Size()
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to stat dead-letter file: %w", err)
+	}
+
+	return &FileSink{
+		path:     path,
+		maxBytes: maxBytes,
+		written:  written,
+		logger:   logger,
+	}, nil
+}
+
+// encode renders entries as NDJSON, dropping entries that would push the sink
+// past maxBytes. It returns the encoded data and the number of dropped entries.
+func encode(entries []*models.LogEntry, written, maxBytes int64) ([]byte, int, error) {
+	var buf bytes.Buffer
+	for i, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to marshal entry: %w", err)
+		}
+		if written+int64(buf.Len()+len(line)+1) > maxBytes {
+			return buf.Bytes(), len(entries) - i, nil
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), 0, nil
+}
+
+// FileSink appends dead-lettered entries to a local NDJSON file of bounded size
+type FileSink struct {
+	path     string
+	maxBytes int64
+	logger   *logrus.Logger
+
+	mu      sync.Mutex
+	written int64
+}
+
+func (s *FileSink) Write(ctx context.Context, entries []*models.LogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, dropped, err := encode(entries, s.written, s.maxBytes)
+	if err != nil {
+		return err
+	}
+	if dropped > 0 {
+		s.logger.WithFields(logrus.Fields{
+			"path":      s.path,
+			"dropped":   dropped,
+			"max_bytes": s.maxBytes,
+		}).Warn("Dead-letter file is full, dropping entries")
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open dead-letter file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	n, err := f.Write(data)
+	s.written += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write dead-letter file: %w", err)
+	}
+
+	return nil
+}
+
+// HTTPSink posts dead-lettered entries as NDJSON to a remote endpoint,
+// accepting at most maxBytes over the lifetime of the process
+type HTTPSink struct {
+	url      string
+	client   *http.Client
+	maxBytes int64
+	logger   *logrus.Logger
+
+	mu   sync.Mutex
+	sent int64
+}
+
+func (s *HTTPSink) Write(ctx context.Context, entries []*models.LogEntry) error {
+	s.mu.Lock()
+	data, dropped, err := encode(entries, s.sent, s.maxBytes)
+	if err == nil {
+		s.sent += int64(len(data))
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	if dropped > 0 {
+		s.logger.WithFields(logrus.Fields{
+			"url":       s.url,
+			"dropped":   dropped,
+			"max_bytes": s.maxBytes,
+		}).Warn("Dead-letter endpoint quota exhausted, dropping entries")
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create dead-letter request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send dead-letter request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("dead-letter endpoint returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Ensure sinks implement Sink
+var _ Sink = (*FileSink)(nil)
+var _ Sink = (*HTTPSink)(nil)
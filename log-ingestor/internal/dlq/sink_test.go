@@ -0,0 +1,165 @@
+package dlq
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+func testEntries() []*models.LogEntry {
+	return []*models.LogEntry{
+		{Timestamp: 1700000000000, Message: "first", Source: "api"},
+		{Timestamp: 1700000000001, Message: "second", Source: "api", Metadata: map[string]interface{}{"level": "ERROR"}},
+	}
+}
+
+// readNDJSON decodes every line of an NDJSON stream
+func readNDJSON(t *testing.T, r io.Reader) []models.LogEntry {
+	var entries []models.LogEntry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var entry models.LogEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.NoError(t, scanner.Err())
+	return entries
+}
+
+func TestNewSink_Disabled(t *testing.T) {
+	sink, err := NewSink("", 0, logrus.New())
+
+	require.NoError(t, err)
+	assert.Nil(t, sink)
+}
+
+func TestNewSink_SelectsImplementation(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		endpoint string
+		expected Sink
+	}{
+		{"http://dlq.local/ingest", &HTTPSink{}},
+		{"https://dlq.local/ingest", &HTTPSink{}},
+		{"file://" + filepath.Join(dir, "a.ndjson"), &FileSink{}},
+		{filepath.Join(dir, "b.ndjson"), &FileSink{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			sink, err := NewSink(tt.endpoint, 0, logrus.New())
+			require.NoError(t, err)
+			assert.IsType(t, tt.expected, sink)
+		})
+	}
+}
+
+func TestFileSink_Write(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dlq.ndjson")
+	sink, err := NewSink("file://"+path, 0, logrus.New())
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Write(context.Background(), testEntries()))
+	require.NoError(t, sink.Write(context.Background(), testEntries()[:1]))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	entries := readNDJSON(t, f)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "first", entries[0].Message)
+	assert.Equal(t, "ERROR", entries[1].Metadata["level"])
+	assert.Equal(t, "first", entries[2].Message)
+}
+
+func TestFileSink_BoundedSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dlq.ndjson")
+
+	line, err := json.Marshal(testEntries()[0])
+	require.NoError(t, err)
+
+	// Room for exactly one line
+	sink, err := NewSink(path, int64(len(line)+1), logrus.New())
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Write(context.Background(), testEntries()))
+	require.NoError(t, sink.Write(context.Background(), testEntries()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(line)+"\n", string(data))
+}
+
+func TestFileSink_CountsExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dlq.ndjson")
+	require.NoError(t, os.WriteFile(path, []byte("existing\n"), 0o644))
+
+	sink, err := NewSink(path, 9, logrus.New())
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Write(context.Background(), testEntries()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "existing\n", string(data))
+}
+
+func TestHTTPSink_Write(t *testing.T) {
+	var received []models.LogEntry
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		received = append(received, readNDJSON(t, r.Body)...)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sink, err := NewSink(server.URL, 0, logrus.New())
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Write(context.Background(), testEntries()))
+
+	require.Len(t, received, 2)
+	assert.Equal(t, "second", received[1].Message)
+}
+
+func TestHTTPSink_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sink, err := NewSink(server.URL, 0, logrus.New())
+	require.NoError(t, err)
+
+	err = sink.Write(context.Background(), testEntries())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "dead-letter endpoint returned status 500")
+}
+
+func TestHTTPSink_QuotaExhausted(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	sink, err := NewSink(server.URL, 1, logrus.New())
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Write(context.Background(), testEntries()))
+	assert.Equal(t, 0, requests)
+}
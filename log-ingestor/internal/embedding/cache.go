@@ -0,0 +1,158 @@
+package embedding
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CachingService wraps an embedding Interface with an in-memory LRU cache keyed
+// by input text. Repeated log messages are common, so caching avoids embedding
+// the same text over and over.
+type CachingService struct {
+	next    Interface
+	size    int
+	metrics *CacheMetrics
+
+	// hitCount and missCount back the hit-ratio gauge
+	hitCount  atomic.Uint64
+	missCount atomic.Uint64
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // front is most recently used
+}
+
+type cacheEntry struct {
+	text      string
+	embedding []float32
+}
+
+type CacheMetrics struct {
+	hits     prometheus.Counter
+	misses   prometheus.Counter
+	hitRatio prometheus.GaugeFunc
+}
+
+// NewCachingService creates a cache of at most size embeddings in front of next.
+// Metrics are registered with registerer, ignoring duplicate registration errors.
+func NewCachingService(next Interface, size int, registerer prometheus.Registerer) *CachingService {
+	c := &CachingService{
+		next:    next,
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+
+	c.metrics = &CacheMetrics{
+		hits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "log_ingestor_embedding_cache_hits_total",
+			Help: "Total number of embeddings served from the cache",
+		}),
+		misses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "log_ingestor_embedding_cache_misses_total",
+			Help: "Total number of embeddings fetched from the embedding service",
+		}),
+	}
+	c.metrics.hitRatio = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "log_ingestor_embedding_cache_hit_ratio",
+		Help: "Ratio of embedding lookups served from the cache",
+	}, c.HitRatio)
+
+	_ = registerer.Register(c.metrics.hits)
+	_ = registerer.Register(c.metrics.misses)
+	_ = registerer.Register(c.metrics.hitRatio)
+
+	return c
+}
+
+// GetEmbeddings serves cached embeddings and fetches the rest in a single request
+func (c *CachingService) GetEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+
+	var missTexts []string
+	var missIdx []int
+	c.mu.Lock()
+	for i, text := range texts {
+		if elem, ok := c.entries[text]; ok {
+			c.order.MoveToFront(elem)
+			embeddings[i] = elem.Value.(*cacheEntry).embedding
+			continue
+		}
+		missTexts = append(missTexts, text)
+		missIdx = append(missIdx, i)
+	}
+	c.mu.Unlock()
+
+	hits := len(texts) - len(missTexts)
+	c.hitCount.Add(uint64(hits))
+	c.missCount.Add(uint64(len(missTexts)))
+	c.metrics.hits.Add(float64(hits))
+	c.metrics.misses.Add(float64(len(missTexts)))
+
+	if len(missTexts) == 0 && len(texts) > 0 {
+		return embeddings, nil
+	}
+
+	// Let the wrapped service validate empty input and report its own errors
+	fetched, err := c.next.GetEmbeddings(ctx, missTexts)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	for j, i := range missIdx {
+		embeddings[i] = fetched[j]
+		c.add(missTexts[j], fetched[j])
+	}
+	c.mu.Unlock()
+
+	return embeddings, nil
+}
+
+// GetEmbedding retrieves the embedding for a single text, using the cache when possible
+func (c *CachingService) GetEmbedding(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := c.GetEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// HealthCheck bypasses the cache and checks the wrapped service
+func (c *CachingService) HealthCheck(ctx context.Context) error {
+	return c.next.HealthCheck(ctx)
+}
+
+// HitRatio returns the fraction of lookups served from the cache, or 0 before any lookups
+func (c *CachingService) HitRatio() float64 {
+	hits := c.hitCount.Load()
+	total := hits + c.missCount.Load()
+	if total == 0 {
+		return 0
+	}
+	return float64(hits) / float64(total)
+}
+
+// add inserts an embedding, evicting the least recently used entry when full.
+// Callers must hold c.mu.
+func (c *CachingService) add(text string, embedding []float32) {
+	if elem, ok := c.entries[text]; ok {
+		c.order.MoveToFront(elem)
+		elem.Value.(*cacheEntry).embedding = embedding
+		return
+	}
+
+	c.entries[text] = c.order.PushFront(&cacheEntry{text: text, embedding: embedding})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).text)
+	}
+}
+
+// Ensure CachingService implements Interface
+var _ Interface = (*CachingService)(nil)
@@ -0,0 +1,126 @@
+package embedding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockEmbedder is a mock implementation of Interface
+type MockEmbedder struct {
+	mock.Mock
+}
+
+func (m *MockEmbedder) GetEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	args := m.Called(ctx, texts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([][]float32), args.Error(1)
+}
+
+func (m *MockEmbedder) GetEmbedding(ctx context.Context, text string) ([]float32, error) {
+	args := m.Called(ctx, text)
+	return args.Get(0).([]float32), args.Error(1)
+}
+
+func (m *MockEmbedder) HealthCheck(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func TestCachingService_HitsAndMisses(t *testing.T) {
+	next := new(MockEmbedder)
+	cache := NewCachingService(next, 10, prometheus.NewRegistry())
+
+	next.On("GetEmbeddings", mock.Anything, []string{"a", "b"}).
+		Return([][]float32{{1}, {2}}, nil).Once()
+	next.On("GetEmbeddings", mock.Anything, []string{"c"}).
+		Return([][]float32{{3}}, nil).Once()
+
+	embeddings, err := cache.GetEmbeddings(context.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1}, {2}}, embeddings)
+
+	// "a" and "b" are served from the cache, only "c" is fetched
+	embeddings, err = cache.GetEmbeddings(context.Background(), []string{"b", "c", "a"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{2}, {3}}, embeddings[:2])
+	assert.Equal(t, []float32{1}, embeddings[2])
+
+	embedding, err := cache.GetEmbedding(context.Background(), "c")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{3}, embedding)
+
+	assert.Equal(t, float64(3), testutil.ToFloat64(cache.metrics.hits))
+	assert.Equal(t, float64(3), testutil.ToFloat64(cache.metrics.misses))
+	assert.InDelta(t, 0.5, testutil.ToFloat64(cache.metrics.hitRatio), 1e-9)
+
+	next.AssertExpectations(t)
+}
+
+func TestCachingService_HitRatioBeforeLookups(t *testing.T) {
+	cache := NewCachingService(new(MockEmbedder), 10, prometheus.NewRegistry())
+
+	assert.Equal(t, float64(0), cache.HitRatio())
+}
+
+func TestCachingService_EvictsLeastRecentlyUsed(t *testing.T) {
+	next := new(MockEmbedder)
+	cache := NewCachingService(next, 2, prometheus.NewRegistry())
+
+	next.On("GetEmbeddings", mock.Anything, []string{"a", "b"}).Return([][]float32{{1}, {2}}, nil).Once()
+	next.On("GetEmbeddings", mock.Anything, []string{"c"}).Return([][]float32{{3}}, nil).Once()
+	next.On("GetEmbeddings", mock.Anything, []string{"b"}).Return([][]float32{{2}}, nil).Once()
+
+	_, err := cache.GetEmbeddings(context.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+
+	// Touch "a" so "b" becomes the least recently used entry
+	_, err = cache.GetEmbedding(context.Background(), "a")
+	require.NoError(t, err)
+
+	_, err = cache.GetEmbedding(context.Background(), "c")
+	require.NoError(t, err)
+
+	// "b" was evicted and must be fetched again
+	_, err = cache.GetEmbedding(context.Background(), "b")
+	require.NoError(t, err)
+
+	next.AssertExpectations(t)
+}
+
+func TestCachingService_ErrorsAreNotCached(t *testing.T) {
+	next := new(MockEmbedder)
+	cache := NewCachingService(next, 10, prometheus.NewRegistry())
+
+	next.On("GetEmbeddings", mock.Anything, []string{"a"}).Return(nil, assert.AnError).Once()
+	next.On("GetEmbeddings", mock.Anything, []string{"a"}).Return([][]float32{{1}}, nil).Once()
+
+	_, err := cache.GetEmbedding(context.Background(), "a")
+	assert.Error(t, err)
+
+	embedding, err := cache.GetEmbedding(context.Background(), "a")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{1}, embedding)
+
+	next.AssertExpectations(t)
+}
+
+func TestCachingService_RegistersMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	NewCachingService(new(MockEmbedder), 10, registry)
+
+	count, err := testutil.GatherAndCount(registry,
+		"log_ingestor_embedding_cache_hits_total",
+		"log_ingestor_embedding_cache_misses_total",
+		"log_ingestor_embedding_cache_hit_ratio",
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
@@ -0,0 +1,137 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	// DefaultChunkSize is the chunk length in characters when none is configured
+	DefaultChunkSize = 2000
+	// DefaultChunkOverlap is how many characters consecutive chunks share by default
+	DefaultChunkOverlap = 200
+)
+
+// ChunkingService wraps an embedding Interface so that texts longer than the
+// chunk size are split into overlapping chunks, embedded separately and
+// mean-pooled into one vector, instead of being truncated by the model
+type ChunkingService struct {
+	next      Interface
+	chunkSize int
+	overlap   int
+}
+
+// NewChunkingService splits texts into chunks of at most chunkSize characters
+// sharing overlap characters. Overlap is clamped below chunkSize.
+func NewChunkingService(next Interface, chunkSize, overlap int) *ChunkingService {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	if overlap < 0 {
+		overlap = 0
+	}
+	if overlap >= chunkSize {
+		overlap = chunkSize - 1
+	}
+	return &ChunkingService{
+		next:      next,
+		chunkSize: chunkSize,
+		overlap:   overlap,
+	}
+}
+
+// chunks splits text into overlapping windows of at most chunkSize runes.
+// Text that fits a single chunk is returned unchanged.
+func (c *ChunkingService) chunks(text string) []string {
+	runes := []rune(text)
+	if len(runes) <= c.chunkSize {
+		return []string{text}
+	}
+
+	step := c.chunkSize - c.overlap
+	var chunks []string
+	for start := 0; ; start += step {
+		end := min(start+c.chunkSize, len(runes))
+		chunks = append(chunks, string(runes[start:end]))
+		if end == len(runes) {
+			return chunks
+		}
+	}
+}
+
+// GetEmbeddings embeds every chunk of every text in one request to the wrapped
+// service and returns one mean-pooled vector per text
+func (c *ChunkingService) GetEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	var all []string
+	counts := make([]int, len(texts))
+	for i, text := range texts {
+		chunks := c.chunks(text)
+		counts[i] = len(chunks)
+		all = append(all, chunks...)
+	}
+	if len(all) == len(texts) {
+		return c.next.GetEmbeddings(ctx, texts)
+	}
+
+	embeddings, err := c.next.GetEmbeddings(ctx, all)
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) != len(all) {
+		return nil, fmt.Errorf("expected %d chunk embeddings, got %d", len(all), len(embeddings))
+	}
+
+	pooled := make([][]float32, len(texts))
+	offset := 0
+	for i, count := range counts {
+		pooled[i] = meanPool(embeddings[offset : offset+count])
+		offset += count
+	}
+	return pooled, nil
+}
+
+// GetEmbedding embeds text directly when it fits one chunk, otherwise returns
+// the mean of its chunk embeddings
+func (c *ChunkingService) GetEmbedding(ctx context.Context, text string) ([]float32, error) {
+	chunks := c.chunks(text)
+	if len(chunks) == 1 {
+		return c.next.GetEmbedding(ctx, text)
+	}
+
+	embeddings, err := c.next.GetEmbeddings(ctx, chunks)
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) != len(chunks) {
+		return nil, fmt.Errorf("expected %d chunk embeddings, got %d", len(chunks), len(embeddings))
+	}
+	return meanPool(embeddings), nil
+}
+
+// HealthCheck forwards to the wrapped service
+func (c *ChunkingService) HealthCheck(ctx context.Context) error {
+	return c.next.HealthCheck(ctx)
+}
+
+// meanPool returns the element-wise mean of vectors
+func meanPool(vectors [][]float32) []float32 {
+	if len(vectors) == 1 {
+		return vectors[0]
+	}
+
+	mean := make([]float32, len(vectors[0]))
+	for _, vector := range vectors {
+		for i := range mean {
+			if i < len(vector) {
+				mean[i] += vector[i]
+			}
+		}
+	}
+	for i := range mean {
+		mean[i] /= float32(len(vectors))
+	}
+	return mean
+}
+
+// Ensure ChunkingService implements Interface
+var _ Interface = (*ChunkingService)(nil)
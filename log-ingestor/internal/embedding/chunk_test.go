@@ -0,0 +1,75 @@
+package embedding
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkingService_Chunks(t *testing.T) {
+	chunker := NewChunkingService(new(MockEmbedder), 4, 1)
+
+	assert.Equal(t, []string{"abcd"}, chunker.chunks("abcd"))
+	assert.Equal(t, []string{"abcd", "defg", "ghij"}, chunker.chunks("abcdefghij"))
+	assert.Equal(t, []string{"abcd", "defg", "gh"}, chunker.chunks("abcdefgh"))
+	// Chunks split on characters, not bytes
+	assert.Equal(t, []string{"ééé€", "€üüü"}, chunker.chunks("ééé€üüü"))
+}
+
+func TestChunkingService_GetEmbedding_ShortMessageEmbedsOnce(t *testing.T) {
+	next := new(MockEmbedder)
+	chunker := NewChunkingService(next, 100, 10)
+
+	next.On("GetEmbedding", mock.Anything, "short message").Return([]float32{0.5, 0.5}, nil).Once()
+
+	embedding, err := chunker.GetEmbedding(context.Background(), "short message")
+
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.5, 0.5}, embedding)
+	next.AssertExpectations(t)
+	next.AssertNotCalled(t, "GetEmbeddings", mock.Anything, mock.Anything)
+}
+
+func TestChunkingService_GetEmbedding_LongMessageIsAveraged(t *testing.T) {
+	next := new(MockEmbedder)
+	chunker := NewChunkingService(next, 10, 2)
+
+	message := strings.Repeat("a", 10) + strings.Repeat("b", 8)
+	next.On("GetEmbeddings", mock.Anything, []string{strings.Repeat("a", 10), "aa" + strings.Repeat("b", 8)}).
+		Return([][]float32{{1, 0, 2}, {0, 1, 4}}, nil).Once()
+
+	embedding, err := chunker.GetEmbedding(context.Background(), message)
+
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.5, 0.5, 3}, embedding)
+	next.AssertExpectations(t)
+	next.AssertNotCalled(t, "GetEmbedding", mock.Anything, mock.Anything)
+}
+
+func TestChunkingService_GetEmbeddings_MixedLengths(t *testing.T) {
+	next := new(MockEmbedder)
+	chunker := NewChunkingService(next, 4, 0)
+
+	next.On("GetEmbeddings", mock.Anything, []string{"ok", "abcd", "efgh", "ijkl", "fine"}).
+		Return([][]float32{{1, 1}, {3, 0}, {0, 3}, {3, 3}, {2, 2}}, nil).Once()
+
+	embeddings, err := chunker.GetEmbeddings(context.Background(), []string{"ok", "abcdefghijkl", "fine"})
+
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1, 1}, {2, 2}, {2, 2}}, embeddings)
+	next.AssertExpectations(t)
+}
+
+func TestChunkingService_GetEmbeddings_CountMismatch(t *testing.T) {
+	next := new(MockEmbedder)
+	chunker := NewChunkingService(next, 4, 0)
+
+	next.On("GetEmbeddings", mock.Anything, mock.Anything).Return([][]float32{{1}}, nil).Once()
+
+	_, err := chunker.GetEmbedding(context.Background(), "abcdefgh")
+	assert.Error(t, err)
+}
@@ -0,0 +1,65 @@
+package embedding
+
+import (
+	"context"
+)
+
+// LimitingService wraps an embedding Interface so that at most a fixed number
+// of requests are in flight at once. Bursts of stores then queue in the
+// ingestor instead of overwhelming the embedding service.
+type LimitingService struct {
+	next Interface
+	sem  chan struct{}
+}
+
+// NewLimitingService allows at most maxConcurrency concurrent calls to next
+func NewLimitingService(next Interface, maxConcurrency int) *LimitingService {
+	return &LimitingService{
+		next: next,
+		sem:  make(chan struct{}, maxConcurrency),
+	}
+}
+
+// acquire waits for a free slot, giving up when ctx is done
+func (l *LimitingService) acquire(ctx context.Context) error {
+	select {
+	case l.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *LimitingService) release() {
+	<-l.sem
+}
+
+// GetEmbeddings forwards to the wrapped service once a slot is free
+func (l *LimitingService) GetEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := l.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer l.release()
+	return l.next.GetEmbeddings(ctx, texts)
+}
+
+// GetEmbedding forwards to the wrapped service once a slot is free
+func (l *LimitingService) GetEmbedding(ctx context.Context, text string) ([]float32, error) {
+	if err := l.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer l.release()
+	return l.next.GetEmbedding(ctx, text)
+}
+
+// HealthCheck forwards to the wrapped service once a slot is free
+func (l *LimitingService) HealthCheck(ctx context.Context) error {
+	if err := l.acquire(ctx); err != nil {
+		return err
+	}
+	defer l.release()
+	return l.next.HealthCheck(ctx)
+}
+
+// Ensure LimitingService implements Interface
+var _ Interface = (*LimitingService)(nil)
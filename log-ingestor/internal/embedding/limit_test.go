@@ -0,0 +1,84 @@
+package embedding
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowEmbedder records the highest number of concurrent calls it has seen
+type slowEmbedder struct {
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (s *slowEmbedder) track() {
+	current := s.inFlight.Add(1)
+	for {
+		seen := s.maxInFlight.Load()
+		if current <= seen || s.maxInFlight.CompareAndSwap(seen, current) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	s.inFlight.Add(-1)
+}
+
+func (s *slowEmbedder) GetEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	s.track()
+	return make([][]float32, len(texts)), nil
+}
+
+func (s *slowEmbedder) GetEmbedding(ctx context.Context, text string) ([]float32, error) {
+	s.track()
+	return []float32{0.1}, nil
+}
+
+func (s *slowEmbedder) HealthCheck(ctx context.Context) error {
+	s.track()
+	return nil
+}
+
+func TestLimitingService_BoundsConcurrency(t *testing.T) {
+	next := &slowEmbedder{}
+	limiter := NewLimitingService(next, 3)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			if i%2 == 0 {
+				_, err = limiter.GetEmbedding(context.Background(), "text")
+			} else {
+				_, err = limiter.GetEmbeddings(context.Background(), []string{"a", "b"})
+			}
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, next.maxInFlight.Load(), int32(3))
+	assert.Equal(t, int32(3), next.maxInFlight.Load())
+	assert.Equal(t, 0, len(limiter.sem))
+}
+
+func TestLimitingService_ContextCanceledWhileWaiting(t *testing.T) {
+	next := &slowEmbedder{}
+	limiter := NewLimitingService(next, 1)
+	limiter.sem <- struct{}{} // occupy the only slot
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := limiter.GetEmbedding(ctx, "text")
+
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(0), next.maxInFlight.Load())
+}
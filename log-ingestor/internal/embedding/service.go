@@ -3,12 +3,18 @@ package embedding
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 )
 
@@ -29,14 +35,14 @@ type EmbeddingResponse struct {
 type LlamaCppEmbeddingResponse []LlamaCppEmbedding
 
 type LlamaCppEmbedding struct {
-	Index     int         `json:"index"`
+	Index     *int        `json:"index"`
 	Embedding [][]float32 `json:"embedding"`
 }
 
 // EmbeddingData represents a single embedding result
 type EmbeddingData struct {
 	Embedding []float32 `json:"embedding"`
-	Index     int       `json:"index"`
+	Index     *int      `json:"index"`
 	Object    string    `json:"object"`
 }
 
@@ -48,32 +54,97 @@ type Usage struct {
 
 // Service handles communication with the external embedding service
 type Service struct {
-	endpoint  string
+	endpoints []string
 	model     string
 	dimension int
 	client    *http.Client
 	logger    *logrus.Logger
+
+	// maxBatch caps the number of inputs per request; 0 means unlimited
+	maxBatch int
+
+	// dimensionMismatches counts embeddings whose length differs from dimension
+	dimensionMismatches prometheus.Counter
+
+	// mu guards endpoint health tracking
+	mu        sync.Mutex
+	healthy   []bool
+	preferred int
 }
 
-// NewService creates a new embedding service client
+// NewService creates a new embedding service client. The endpoint may be a
+// comma-separated list; requests fail over to the next endpoint on connection
+// errors or 5xx responses.
 func NewService(endpoint, model string, dimension int, logger *logrus.Logger) *Service {
+	var endpoints []string
+	for _, e := range strings.Split(endpoint, ",") {
+		if trimmed := strings.TrimSpace(e); trimmed != "" {
+			endpoints = append(endpoints, trimmed)
+		}
+	}
+
+	healthy := make([]bool, len(endpoints))
+	for i := range healthy {
+		healthy[i] = true
+	}
+
 	return &Service{
-		endpoint:  endpoint,
+		endpoints: endpoints,
 		model:     model,
 		dimension: dimension,
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		logger: logger,
+		logger:  logger,
+		healthy: healthy,
+		dimensionMismatches: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "log_ingestor_embedding_dimension_mismatch_total",
+			Help: "Total number of embeddings returned with a dimension other than the configured one",
+		}),
 	}
 }
 
-// GetEmbeddings retrieves embeddings for a batch of text inputs
+// retryableError marks a failure that should trigger failover to the next endpoint
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// GetEmbeddings retrieves embeddings for a batch of text inputs. When a
+// maximum batch size is set, the inputs are split into sub-requests and the
+// results are concatenated in input order.
 func (s *Service) GetEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
 	if len(texts) == 0 {
 		return nil, fmt.Errorf("no texts provided")
 	}
 
+	if s.maxBatch <= 0 || len(texts) <= s.maxBatch {
+		return s.fetchEmbeddings(ctx, texts)
+	}
+
+	embeddings := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += s.maxBatch {
+		end := min(start+s.maxBatch, len(texts))
+
+		chunk, err := s.fetchEmbeddings(ctx, texts[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed inputs %d-%d: %w", start, end-1, err)
+		}
+		embeddings = append(embeddings, chunk...)
+	}
+
+	return embeddings, nil
+}
+
+// fetchEmbeddings retrieves embeddings for texts in a single request
+func (s *Service) fetchEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
 	s.logger.WithField("text_count", len(texts)).Debug("Requesting embeddings")
 
 	request := EmbeddingRequest{
@@ -86,27 +157,9 @@ func (s *Service) GetEmbeddings(ctx context.Context, texts []string) ([][]float3
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", s.endpoint, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("embedding service returned status %d", resp.StatusCode)
-	}
-
-	// Read the raw response body first
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := s.post(ctx, jsonData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
 	}
 
 	// Try to decode as llama.cpp format first (array format)
@@ -119,13 +172,26 @@ func (s *Service) GetEmbeddings(ctx context.Context, texts []string) ([][]float3
 			return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(llamaResponse))
 		}
 
+		indices := make([]*int, len(llamaResponse))
+		for i, data := range llamaResponse {
+			indices[i] = data.Index
+		}
+		positions, err := inputPositions(indices)
+		if err != nil {
+			return nil, err
+		}
+
 		embeddings := make([][]float32, len(llamaResponse))
 		for i, data := range llamaResponse {
 			// llama.cpp returns embedding as [][]float32, but we need []float32
-			if len(data.Embedding) == 0 || len(data.Embedding[0]) != s.dimension {
-				return nil, fmt.Errorf("expected embedding dimension %d, got %d for text %d", s.dimension, len(data.Embedding[0]), i)
+			var embedding []float32
+			if len(data.Embedding) > 0 {
+				embedding = data.Embedding[0] // Take the first (and only) embedding array
 			}
-			embeddings[i] = data.Embedding[0] // Take the first (and only) embedding array
+			if err := s.checkDimension(embedding, positions[i]); err != nil {
+				return nil, err
+			}
+			embeddings[positions[i]] = embedding
 		}
 		return embeddings, nil
 	}
@@ -142,12 +208,21 @@ func (s *Service) GetEmbeddings(ctx context.Context, texts []string) ([][]float3
 		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(openaiResponse.Data))
 	}
 
+	indices := make([]*int, len(openaiResponse.Data))
+	for i, data := range openaiResponse.Data {
+		indices[i] = data.Index
+	}
+	positions, err := inputPositions(indices)
+	if err != nil {
+		return nil, err
+	}
+
 	embeddings := make([][]float32, len(openaiResponse.Data))
 	for i, data := range openaiResponse.Data {
-		if len(data.Embedding) != s.dimension {
-			return nil, fmt.Errorf("expected embedding dimension %d, got %d for text %d", s.dimension, len(data.Embedding), i)
+		if err := s.checkDimension(data.Embedding, positions[i]); err != nil {
+			return nil, err
 		}
-		embeddings[i] = data.Embedding
+		embeddings[positions[i]] = data.Embedding
 	}
 
 	s.logger.WithFields(logrus.Fields{
@@ -158,6 +233,145 @@ func (s *Service) GetEmbeddings(ctx context.Context, texts []string) ([][]float3
 	return embeddings, nil
 }
 
+// inputPositions maps each response item to the input it embeds using the
+// items' index fields, so backends that return results out of order are
+// handled. When no item has an index they are taken in order. Otherwise every
+// item needs one and the indices must cover the inputs exactly once.
+func inputPositions(indices []*int) ([]int, error) {
+	positions := make([]int, len(indices))
+	present := 0
+	for i, index := range indices {
+		positions[i] = i
+		if index != nil {
+			present++
+		}
+	}
+	if present == 0 {
+		return positions, nil
+	}
+	if present != len(indices) {
+		return nil, fmt.Errorf("embedding index missing on %d of %d items", len(indices)-present, len(indices))
+	}
+
+	seen := make([]bool, len(indices))
+	for i, ptr := range indices {
+		index := *ptr
+		if index < 0 || index >= len(indices) {
+			return nil, fmt.Errorf("embedding index %d out of range for %d inputs", index, len(indices))
+		}
+		if seen[index] {
+			return nil, fmt.Errorf("duplicate embedding index %d", index)
+		}
+		seen[index] = true
+		positions[i] = index
+	}
+	return positions, nil
+}
+
+// checkDimension verifies the embedding for text i has the configured dimension,
+// counting mismatches so model/config drift can be alerted on
+func (s *Service) checkDimension(embedding []float32, i int) error {
+	if len(embedding) == s.dimension {
+		return nil
+	}
+	s.dimensionMismatches.Inc()
+	return fmt.Errorf("expected embedding dimension %d, got %d for text %d", s.dimension, len(embedding), i)
+}
+
+// post sends the request body to the embedding endpoints, starting with the
+// preferred (last known healthy) endpoint and failing over on retryable errors
+func (s *Service) post(ctx context.Context, body []byte) ([]byte, error) {
+	if len(s.endpoints) == 0 {
+		return nil, fmt.Errorf("no embedding endpoints configured")
+	}
+
+	s.mu.Lock()
+	start := s.preferred
+	s.mu.Unlock()
+
+	var lastErr error
+	for attempt := 0; attempt < len(s.endpoints); attempt++ {
+		i := (start + attempt) % len(s.endpoints)
+		endpoint := s.endpoints[i]
+
+		respBody, err := s.postTo(ctx, endpoint, body)
+		if err == nil {
+			s.markEndpoint(i, true)
+			return respBody, nil
+		}
+
+		retryable, ok := err.(*retryableError)
+		if !ok || ctx.Err() != nil {
+			return nil, err
+		}
+
+		s.markEndpoint(i, false)
+		lastErr = retryable.err
+		if len(s.endpoints) > 1 {
+			s.logger.WithError(lastErr).WithField("endpoint", endpoint).Warn("Embedding endpoint failed, failing over")
+		}
+	}
+
+	if len(s.endpoints) > 1 {
+		return nil, fmt.Errorf("all %d embedding endpoints failed, last error: %w", len(s.endpoints), lastErr)
+	}
+	return nil, lastErr
+}
+
+// postTo sends a single request to one endpoint and returns the response body
+func (s *Service) postTo(ctx context.Context, endpoint string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, &retryableError{err: fmt.Errorf("failed to send request: %w", err)}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, &retryableError{err: fmt.Errorf("embedding service returned status %d", resp.StatusCode)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embedding service returned status %d", resp.StatusCode)
+	}
+
+	// Read the raw response body first
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	return respBody, nil
+}
+
+// markEndpoint records the health of an endpoint, preferring it if healthy
+func (s *Service) markEndpoint(i int, healthy bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.healthy[i] = healthy
+	if healthy {
+		s.preferred = i
+	}
+}
+
+// EndpointHealth returns the last observed health of each configured endpoint
+func (s *Service) EndpointHealth() map[string]bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	health := make(map[string]bool, len(s.endpoints))
+	for i, endpoint := range s.endpoints {
+		health[endpoint] = s.healthy[i]
+	}
+	return health
+}
+
 // GetEmbedding retrieves embedding for a single text input
 func (s *Service) GetEmbedding(ctx context.Context, text string) ([]float32, error) {
 	embeddings, err := s.GetEmbeddings(ctx, []string{text})
@@ -186,6 +400,56 @@ func (s *Service) SetTimeout(timeout time.Duration) {
 	s.client.Timeout = timeout
 }
 
+// SetMaxBatch caps the number of inputs sent per embedding request; 0 disables the cap
+func (s *Service) SetMaxBatch(maxBatch int) {
+	s.maxBatch = maxBatch
+}
+
+// RegisterMetrics registers the service's metrics with registerer,
+// ignoring duplicate registration errors
+func (s *Service) RegisterMetrics(registerer prometheus.Registerer) {
+	_ = registerer.Register(s.dimensionMismatches)
+}
+
+// ConfigureTLS sets up TLS for HTTPS endpoints. caCertFile is a PEM bundle
+// trusted in addition to the system roots, e.g. for a self-signed embedding
+// service; insecureSkipVerify disables certificate verification entirely.
+func (s *Service) ConfigureTLS(caCertFile string, insecureSkipVerify bool) error {
+	if caCertFile == "" && !insecureSkipVerify {
+		return nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: insecureSkipVerify,
+	}
+
+	if caCertFile != "" {
+		pemData, err := os.ReadFile(caCertFile)
+		if err != nil {
+			return fmt.Errorf("failed to read embedding CA certificate: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pemData) {
+			return fmt.Errorf("no certificates found in %s", caCertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if insecureSkipVerify {
+		s.logger.Warn("TLS certificate verification is disabled for the embedding service")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	s.client.Transport = transport
+
+	return nil
+}
+
 // Interface defines the embedding service contract
 type Interface interface {
 	GetEmbeddings(ctx context.Context, texts []string) ([][]float32, error)
@@ -3,11 +3,19 @@ package embedding
 import (
 	"context"
 	"encoding/json"
+	"encoding/pem"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -20,7 +28,7 @@ func TestNewService(t *testing.T) {
 
 	service := NewService(endpoint, model, dimension, logrus.New())
 
-	assert.Equal(t, endpoint, service.endpoint)
+	assert.Equal(t, []string{endpoint}, service.endpoints)
 	assert.Equal(t, model, service.model)
 	assert.Equal(t, dimension, service.dimension)
 	assert.NotNil(t, service.client)
@@ -46,12 +54,12 @@ func TestService_GetEmbeddings_Success(t *testing.T) {
 			Data: []EmbeddingData{
 				{
 					Embedding: []float32{0.1, 0.2, 0.3},
-					Index:     0,
+					Index:     indexOf(0),
 					Object:    "embedding",
 				},
 				{
 					Embedding: []float32{0.4, 0.5, 0.6},
-					Index:     1,
+					Index:     indexOf(1),
 					Object:    "embedding",
 				},
 			},
@@ -88,7 +96,7 @@ func TestService_GetEmbedding_Success(t *testing.T) {
 			Data: []EmbeddingData{
 				{
 					Embedding: []float32{0.1, 0.2},
-					Index:     0,
+					Index:     indexOf(0),
 					Object:    "embedding",
 				},
 			},
@@ -107,6 +115,235 @@ func TestService_GetEmbedding_Success(t *testing.T) {
 	assert.Equal(t, []float32{0.1, 0.2}, embedding)
 }
 
+func TestNewService_MultipleEndpoints(t *testing.T) {
+	service := NewService("http://a/embed, http://b/embed,", "test-model", 3, logrus.New())
+
+	assert.Equal(t, []string{"http://a/embed", "http://b/embed"}, service.endpoints)
+	assert.Equal(t, map[string]bool{"http://a/embed": true, "http://b/embed": true}, service.EndpointHealth())
+}
+
+// newEmbeddingServer returns a server answering every request with a fixed embedding
+func newEmbeddingServer(t *testing.T, calls *int) *httptest.Server {
+	return httptest.NewServer(embeddingHandler(calls))
+}
+
+// embeddingHandler answers every request with a fixed embedding
+func embeddingHandler(calls *int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		response := EmbeddingResponse{
+			Data:  []EmbeddingData{{Embedding: []float32{0.1, 0.2, 0.3}, Index: indexOf(0), Object: "embedding"}},
+			Model: "test-model",
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	})
+}
+
+// newTLSEmbeddingServer returns a self-signed HTTPS embedding server and the path
+// of a PEM file holding its certificate
+func newTLSEmbeddingServer(t *testing.T, calls *int) (*httptest.Server, string) {
+	server := httptest.NewTLSServer(embeddingHandler(calls))
+	t.Cleanup(server.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, certPEM, 0o600))
+
+	return server, caFile
+}
+
+func TestService_ConfigureTLS(t *testing.T) {
+	tests := []struct {
+		name               string
+		useCA              bool
+		insecureSkipVerify bool
+		expectError        bool
+	}{
+		{"Untrusted certificate rejected", false, false, true},
+		{"Custom CA trusted", true, false, false},
+		{"Verification skipped", false, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server, caFile := newTLSEmbeddingServer(t, &calls)
+			if !tt.useCA {
+				caFile = ""
+			}
+
+			service := NewService(server.URL, "test-model", 3, logrus.New())
+			require.NoError(t, service.ConfigureTLS(caFile, tt.insecureSkipVerify))
+
+			_, err := service.GetEmbedding(context.Background(), "hello")
+			if tt.expectError {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "certificate")
+				assert.Equal(t, 0, calls)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 1, calls)
+		})
+	}
+}
+
+func TestService_ConfigureTLS_InvalidCAFile(t *testing.T) {
+	service := NewService("https://embedding.local/embed", "test-model", 3, logrus.New())
+
+	err := service.ConfigureTLS(filepath.Join(t.TempDir(), "missing.pem"), false)
+	assert.Error(t, err)
+
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+	err = service.ConfigureTLS(notPEM, false)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no certificates found")
+}
+
+func TestService_GetEmbeddings_FailsOverToHealthyEndpoint(t *testing.T) {
+	failingCalls := 0
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failingCalls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	healthyCalls := 0
+	healthy := newEmbeddingServer(t, &healthyCalls)
+	defer healthy.Close()
+
+	service := NewService(failing.URL+","+healthy.URL, "test-model", 3, logrus.New())
+
+	embedding, err := service.GetEmbedding(context.Background(), "failover")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.1, 0.2, 0.3}, embedding)
+	assert.Equal(t, 1, failingCalls)
+	assert.Equal(t, 1, healthyCalls)
+	assert.Equal(t, map[string]bool{failing.URL: false, healthy.URL: true}, service.EndpointHealth())
+
+	// Subsequent requests go straight to the endpoint known to be healthy
+	_, err = service.GetEmbedding(context.Background(), "again")
+	require.NoError(t, err)
+	assert.Equal(t, 1, failingCalls)
+	assert.Equal(t, 2, healthyCalls)
+}
+
+func TestService_GetEmbeddings_FailsOverOnConnectionError(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	downURL := down.URL
+	down.Close() // Nothing is listening anymore
+
+	healthyCalls := 0
+	healthy := newEmbeddingServer(t, &healthyCalls)
+	defer healthy.Close()
+
+	service := NewService(downURL+","+healthy.URL, "test-model", 3, logrus.New())
+
+	_, err := service.GetEmbedding(context.Background(), "failover")
+	require.NoError(t, err)
+	assert.Equal(t, 1, healthyCalls)
+	assert.False(t, service.EndpointHealth()[downURL])
+}
+
+func TestService_GetEmbeddings_AllEndpointsFail(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	service := NewService(failing.URL+","+failing.URL+"/other", "test-model", 3, logrus.New())
+
+	_, err := service.GetEmbedding(context.Background(), "test")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "all 2 embedding endpoints failed")
+	assert.Contains(t, err.Error(), "embedding service returned status 503")
+}
+
+func TestService_GetEmbeddings_NoFailoverOnClientError(t *testing.T) {
+	badRequestCalls := 0
+	badRequest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		badRequestCalls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer badRequest.Close()
+
+	healthyCalls := 0
+	healthy := newEmbeddingServer(t, &healthyCalls)
+	defer healthy.Close()
+
+	service := NewService(badRequest.URL+","+healthy.URL, "test-model", 3, logrus.New())
+
+	_, err := service.GetEmbedding(context.Background(), "test")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "embedding service returned status 400")
+	assert.Equal(t, 1, badRequestCalls)
+	assert.Equal(t, 0, healthyCalls)
+}
+
+func TestService_GetEmbeddings_MaxBatch(t *testing.T) {
+	var batchSizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req EmbeddingRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		batchSizes = append(batchSizes, len(req.Input))
+
+		// Encode each input's index in its embedding so ordering can be verified
+		response := EmbeddingResponse{Model: "test-model"}
+		for i, text := range req.Input {
+			n, err := strconv.Atoi(strings.TrimPrefix(text, "text-"))
+			require.NoError(t, err)
+			response.Data = append(response.Data, EmbeddingData{Embedding: []float32{float32(n)}, Index: indexOf(i), Object: "embedding"})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	service := NewService(server.URL, "test-model", 1, logrus.New())
+	service.SetMaxBatch(64)
+
+	texts := make([]string, 250)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("text-%d", i)
+	}
+
+	embeddings, err := service.GetEmbeddings(context.Background(), texts)
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{64, 64, 64, 58}, batchSizes)
+	require.Len(t, embeddings, 250)
+	for i, embedding := range embeddings {
+		assert.Equal(t, []float32{float32(i)}, embedding)
+	}
+}
+
+func TestService_GetEmbeddings_MaxBatchError(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		response := EmbeddingResponse{
+			Data: []EmbeddingData{{Embedding: []float32{0.1}}, {Embedding: []float32{0.2}}},
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	service := NewService(server.URL, "test-model", 1, logrus.New())
+	service.SetMaxBatch(2)
+
+	_, err := service.GetEmbeddings(context.Background(), []string{"a", "b", "c", "d", "e", "f"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to embed inputs 2-3")
+	assert.Equal(t, 2, requests)
+}
+
 func TestService_GetEmbeddings_EmptyTexts(t *testing.T) {
 	service := NewService("http://test.com", "test-model", 768, logrus.New())
 	_, err := service.GetEmbeddings(context.Background(), []string{})
@@ -134,7 +371,7 @@ func TestService_GetEmbeddings_WrongDimension(t *testing.T) {
 			Data: []EmbeddingData{
 				{
 					Embedding: []float32{0.1, 0.2}, // Dimension 2, but service expects 3
-					Index:     0,
+					Index:     indexOf(0),
 					Object:    "embedding",
 				},
 			},
@@ -151,6 +388,42 @@ func TestService_GetEmbeddings_WrongDimension(t *testing.T) {
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "expected embedding dimension 3, got 2")
+	assert.Equal(t, float64(1), testutil.ToFloat64(service.dimensionMismatches))
+}
+
+func TestService_DimensionMismatchMetric(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected float64
+	}{
+		{"OpenAI match", `{"data":[{"embedding":[0.1,0.2,0.3],"index":0}]}`, 0},
+		{"OpenAI mismatch", `{"data":[{"embedding":[0.1,0.2],"index":0}]}`, 1},
+		{"llama.cpp mismatch", `[{"index":0,"embedding":[[0.1,0.2,0.3,0.4]]}]`, 1},
+		{"llama.cpp empty", `[{"index":0,"embedding":[]}]`, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			service := NewService(server.URL, "test-model", 3, logrus.New())
+			registry := prometheus.NewRegistry()
+			service.RegisterMetrics(registry)
+
+			_, err := service.GetEmbeddings(context.Background(), []string{"test"})
+
+			assert.Equal(t, tt.expected > 0, err != nil)
+			assert.Equal(t, tt.expected, testutil.ToFloat64(service.dimensionMismatches))
+			count, err := testutil.GatherAndCount(registry, "log_ingestor_embedding_dimension_mismatch_total")
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+		})
+	}
 }
 
 func TestService_GetEmbeddings_MismatchedCount(t *testing.T) {
@@ -159,7 +432,7 @@ func TestService_GetEmbeddings_MismatchedCount(t *testing.T) {
 			Data: []EmbeddingData{
 				{
 					Embedding: []float32{0.1, 0.2, 0.3},
-					Index:     0,
+					Index:     indexOf(0),
 					Object:    "embedding",
 				},
 				// Missing second embedding for second text
@@ -179,6 +452,68 @@ func TestService_GetEmbeddings_MismatchedCount(t *testing.T) {
 	assert.Contains(t, err.Error(), "expected 2 embeddings, got 1")
 }
 
+func TestService_GetEmbeddings_OrdersByIndex(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"OpenAI shuffled", `{"data":[{"embedding":[2],"index":2},{"embedding":[0],"index":0},{"embedding":[1],"index":1}]}`},
+		{"llama.cpp shuffled", `[{"index":1,"embedding":[[1]]},{"index":2,"embedding":[[2]]},{"index":0,"embedding":[[0]]}]`},
+		{"Indices absent", `{"data":[{"embedding":[0]},{"embedding":[1]},{"embedding":[2]}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			service := NewService(server.URL, "test-model", 1, logrus.New())
+			embeddings, err := service.GetEmbeddings(context.Background(), []string{"a", "b", "c"})
+
+			require.NoError(t, err)
+			assert.Equal(t, [][]float32{{0}, {1}, {2}}, embeddings)
+		})
+	}
+}
+
+// indexOf returns a pointer to index for building embedding responses
+func indexOf(index int) *int {
+	return &index
+}
+
+func TestService_GetEmbeddings_InvalidIndices(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		expectError string
+	}{
+		{"Duplicate index", `{"data":[{"embedding":[0],"index":1},{"embedding":[1],"index":1},{"embedding":[2],"index":0}]}`, "duplicate embedding index 1"},
+		{"Gap in indices", `[{"index":0,"embedding":[[0]]},{"index":1,"embedding":[[1]]},{"index":3,"embedding":[[3]]}]`, "embedding index 3 out of range for 3 inputs"},
+		{"All indices zero", `{"data":[{"embedding":[0],"index":0},{"embedding":[1],"index":0},{"embedding":[2],"index":0}]}`, "duplicate embedding index 0"},
+		{"Negative index", `{"data":[{"embedding":[0],"index":0},{"embedding":[1],"index":-1},{"embedding":[2],"index":2}]}`, "embedding index -1 out of range for 3 inputs"},
+		{"Index missing on some items", `[{"index":0,"embedding":[[0]]},{"embedding":[[1]]},{"index":2,"embedding":[[2]]}]`, "embedding index missing on 1 of 3 items"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			service := NewService(server.URL, "test-model", 1, logrus.New())
+			_, err := service.GetEmbeddings(context.Background(), []string{"a", "b", "c"})
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectError)
+		})
+	}
+}
+
 func TestService_GetEmbeddings_Timeout(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		time.Sleep(100 * time.Millisecond) // Simulate slow response
@@ -202,7 +537,7 @@ func TestService_HealthCheck_Success(t *testing.T) {
 			Data: []EmbeddingData{
 				{
 					Embedding: []float32{0.1, 0.2, 0.3},
-					Index:     0,
+					Index:     indexOf(0),
 					Object:    "embedding",
 				},
 			},
@@ -0,0 +1,122 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"github.com/timberline/log-ingestor/internal/handlers"
+	"github.com/timberline/log-ingestor/internal/ingestorpb"
+	"github.com/timberline/log-ingestor/internal/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server serves the LogIngestor gRPC service, feeding entries into the same
+// worker pipeline as the HTTP stream endpoint
+type Server struct {
+	ingestorpb.UnimplementedLogIngestorServer
+
+	server  *grpc.Server
+	address string
+	stream  *handlers.StreamHandler
+	logger  *logrus.Logger
+}
+
+func NewServer(port int, stream *handlers.StreamHandler, logger *logrus.Logger) *Server {
+	s := &Server{
+		server:  grpc.NewServer(),
+		address: ":" + strconv.Itoa(port),
+		stream:  stream,
+		logger:  logger,
+	}
+	ingestorpb.RegisterLogIngestorServer(s.server, s)
+
+	return s
+}
+
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+
+	s.logger.WithField("address", s.address).Info("Starting gRPC server")
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on listener until the server is stopped
+func (s *Server) Serve(listener net.Listener) error {
+	if err := s.server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+
+	return nil
+}
+
+// Stop waits for open streams to finish, forcing them closed if ctx expires first
+func (s *Server) Stop(ctx context.Context) error {
+	s.logger.Info("Shutting down gRPC server")
+
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
+
+// StreamLogs queues every received entry for storage and replies with the
+// number of accepted and rejected entries once the client closes the stream
+func (s *Server) StreamLogs(stream ingestorpb.LogIngestor_StreamLogsServer) error {
+	// Ask clients to back off while storage is unreachable, like the HTTP endpoint
+	if !s.stream.StorageAvailable() {
+		return status.Error(codes.Unavailable, "storage unavailable, retry later")
+	}
+
+	response := &ingestorpb.StreamLogsResponse{}
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			s.logger.WithFields(logrus.Fields{
+				"processed_count": response.ProcessedCount,
+				"rejected_count":  response.RejectedCount,
+			}).Info("gRPC stream processed successfully")
+			return stream.SendAndClose(response)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := s.stream.Enqueue(toLogEntry(msg)); err != nil {
+			response.RejectedCount++
+			continue
+		}
+		response.ProcessedCount++
+	}
+}
+
+// toLogEntry converts a protobuf log entry to our internal format
+func toLogEntry(msg *ingestorpb.LogEntry) *models.LogEntry {
+	entry := &models.LogEntry{
+		Timestamp: msg.GetTimestamp(),
+		Message:   msg.GetMessage(),
+		Source:    msg.GetSource(),
+	}
+	if msg.GetMetadata() != nil {
+		entry.Metadata = msg.GetMetadata().AsMap()
+	}
+
+	return entry
+}
@@ -0,0 +1,186 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/handlers"
+	"github.com/timberline/log-ingestor/internal/ingestorpb"
+	"github.com/timberline/log-ingestor/internal/models"
+	"github.com/timberline/log-ingestor/internal/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// MockStorage is a mock implementation of storage.StorageInterface
+type MockStorage struct {
+	mock.Mock
+}
+
+func (m *MockStorage) Connect(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockStorage) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockStorage) StoreLog(ctx context.Context, log *models.LogEntry) error {
+	args := m.Called(ctx, log)
+	return args.Error(0)
+}
+
+func (m *MockStorage) StoreBatch(ctx context.Context, logs []*models.LogEntry) error {
+	args := m.Called(ctx, logs)
+	return args.Error(0)
+}
+
+func (m *MockStorage) HealthCheck(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockStorage) CreateCollection(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+// newTestClient serves s over an in-process connection and returns a client for it
+func newTestClient(t *testing.T, s *Server) ingestorpb.LogIngestorClient {
+	listener := bufconn.Listen(1024 * 1024)
+	go func() { _ = s.Serve(listener) }()
+	t.Cleanup(func() { _ = s.Stop(context.Background()) })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return ingestorpb.NewLogIngestorClient(conn)
+}
+
+func TestServer_StreamLogs(t *testing.T) {
+	mockStorage := new(MockStorage)
+	logChannel := make(chan *models.LogEntry, 10)
+	streamHandler := handlers.NewStreamHandler(mockStorage, 2, time.Second, logChannel)
+
+	stored := make(chan []*models.LogEntry, 1)
+	mockStorage.On("StoreBatch", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		stored <- args.Get(1).([]*models.LogEntry)
+	}).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go streamHandler.StartWorker(ctx)
+
+	client := newTestClient(t, NewServer(0, streamHandler, logrus.New()))
+
+	metadata, err := structpb.NewStruct(map[string]interface{}{"level": "ERROR"})
+	require.NoError(t, err)
+
+	now := time.Now().UnixMilli()
+	stream, err := client.StreamLogs(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&ingestorpb.LogEntry{Timestamp: now, Message: "first", Source: "api", Metadata: metadata}))
+	require.NoError(t, stream.Send(&ingestorpb.LogEntry{Timestamp: now, Message: "", Source: "api"}))
+	require.NoError(t, stream.Send(&ingestorpb.LogEntry{Timestamp: now, Message: "second", Source: "api"}))
+
+	response, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), response.ProcessedCount)
+	assert.Equal(t, int64(1), response.RejectedCount)
+
+	select {
+	case batch := <-stored:
+		require.Len(t, batch, 2)
+		assert.Equal(t, "first", batch[0].Message)
+		assert.Equal(t, "api", batch[0].Source)
+		assert.Equal(t, "ERROR", batch[0].GetLevel())
+		assert.Equal(t, "second", batch[1].Message)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for batch to be stored")
+	}
+	mockStorage.AssertExpectations(t)
+}
+
+func TestServer_StreamLogs_EmptyStream(t *testing.T) {
+	logChannel := make(chan *models.LogEntry, 10)
+	streamHandler := handlers.NewStreamHandler(new(MockStorage), 2, time.Second, logChannel)
+
+	client := newTestClient(t, NewServer(0, streamHandler, logrus.New()))
+
+	stream, err := client.StreamLogs(context.Background())
+	require.NoError(t, err)
+
+	response, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), response.ProcessedCount)
+	assert.Equal(t, int64(0), response.RejectedCount)
+	assert.Empty(t, logChannel)
+}
+
+func TestServer_StreamLogs_QueueFull(t *testing.T) {
+	logChannel := make(chan *models.LogEntry, 1)
+	streamHandler := handlers.NewStreamHandler(new(MockStorage), 2, time.Second, logChannel)
+
+	client := newTestClient(t, NewServer(0, streamHandler, logrus.New()))
+
+	now := time.Now().UnixMilli()
+	stream, err := client.StreamLogs(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&ingestorpb.LogEntry{Timestamp: now, Message: "queued"}))
+	require.NoError(t, stream.Send(&ingestorpb.LogEntry{Timestamp: now, Message: "dropped"}))
+
+	response, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), response.ProcessedCount)
+	assert.Equal(t, int64(1), response.RejectedCount)
+}
+
+func TestServer_StreamLogs_StorageUnavailable(t *testing.T) {
+	mockStorage := new(MockStorage)
+	logChannel := make(chan *models.LogEntry, 10)
+	streamHandler := handlers.NewStreamHandler(mockStorage, 1, time.Second, logChannel)
+
+	failed := make(chan struct{})
+	mockStorage.On("StoreBatch", mock.Anything, mock.Anything).Return(storage.ErrNotConnected).Run(func(args mock.Arguments) {
+		close(failed)
+	}).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go streamHandler.StartWorker(ctx)
+
+	// A failed flush marks storage as unavailable
+	require.NoError(t, streamHandler.Enqueue(&models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "lost"}))
+	select {
+	case <-failed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for batch to be stored")
+	}
+	require.Eventually(t, func() bool { return !streamHandler.StorageAvailable() }, time.Second, 10*time.Millisecond)
+
+	client := newTestClient(t, NewServer(0, streamHandler, logrus.New()))
+
+	stream, err := client.StreamLogs(context.Background())
+	require.NoError(t, err)
+
+	_, err = stream.CloseAndRecv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/timberline/log-ingestor/internal/models"
+	"github.com/timberline/log-ingestor/internal/storage"
+)
+
+type AdminHandler struct {
+	storage    storage.AdminInterface
+	allowPurge bool
+	logger     *logrus.Logger
+
+	// flusher forces queued entries to storage; flushing is refused unless allowFlush
+	flusher    QueueFlusher
+	allowFlush bool
+}
+
+func NewAdminHandler(storage storage.AdminInterface, allowPurge bool, logger *logrus.Logger) *AdminHandler {
+	return &AdminHandler{
+		storage:    storage,
+		allowPurge: allowPurge,
+		logger:     logger,
+	}
+}
+
+// HandlePurgeCollection drops and recreates the log collection, deleting all
+// stored logs. It is refused unless purging was explicitly enabled.
+func (h *AdminHandler) HandlePurgeCollection(w http.ResponseWriter, r *http.Request) {
+	if !h.allowPurge {
+		h.logger.WithField("remote_addr", r.RemoteAddr).Warn("Rejected collection purge, ALLOW_ADMIN_PURGE is disabled")
+		writeErrorResponse(w, http.StatusForbidden, "Collection purge is disabled")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	h.logger.WithField("remote_addr", r.RemoteAddr).Warn("Purging log collection")
+	if err := h.storage.ResetCollection(ctx); err != nil {
+		h.logger.WithError(err).Error("Failed to purge collection")
+		if storage.IsUnavailable(err) {
+			writeUnavailableResponse(w, "Storage unavailable, retry later")
+			return
+		}
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to purge collection")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(models.AdminResponse{
+		Success: true,
+		Message: "Collection purged",
+	})
+}
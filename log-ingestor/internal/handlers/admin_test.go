@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/timberline/log-ingestor/internal/storage"
+)
+
+// MockAdminStorage is a mock implementation of storage.AdminInterface
+type MockAdminStorage struct {
+	mock.Mock
+}
+
+func (m *MockAdminStorage) ResetCollection(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func TestAdminHandler_HandlePurgeCollection_Disabled(t *testing.T) {
+	mockStorage := new(MockAdminStorage)
+	handler := NewAdminHandler(mockStorage, false, logrus.New())
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/admin/collection", nil)
+	rr := httptest.NewRecorder()
+	handler.HandlePurgeCollection(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	mockStorage.AssertNotCalled(t, "ResetCollection", mock.Anything)
+}
+
+func TestAdminHandler_HandlePurgeCollection_Enabled(t *testing.T) {
+	mockStorage := new(MockAdminStorage)
+	handler := NewAdminHandler(mockStorage, true, logrus.New())
+
+	mockStorage.On("ResetCollection", mock.Anything).Return(nil).Once()
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/admin/collection", nil)
+	rr := httptest.NewRecorder()
+	handler.HandlePurgeCollection(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"success":true`)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestAdminHandler_HandlePurgeCollection_StorageErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{"internal error", assert.AnError, http.StatusInternalServerError},
+		{"storage unavailable", storage.ErrNotConnected, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(MockAdminStorage)
+			handler := NewAdminHandler(mockStorage, true, logrus.New())
+
+			mockStorage.On("ResetCollection", mock.Anything).Return(tt.err).Once()
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/admin/collection", nil)
+			rr := httptest.NewRecorder()
+			handler.HandlePurgeCollection(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+// newAuditedAdminRouter routes the purge endpoint through the audit middleware
+func newAuditedAdminRouter(handler *AdminHandler, audit *AuditLogger) *mux.Router {
+	router := mux.NewRouter()
+	admin := router.PathPrefix("/api/v1/admin").Subrouter()
+	admin.Use(audit.Middleware)
+	admin.HandleFunc("/collection", handler.HandlePurgeCollection).Methods("DELETE")
+	return router
+}
+
+func TestAuditLogger_RecordsAdminCall(t *testing.T) {
+	tests := []struct {
+		name           string
+		allowPurge     bool
+		headers        map[string]string
+		expectedStatus int
+		expectedActor  string
+		expectedIP     string
+	}{
+		{
+			name:           "Actor from header and client from X-Forwarded-For",
+			allowPurge:     true,
+			headers:        map[string]string{"X-Forwarded-User": "alice", "X-Forwarded-For": "203.0.113.7, 10.0.0.1"},
+			expectedStatus: http.StatusOK,
+			expectedActor:  "alice",
+			expectedIP:     "203.0.113.7",
+		},
+		{
+			name:           "Refused call is audited with remote address",
+			allowPurge:     false,
+			expectedStatus: http.StatusForbidden,
+			expectedActor:  "anonymous",
+			expectedIP:     "192.0.2.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(MockAdminStorage)
+			mockStorage.On("ResetCollection", mock.Anything).Return(nil).Maybe()
+
+			logger, hook := logtest.NewNullLogger()
+			router := newAuditedAdminRouter(NewAdminHandler(mockStorage, tt.allowPurge, logrus.New()), NewAuditLogger(logger, "X-Forwarded-User", nil))
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/admin/collection", nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			require.Len(t, hook.AllEntries(), 1)
+			entry := hook.LastEntry()
+			assert.Equal(t, "Admin action", entry.Message)
+			assert.Equal(t, true, entry.Data["audit"])
+			assert.Equal(t, "DELETE /api/v1/admin/collection", entry.Data["action"])
+			assert.Equal(t, tt.expectedActor, entry.Data["actor"])
+			assert.Equal(t, tt.expectedIP, entry.Data["client_ip"])
+			assert.Equal(t, tt.expectedStatus, entry.Data["status"])
+		})
+	}
+}
+
+func TestAuditLogger_MirrorsToSink(t *testing.T) {
+	mockStorage := new(MockAdminStorage)
+	mockStorage.On("ResetCollection", mock.Anything).Return(nil).Once()
+	sink := new(MockDeadLetterSink)
+	sink.On("Write", mock.Anything, mock.MatchedBy(func(entries []*models.LogEntry) bool {
+		return len(entries) == 1 &&
+			entries[0].Source == AuditSource &&
+			entries[0].Metadata["action"] == "DELETE /api/v1/admin/collection" &&
+			entries[0].Metadata["actor"] == "bob" &&
+			entries[0].Metadata["status"] == http.StatusOK
+	})).Return(nil).Once()
+
+	logger, _ := logtest.NewNullLogger()
+	router := newAuditedAdminRouter(NewAdminHandler(mockStorage, true, logrus.New()), NewAuditLogger(logger, "X-Forwarded-User", sink))
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/admin/collection", nil)
+	req.Header.Set("X-Forwarded-User", "bob")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	sink.AssertExpectations(t)
+	mockStorage.AssertExpectations(t)
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/timberline/log-ingestor/internal/config"
+)
+
+type ConfigHandler struct {
+	config *config.Config
+	logger *logrus.Logger
+}
+
+func NewConfigHandler(cfg *config.Config, logger *logrus.Logger) *ConfigHandler {
+	return &ConfigHandler{
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// HandleConfig returns the effective configuration with credentials redacted.
+// It answers 404 unless EXPOSE_CONFIG is enabled.
+func (h *ConfigHandler) HandleConfig(w http.ResponseWriter, r *http.Request) {
+	if !h.config.ExposeConfig {
+		writeErrorResponse(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(h.config.Redacted()); err != nil {
+		h.logger.WithError(err).Error("Failed to encode config response")
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/config"
+)
+
+func TestConfigHandler_HandleConfig_Disabled(t *testing.T) {
+	handler := NewConfigHandler(&config.Config{ServerPort: 8080}, logrus.New())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/config", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleConfig(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "server_port")
+}
+
+func TestConfigHandler_HandleConfig_RedactsSecrets(t *testing.T) {
+	cfg := &config.Config{
+		ServerPort:        8080,
+		MilvusAddress:     "root:Milvus@milvus:19530",
+		EmbeddingEndpoint: "http://embed/embed?token=s3cr3t",
+		ExposeConfig:      true,
+	}
+	handler := NewConfigHandler(cfg, logrus.New())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/config", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleConfig(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.NotContains(t, rr.Body.String(), "Milvus@")
+	assert.NotContains(t, rr.Body.String(), "s3cr3t")
+
+	var body config.Config
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, 8080, body.ServerPort)
+	assert.Equal(t, "REDACTED@milvus:19530", body.MilvusAddress)
+	assert.Equal(t, "http://embed/embed?token=REDACTED", body.EmbeddingEndpoint)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// corsRequestHeaders are the request headers the handlers read, which browsers
+// must be allowed to send
+var corsRequestHeaders = []string{
+	"Content-Type",
+	"Content-Encoding",
+	SignatureHeader,
+	SchemaVersionHeader,
+	"Traceparent",
+	"Tracestate",
+}
+
+// CORSMiddleware adds CORS headers and answers preflight requests. A "*"
+// entry in allowedOrigins allows any origin; otherwise the request origin is
+// echoed back only when listed, and Access-Control-Allow-Origin is omitted
+// for other origins. The allowed methods are those registered on router,
+// looked up on the first request so routes added after the middleware count;
+// the allowed headers are those the handlers read plus extraHeaders, such as
+// the audit actor header.
+func CORSMiddleware(router *mux.Router, allowedOrigins []string, extraHeaders ...string) func(http.Handler) http.Handler {
+	allowAll := false
+	allowed := make(map[string]struct{}, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			allowAll = true
+		}
+		allowed[origin] = struct{}{}
+	}
+
+	headers := append([]string(nil), corsRequestHeaders...)
+	for _, header := range extraHeaders {
+		if header != "" {
+			headers = append(headers, http.CanonicalHeaderKey(header))
+		}
+	}
+	allowHeaders := strings.Join(headers, ", ")
+
+	var methodsOnce sync.Once
+	var allowMethods string
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if allowAll {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				// The response differs per origin, so caches must key on it
+				w.Header().Add("Vary", "Origin")
+				if origin := r.Header.Get("Origin"); origin != "" {
+					if _, ok := allowed[origin]; ok {
+						w.Header().Set("Access-Control-Allow-Origin", origin)
+					}
+				}
+			}
+			methodsOnce.Do(func() {
+				allowMethods = strings.Join(append(registeredMethods(router), http.MethodOptions), ", ")
+			})
+			w.Header().Set("Access-Control-Allow-Methods", allowMethods)
+			w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+
+			// Handle preflight requests
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// corsTestRouter registers routes with the methods the ingestor serves
+func corsTestRouter() *mux.Router {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/logs/stream", noop).Methods("POST")
+	router.HandleFunc("/api/v1/health", noop).Methods("GET")
+	router.HandleFunc("/api/v1/admin/collection", noop).Methods("DELETE")
+	return router
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		allowedOrigins []string
+		origin         string
+		expectOrigin   string
+	}{
+		{"Wildcard default allows any origin", []string{"*"}, "https://anywhere.example", "*"},
+		{"Allowed origin is echoed", []string{"https://ui.example", "https://ops.example"}, "https://ops.example", "https://ops.example"},
+		{"Disallowed origin gets no header", []string{"https://ui.example"}, "https://evil.example", ""},
+		{"Request without origin gets no header", []string{"https://ui.example"}, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := CORSMiddleware(corsTestRouter(), tt.allowedOrigins)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/health", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.True(t, called)
+			assert.Equal(t, tt.expectOrigin, rr.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "DELETE, GET, POST, OPTIONS", rr.Header().Get("Access-Control-Allow-Methods"))
+		})
+	}
+}
+
+func TestCORSMiddleware_Preflight(t *testing.T) {
+	// Wired as in main: the middleware wraps a router that answers unmatched
+	// methods with 405, which must not swallow the preflight
+	router := corsTestRouter()
+	SetMethodNotAllowedHandler(router)
+	handler := CORSMiddleware(router, []string{"https://ui.example"}, "X-Forwarded-User")(router)
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/v1/logs/stream", nil)
+	req.Header.Set("Origin", "https://ui.example")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "https://ui.example", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Origin", rr.Header().Get("Vary"))
+	assert.Equal(t, "DELETE, GET, POST, OPTIONS", rr.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type, Content-Encoding, X-Signature, X-Timberline-Schema, Traceparent, Tracestate, X-Forwarded-User",
+		rr.Header().Get("Access-Control-Allow-Headers"))
+}
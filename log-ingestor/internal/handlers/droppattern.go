@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+// ErrDroppedByPattern is returned by Enqueue for entries whose message matches
+// a drop pattern
+var ErrDroppedByPattern = errors.New("log entry matches a drop pattern")
+
+// SetDropPatterns makes Enqueue drop entries whose message matches any of
+// patterns before they are embedded or stored, for clients that bypass
+// collector-side filtering. Dropped entries are counted, not treated as invalid.
+func (h *StreamHandler) SetDropPatterns(patterns []*regexp.Regexp) {
+	h.dropPatterns = patterns
+}
+
+// matchesDropPattern reports whether the message of entry matches a drop pattern
+func (h *StreamHandler) matchesDropPattern(entry *models.LogEntry) bool {
+	for _, pattern := range h.dropPatterns {
+		if pattern.MatchString(entry.Message) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+func TestStreamHandler_HandleStream_DropPatterns(t *testing.T) {
+	now := time.Now().UnixMilli()
+	requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "GET /healthz 200", "source": "web"}
+{"timestamp": %d, "message": "user 42 logged in", "source": "web"}
+{"timestamp": %d, "message": "DEBUG cache warm", "source": "web"}
+{"timestamp": %d, "message": "payment failed", "source": "web"}`, now, now, now, now)
+
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+	handler.SetDropPatterns([]*regexp.Regexp{regexp.MustCompile(`^GET /healthz`), regexp.MustCompile(`(?i)^debug`)})
+
+	// Only the entries matching no pattern reach storage
+	mockStorage.On("StoreBatch", mock.Anything, mock.MatchedBy(func(logs []*models.LogEntry) bool {
+		return len(logs) == 2 && logs[0].Message == "user 42 logged in" && logs[1].Message == "payment failed"
+	})).Return(nil).Once()
+
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	// Wait for worker to process entries
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response models.BatchResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.ProcessedCount)
+
+	// Dropped entries are counted separately from invalid ones
+	assert.Equal(t, float64(2), testutil.ToFloat64(handler.metrics.droppedByPattern))
+	assert.Equal(t, float64(0), testutil.ToFloat64(handler.metrics.invalidLines))
+	mockStorage.AssertExpectations(t)
+}
+
+func TestStreamHandler_Enqueue_DropPatterns(t *testing.T) {
+	handler := newTestStreamHandler(new(MockStreamStorage), 100)
+	handler.SetDropPatterns([]*regexp.Regexp{regexp.MustCompile(`heartbeat`)})
+
+	err := handler.Enqueue(&models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "heartbeat ok", Source: "grpc-client"})
+	assert.ErrorIs(t, err, ErrDroppedByPattern)
+	assert.Equal(t, float64(0), testutil.ToFloat64(handler.metrics.linesProcessed))
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+// QueueFlusher forces queued log entries to storage
+type QueueFlusher interface {
+	Flush(ctx context.Context) models.FlushResponse
+}
+
+// Flush stores the entries currently queued, in batches of up to the maximum
+// batch size, without waiting for the batch timeout. Entries a worker has
+// already taken are still flushed by that worker. Failed entries go to the
+// dead-letter sink like those of the workers.
+func (h *StreamHandler) Flush(ctx context.Context) models.FlushResponse {
+	var result models.FlushResponse
+	for ctx.Err() == nil {
+		batch := h.takeQueued(h.maxBatchSize)
+		if len(batch) == 0 {
+			break
+		}
+
+		failed := h.storeBatch(ctx, batch)
+		result.BatchCount++
+		result.FlushedCount += len(batch) - failed
+		result.FailedCount += failed
+	}
+	h.metrics.queueSize.Set(float64(len(h.logChannel)))
+
+	result.Success = result.FailedCount == 0
+	return result
+}
+
+// takeQueued removes up to limit entries from the queue without blocking
+func (h *StreamHandler) takeQueued(limit int) []*models.LogEntry {
+	var batch []*models.LogEntry
+	for len(batch) < limit {
+		select {
+		case entry, ok := <-h.logChannel:
+			if !ok {
+				return batch
+			}
+			batch = append(batch, entry)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// SetQueueFlusher enables the flush endpoint, which forces queued entries to
+// storage. Flushing is refused unless allow is set.
+func (h *AdminHandler) SetQueueFlusher(flusher QueueFlusher, allow bool) {
+	h.flusher = flusher
+	h.allowFlush = allow
+}
+
+// HandleFlush stores the entries waiting in the ingestion queue and reports
+// how many were written, e.g. so tests can read back what they sent in async
+// storage mode
+func (h *AdminHandler) HandleFlush(w http.ResponseWriter, r *http.Request) {
+	if !h.allowFlush || h.flusher == nil {
+		h.logger.WithField("remote_addr", r.RemoteAddr).Warn("Rejected queue flush, ALLOW_ADMIN_FLUSH is disabled")
+		writeErrorResponse(w, http.StatusForbidden, "Queue flush is disabled")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	result := h.flusher.Flush(ctx)
+	h.logger.WithFields(logrus.Fields{
+		"flushed_count": result.FlushedCount,
+		"failed_count":  result.FailedCount,
+		"batch_count":   result.BatchCount,
+	}).Info("Flushed log queue")
+
+	statusCode := http.StatusOK
+	if !result.Success {
+		statusCode = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+	"github.com/timberline/log-ingestor/internal/storage"
+)
+
+// newQueuedStreamHandler returns a handler without workers holding count queued entries
+func newQueuedStreamHandler(t *testing.T, mockStorage *MockStreamStorage, maxBatchSize, count int) *StreamHandler {
+	// A long batch timeout and no workers leave the entries queued until flushed
+	handler := NewStreamHandler(mockStorage, maxBatchSize, time.Hour, make(chan *models.LogEntry, 10))
+	now := time.Now().UnixMilli()
+	for i := 0; i < count; i++ {
+		require.NoError(t, handler.Enqueue(&models.LogEntry{Timestamp: now, Message: fmt.Sprintf("queued %d", i)}))
+	}
+	return handler
+}
+
+func TestAdminHandler_HandleFlush(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	streamHandler := newQueuedStreamHandler(t, mockStorage, 2, 5)
+
+	var batchSizes []int
+	mockStorage.On("StoreBatch", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		batchSizes = append(batchSizes, len(args.Get(1).([]*models.LogEntry)))
+	}).Times(3)
+
+	handler := NewAdminHandler(new(MockAdminStorage), false, logrus.New())
+	handler.SetQueueFlusher(streamHandler, true)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/flush", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleFlush(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response models.FlushResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, models.FlushResponse{Success: true, FlushedCount: 5, BatchCount: 3}, response)
+	assert.Equal(t, []int{2, 2, 1}, batchSizes)
+	assert.Empty(t, streamHandler.logChannel)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestAdminHandler_HandleFlush_ReportsFailures(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	streamHandler := newQueuedStreamHandler(t, mockStorage, 10, 3)
+
+	// One of the three entries cannot be stored
+	mockStorage.On("StoreBatch", mock.Anything, mock.Anything).
+		Return(&storage.BatchError{Failed: make([]*models.LogEntry, 1), Total: 3, Err: assert.AnError}).Once()
+
+	handler := NewAdminHandler(new(MockAdminStorage), false, logrus.New())
+	handler.SetQueueFlusher(streamHandler, true)
+
+	rr := httptest.NewRecorder()
+	handler.HandleFlush(rr, httptest.NewRequest(http.MethodPost, "/api/v1/admin/flush", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	var response models.FlushResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, models.FlushResponse{Success: false, FlushedCount: 2, FailedCount: 1, BatchCount: 1}, response)
+}
+
+func TestAdminHandler_HandleFlush_Disabled(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	streamHandler := newQueuedStreamHandler(t, mockStorage, 10, 1)
+
+	handler := NewAdminHandler(new(MockAdminStorage), false, logrus.New())
+	handler.SetQueueFlusher(streamHandler, false)
+
+	rr := httptest.NewRecorder()
+	handler.HandleFlush(rr, httptest.NewRequest(http.MethodPost, "/api/v1/admin/flush", nil))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Len(t, streamHandler.logChannel, 1)
+	mockStorage.AssertNotCalled(t, "StoreBatch", mock.Anything, mock.Anything)
+}
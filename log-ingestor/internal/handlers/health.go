@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -11,8 +12,15 @@ import (
 	"github.com/timberline/log-ingestor/internal/storage"
 )
 
+// EndpointHealthReporter reports the last observed health of each embedding
+// endpoint, keyed by endpoint URL
+type EndpointHealthReporter interface {
+	EndpointHealth() map[string]bool
+}
+
 type HealthHandler struct {
 	storage   storage.StorageInterface
+	embedding EndpointHealthReporter
 	logger    *logrus.Logger
 	startTime time.Time
 	version   string
@@ -27,6 +35,12 @@ func NewHealthHandler(storage storage.StorageInterface, version string, logger *
 	}
 }
 
+// SetEmbeddingHealth adds an embedding check to the health response, which is
+// unhealthy once no endpoint is
+func (h *HealthHandler) SetEmbeddingHealth(reporter EndpointHealthReporter) {
+	h.embedding = reporter
+}
+
 func (h *HealthHandler) HandleHealth(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
@@ -34,6 +48,9 @@ func (h *HealthHandler) HandleHealth(w http.ResponseWriter, r *http.Request) {
 	checks := []models.HealthCheck{
 		h.checkStorage(ctx),
 	}
+	if h.embedding != nil {
+		checks = append(checks, h.checkEmbedding())
+	}
 
 	overallStatus := "healthy"
 	for _, check := range checks {
@@ -82,6 +99,27 @@ func (h *HealthHandler) checkStorage(ctx context.Context) models.HealthCheck {
 	}
 }
 
+// checkEmbedding reports how many embedding endpoints last failed. Endpoint
+// URLs are left out as they may carry credentials.
+func (h *HealthHandler) checkEmbedding() models.HealthCheck {
+	endpoints := h.embedding.EndpointHealth()
+	unhealthy := 0
+	for _, healthy := range endpoints {
+		if !healthy {
+			unhealthy++
+		}
+	}
+
+	check := models.HealthCheck{Name: "embedding", Status: "healthy"}
+	if unhealthy > 0 {
+		check.Message = fmt.Sprintf("%d of %d endpoints unhealthy", unhealthy, len(endpoints))
+	}
+	if unhealthy == len(endpoints) {
+		check.Status = "unhealthy"
+	}
+	return check
+}
+
 func (h *HealthHandler) HandleLiveness(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte("OK"))
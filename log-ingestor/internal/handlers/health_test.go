@@ -25,6 +25,13 @@ func (m *mockStorage) StoreLog(ctx context.Context, log *models.LogEntry) error
 	return nil
 }
 
+func (m *mockStorage) StoreBatch(ctx context.Context, logs []*models.LogEntry) error {
+	if m.healthCheckError {
+		return errors.New("storage error")
+	}
+	return nil
+}
+
 func (m *mockStorage) Connect(ctx context.Context) error {
 	if m.healthCheckError {
 		return errors.New("connection error")
@@ -169,6 +176,64 @@ func TestHealthHandler_HandleHealth_Unhealthy(t *testing.T) {
 	}
 }
 
+// staticEndpointHealth reports a fixed health per embedding endpoint
+type staticEndpointHealth map[string]bool
+
+func (s staticEndpointHealth) EndpointHealth() map[string]bool {
+	return s
+}
+
+func TestHealthHandler_HandleHealth_EmbeddingEndpoints(t *testing.T) {
+	tests := []struct {
+		name           string
+		endpoints      staticEndpointHealth
+		expectedCode   int
+		expectedStatus string
+		expectedMsg    string
+	}{
+		{"All endpoints healthy", staticEndpointHealth{"http://a": true, "http://b": true}, http.StatusOK, "healthy", ""},
+		{"One endpoint down", staticEndpointHealth{"http://a": false, "http://b": true}, http.StatusOK, "healthy", "1 of 2 endpoints unhealthy"},
+		{"All endpoints down", staticEndpointHealth{"http://a": false, "http://b": false}, http.StatusServiceUnavailable, "unhealthy", "2 of 2 endpoints unhealthy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHealthHandler(&mockStorage{}, "1.0.0", logrus.New())
+			handler.SetEmbeddingHealth(tt.endpoints)
+
+			req := httptest.NewRequest(http.MethodGet, "/health", nil)
+			rr := httptest.NewRecorder()
+			handler.HandleHealth(rr, req)
+
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+
+			var response models.HealthResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+
+			found := false
+			for _, check := range response.Checks {
+				if check.Name != "embedding" {
+					continue
+				}
+				found = true
+				if check.Status != tt.expectedStatus {
+					t.Errorf("Expected embedding check to be %s, got '%s'", tt.expectedStatus, check.Status)
+				}
+				if check.Message != tt.expectedMsg {
+					t.Errorf("Expected message %q, got %q", tt.expectedMsg, check.Message)
+				}
+			}
+			if !found {
+				t.Error("Expected embedding health check to be present")
+			}
+		})
+	}
+}
+
 func TestHealthHandler_HandleLiveness(t *testing.T) {
 	storage := &mockStorage{}
 	handler := NewHealthHandler(storage, "1.0.0", logrus.New())
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Reasons a line is counted in log_ingestor_invalid_lines
+const (
+	InvalidReasonNotJSON      = "not_json"
+	InvalidReasonEmptyMessage = "empty_message"
+	InvalidReasonValidation   = "validation"
+	InvalidReasonSchema       = "schema"
+	InvalidReasonOversized    = "oversized"
+	InvalidReasonMetadata     = "missing_metadata"
+)
+
+// DefaultInvalidLineLogInterval is the default minimum time between two
+// logged invalid lines
+const DefaultInvalidLineLogInterval = time.Second
+
+// invalidLineLogLimit caps how much of an invalid line is logged
+const invalidLineLogLimit = 512
+
+// logSampler lets at most one event through per interval and counts the
+// events it suppresses in between. A zero interval lets every event through.
+type logSampler struct {
+	interval   time.Duration
+	next       atomic.Int64
+	suppressed atomic.Int64
+}
+
+// allow reports whether an event may be logged now, along with the number of
+// events suppressed since the last one allowed
+func (s *logSampler) allow() (bool, int64) {
+	if s.interval <= 0 {
+		return true, 0
+	}
+
+	now := time.Now().UnixNano()
+	next := s.next.Load()
+	if now < next || !s.next.CompareAndSwap(next, now+s.interval.Nanoseconds()) {
+		s.suppressed.Add(1)
+		return false, 0
+	}
+	return true, s.suppressed.Swap(0)
+}
+
+// SetInvalidLineLogInterval limits logging of invalid lines to one per
+// interval; the rest are only counted. Zero logs every invalid line.
+func (h *StreamHandler) SetInvalidLineLogInterval(interval time.Duration) {
+	h.invalidLog = &logSampler{interval: interval}
+}
+
+// recordInvalidLine counts an invalid line under reason and logs it with
+// fields, subject to the invalid line log interval
+func (h *StreamHandler) recordInvalidLine(reason string, fields logrus.Fields, err error) {
+	h.metrics.invalidLines.Inc()
+	h.metrics.invalidReasons.WithLabelValues(reason).Inc()
+
+	allowed, suppressed := true, int64(0)
+	if h.invalidLog != nil {
+		allowed, suppressed = h.invalidLog.allow()
+	}
+	if !allowed {
+		return
+	}
+
+	entry := h.logger.WithFields(fields).WithField("reason", reason)
+	if suppressed > 0 {
+		entry = entry.WithField("suppressed", suppressed)
+	}
+	if err != nil {
+		entry = entry.WithError(err)
+	}
+	entry.Warn("Invalid log line")
+}
+
+// truncateLine shortens line for logging, keeping it valid UTF-8
+func truncateLine(line string) string {
+	if len(line) <= invalidLineLogLimit {
+		return line
+	}
+	return strings.ToValidUTF8(line[:invalidLineLogLimit], "") + "..."
+}
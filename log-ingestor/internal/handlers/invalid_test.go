@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamHandler_HandleStream_InvalidLineReasons(t *testing.T) {
+	now := time.Now().UnixMilli()
+	future := time.Now().Add(48 * time.Hour).UnixMilli()
+	requestBody := fmt.Sprintf(`not json at all
+{"timestamp": %d, "message": "   ", "source": "test"}
+{"timestamp": %d, "message": "from the future", "source": "test"}
+{"timestamp": %d, "message": "kept", "source": "test"}
+{"timestamp": %d, "message": "truncated`, now, future, now, now)
+
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+	logger, hook := logtest.NewNullLogger()
+	handler.logger = logger
+	mockStorage.On("StoreBatch", mock.Anything, mock.Anything).Return(nil).Once()
+
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	// Wait for worker to process entries
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, float64(2), testutil.ToFloat64(handler.metrics.invalidReasons.WithLabelValues(InvalidReasonNotJSON)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(handler.metrics.invalidReasons.WithLabelValues(InvalidReasonEmptyMessage)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(handler.metrics.invalidReasons.WithLabelValues(InvalidReasonValidation)))
+	assert.Equal(t, float64(4), testutil.ToFloat64(handler.metrics.invalidLines))
+
+	// Unparseable lines are logged with the decoders that were tried
+	var logged []string
+	for _, entry := range hook.AllEntries() {
+		if entry.Data["reason"] == InvalidReasonNotJSON {
+			assert.Equal(t, []string{"log_entry", "fluent_bit"}, entry.Data["decoders"])
+			logged = append(logged, entry.Data["line"].(string))
+		}
+	}
+	assert.Equal(t, []string{"not json at all", fmt.Sprintf(`{"timestamp": %d, "message": "truncated`, now)}, logged)
+}
+
+func TestStreamHandler_RecordInvalidLine_Sampled(t *testing.T) {
+	handler := newTestStreamHandler(new(MockStreamStorage), 100)
+	logger, hook := logtest.NewNullLogger()
+	handler.logger = logger
+	handler.SetInvalidLineLogInterval(time.Hour)
+
+	for i := 0; i < 3; i++ {
+		handler.recordInvalidLine(InvalidReasonNotJSON, nil, nil)
+	}
+
+	// Every line is counted, but only the first is logged within the interval
+	assert.Equal(t, float64(3), testutil.ToFloat64(handler.metrics.invalidReasons.WithLabelValues(InvalidReasonNotJSON)))
+	require.Len(t, hook.AllEntries(), 1)
+
+	// The next logged line reports how many were suppressed
+	handler.invalidLog.next.Store(0)
+	handler.recordInvalidLine(InvalidReasonNotJSON, nil, nil)
+	require.Len(t, hook.AllEntries(), 2)
+	assert.Equal(t, int64(2), hook.LastEntry().Data["suppressed"])
+}
+
+func TestTruncateLine(t *testing.T) {
+	assert.Equal(t, "short", truncateLine("short"))
+
+	long := strings.Repeat("é", invalidLineLogLimit)
+	truncated := truncateLine(long)
+	assert.True(t, strings.HasSuffix(truncated, "..."))
+	assert.LessOrEqual(t, len(truncated), invalidLineLogLimit+3)
+	assert.Equal(t, strings.Repeat("é", invalidLineLogLimit/2)+"...", truncated)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/timberline/log-ingestor/internal/models"
+	"github.com/timberline/log-ingestor/internal/storage"
+)
+
+const (
+	// DefaultRecentLimit is the number of logs returned when no limit is given
+	DefaultRecentLimit = 50
+	// MaxRecentLimit caps the number of logs a single recent-logs request may return
+	MaxRecentLimit = 1000
+
+	// DefaultSearchLimit is the number of matches returned when no limit is given
+	DefaultSearchLimit = 10
+	// MaxSearchLimit caps the number of matches a single search may return
+	MaxSearchLimit = 100
+	// MaxSearchEf caps the per-request HNSW ef so one query cannot monopolize the query nodes
+	MaxSearchEf = 2048
+	// maxSearchRequestSize bounds the search request body
+	maxSearchRequestSize = 64 * 1024
+)
+
+type LogsHandler struct {
+	storage storage.QueryInterface
+	logger  *logrus.Logger
+}
+
+func NewLogsHandler(storage storage.QueryInterface, logger *logrus.Logger) *LogsHandler {
+	return &LogsHandler{
+		storage: storage,
+		logger:  logger,
+	}
+}
+
+// HandleRecent returns the newest stored logs, optionally filtered by source
+func (h *LogsHandler) HandleRecent(w http.ResponseWriter, r *http.Request) {
+	limit := DefaultRecentLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			writeErrorResponse(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+	if limit > MaxRecentLimit {
+		limit = MaxRecentLimit
+	}
+
+	source := r.URL.Query().Get("source")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	logs, err := h.storage.RecentLogs(ctx, limit, source)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to fetch recent logs")
+		if storage.IsUnavailable(err) {
+			writeUnavailableResponse(w, "Storage unavailable, retry later")
+			return
+		}
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to fetch recent logs")
+		return
+	}
+
+	response := models.LogsResponse{
+		Logs:  logs,
+		Count: len(logs),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// HandleSearch returns the stored logs most similar to the query text
+func (h *LogsHandler) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	var request models.SearchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSearchRequestSize)).Decode(&request); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid search request")
+		return
+	}
+	if request.Query == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "query is required")
+		return
+	}
+	if request.Limit < 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "limit must be a positive integer")
+		return
+	}
+	if request.Ef < 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "ef must be a positive integer")
+		return
+	}
+
+	limit := request.Limit
+	if limit == 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+	ef := request.Ef
+	if ef > MaxSearchEf {
+		ef = MaxSearchEf
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	matches, err := h.storage.SearchLogs(ctx, request.Query, limit, ef, request.Filters)
+	if errors.Is(err, storage.ErrInvalidFilter) {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to search logs")
+		if storage.IsUnavailable(err) {
+			writeUnavailableResponse(w, "Storage unavailable, retry later")
+			return
+		}
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to search logs")
+		return
+	}
+
+	response := models.SearchResponse{
+		Matches: matches,
+		Count:   len(matches),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
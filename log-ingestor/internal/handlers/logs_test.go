@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+	"github.com/timberline/log-ingestor/internal/storage"
+)
+
+// MockQueryStorage is a mock implementation of storage.QueryInterface
+type MockQueryStorage struct {
+	mock.Mock
+}
+
+func (m *MockQueryStorage) RecentLogs(ctx context.Context, limit int, source string) ([]*models.StoredLog, error) {
+	args := m.Called(ctx, limit, source)
+	logs, _ := args.Get(0).([]*models.StoredLog)
+	return logs, args.Error(1)
+}
+
+func (m *MockQueryStorage) SearchLogs(ctx context.Context, query string, limit, ef int, filters map[string]interface{}) ([]*models.SearchMatch, error) {
+	args := m.Called(ctx, query, limit, ef, filters)
+	matches, _ := args.Get(0).([]*models.SearchMatch)
+	return matches, args.Error(1)
+}
+
+func TestLogsHandler_HandleRecent_Success(t *testing.T) {
+	mockStorage := new(MockQueryStorage)
+	handler := NewLogsHandler(mockStorage, logrus.New())
+
+	stored := []*models.StoredLog{
+		{ID: 2, Timestamp: 2000, Message: "newest", Source: "api", DuplicateCount: 1},
+		{ID: 1, Timestamp: 1000, Message: "older", Source: "api", DuplicateCount: 3},
+	}
+	mockStorage.On("RecentLogs", mock.Anything, 10, "api").Return(stored, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/logs/recent?limit=10&source=api", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleRecent(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var response models.LogsResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.Count)
+	require.Len(t, response.Logs, 2)
+	assert.Equal(t, "newest", response.Logs[0].Message)
+	assert.Equal(t, int64(3), response.Logs[1].DuplicateCount)
+
+	mockStorage.AssertExpectations(t)
+}
+
+func TestLogsHandler_HandleRecent_DefaultLimit(t *testing.T) {
+	mockStorage := new(MockQueryStorage)
+	handler := NewLogsHandler(mockStorage, logrus.New())
+
+	mockStorage.On("RecentLogs", mock.Anything, DefaultRecentLimit, "").Return([]*models.StoredLog{}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/logs/recent", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleRecent(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestLogsHandler_HandleRecent_ClampsLimit(t *testing.T) {
+	mockStorage := new(MockQueryStorage)
+	handler := NewLogsHandler(mockStorage, logrus.New())
+
+	mockStorage.On("RecentLogs", mock.Anything, MaxRecentLimit, "").Return([]*models.StoredLog{}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/logs/recent?limit=50000", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleRecent(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestLogsHandler_HandleRecent_InvalidLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit string
+	}{
+		{"not a number", "abc"},
+		{"zero", "0"},
+		{"negative", "-5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(MockQueryStorage)
+			handler := NewLogsHandler(mockStorage, logrus.New())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/logs/recent?limit="+tt.limit, nil)
+			rr := httptest.NewRecorder()
+			handler.HandleRecent(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			mockStorage.AssertNotCalled(t, "RecentLogs", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestLogsHandler_HandleRecent_StorageError(t *testing.T) {
+	mockStorage := new(MockQueryStorage)
+	handler := NewLogsHandler(mockStorage, logrus.New())
+
+	mockStorage.On("RecentLogs", mock.Anything, DefaultRecentLimit, "").Return(nil, assert.AnError).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/logs/recent", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleRecent(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	var response models.BatchResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.False(t, response.Success)
+}
+
+func TestLogsHandler_HandleRecent_StorageUnavailable(t *testing.T) {
+	mockStorage := new(MockQueryStorage)
+	handler := NewLogsHandler(mockStorage, logrus.New())
+
+	mockStorage.On("RecentLogs", mock.Anything, DefaultRecentLimit, "").Return(nil, storage.ErrNotConnected).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/logs/recent", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleRecent(rr, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "5", rr.Header().Get("Retry-After"))
+}
+
+func TestLogsHandler_HandleSearch_Success(t *testing.T) {
+	mockStorage := new(MockQueryStorage)
+	handler := NewLogsHandler(mockStorage, logrus.New())
+
+	matches := []*models.SearchMatch{
+		{StoredLog: models.StoredLog{ID: 7, Message: "connection refused", Source: "api"}, Score: 0.91},
+	}
+	mockStorage.On("SearchLogs", mock.Anything, "connection errors", DefaultSearchLimit, 0, mock.Anything).Return(matches, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/logs/search", strings.NewReader(`{"query":"connection errors"}`))
+	rr := httptest.NewRecorder()
+	handler.HandleSearch(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var response models.SearchResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.Count)
+	require.Len(t, response.Matches, 1)
+	assert.Equal(t, int64(7), response.Matches[0].ID)
+	assert.Equal(t, float32(0.91), response.Matches[0].Score)
+
+	mockStorage.AssertExpectations(t)
+}
+
+func TestLogsHandler_HandleSearch_EfAndLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		expectLimit int
+		expectEf    int
+	}{
+		{"Per-request ef is applied", `{"query":"timeout","limit":5,"ef":256}`, 5, 256},
+		{"Ef above maximum is clamped", `{"query":"timeout","ef":100000}`, DefaultSearchLimit, MaxSearchEf},
+		{"Limit above maximum is clamped", `{"query":"timeout","limit":5000}`, MaxSearchLimit, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(MockQueryStorage)
+			handler := NewLogsHandler(mockStorage, logrus.New())
+
+			mockStorage.On("SearchLogs", mock.Anything, "timeout", tt.expectLimit, tt.expectEf, mock.Anything).
+				Return([]*models.SearchMatch{}, nil).Once()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/logs/search", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			handler.HandleSearch(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLogsHandler_HandleSearch_Filters(t *testing.T) {
+	mockStorage := new(MockQueryStorage)
+	handler := NewLogsHandler(mockStorage, logrus.New())
+
+	filters := map[string]interface{}{"level": "ERROR", "namespace": "X", "after": float64(1700000000000)}
+	mockStorage.On("SearchLogs", mock.Anything, "timeout", DefaultSearchLimit, 0, filters).
+		Return([]*models.SearchMatch{}, nil).Once()
+
+	body := `{"query":"timeout","filters":{"level":"ERROR","namespace":"X","after":1700000000000}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/logs/search", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	handler.HandleSearch(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestLogsHandler_HandleSearch_InvalidFilter(t *testing.T) {
+	mockStorage := new(MockQueryStorage)
+	handler := NewLogsHandler(mockStorage, logrus.New())
+
+	err := fmt.Errorf("%w: pod is not a filterable field", storage.ErrInvalidFilter)
+	mockStorage.On("SearchLogs", mock.Anything, "timeout", DefaultSearchLimit, 0, mock.Anything).Return(nil, err).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/logs/search", strings.NewReader(`{"query":"timeout","filters":{"pod":"api-0"}}`))
+	rr := httptest.NewRecorder()
+	handler.HandleSearch(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "pod is not a filterable field")
+}
+
+func TestLogsHandler_HandleSearch_InvalidRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"malformed JSON", `{"query":`},
+		{"missing query", `{"limit":5}`},
+		{"negative limit", `{"query":"timeout","limit":-1}`},
+		{"negative ef", `{"query":"timeout","ef":-1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(MockQueryStorage)
+			handler := NewLogsHandler(mockStorage, logrus.New())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/logs/search", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			handler.HandleSearch(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			mockStorage.AssertNotCalled(t, "SearchLogs", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestLogsHandler_HandleSearch_StorageUnavailable(t *testing.T) {
+	mockStorage := new(MockQueryStorage)
+	handler := NewLogsHandler(mockStorage, logrus.New())
+
+	mockStorage.On("SearchLogs", mock.Anything, "timeout", DefaultSearchLimit, 0, mock.Anything).Return(nil, storage.ErrNotConnected).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/logs/search", strings.NewReader(`{"query":"timeout"}`))
+	rr := httptest.NewRecorder()
+	handler.HandleSearch(rr, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// SetMethodNotAllowedHandler makes router answer requests whose path matches
+// a route but whose method does not with 405, an Allow header listing the
+// methods registered for the path and a JSON error. It is installed for
+// unmatched requests too, because gorilla/mux reports a method mismatch as
+// not found once a later subrouter fails to match; requests whose path
+// matches no route still get a plain 404.
+func SetMethodNotAllowedHandler(router *mux.Router) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed := allowedMethods(router, r)
+		if len(allowed) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method "+r.Method+" not allowed, use "+strings.Join(allowed, " or "))
+	})
+	router.MethodNotAllowedHandler = handler
+	router.NotFoundHandler = handler
+}
+
+// registeredMethods returns the sorted methods of all routes on router
+func registeredMethods(router *mux.Router) []string {
+	seen := make(map[string]struct{})
+	_ = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil // Route without a method matcher, e.g. a subrouter prefix
+		}
+		for _, method := range methods {
+			seen[method] = struct{}{}
+		}
+		return nil
+	})
+
+	methods := make([]string, 0, len(seen))
+	for method := range seen {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// allowedMethods returns the sorted methods of the routes matching the
+// request path
+func allowedMethods(router *mux.Router, r *http.Request) []string {
+	seen := make(map[string]struct{})
+	_ = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil // Route without a method matcher, e.g. a subrouter prefix
+		}
+		for _, method := range methods {
+			probe := r.Clone(r.Context())
+			probe.Method = method
+			if route.Match(probe, &mux.RouteMatch{}) {
+				seen[method] = struct{}{}
+			}
+		}
+		return nil
+	})
+
+	allowed := make([]string, 0, len(seen))
+	for method := range seen {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+	return allowed
+}
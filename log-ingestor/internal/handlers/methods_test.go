@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+func TestSetMethodNotAllowedHandler(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/logs/stream", ok).Methods("POST")
+	api.HandleFunc("/logs/recent", ok).Methods("GET")
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.HandleFunc("/collection", ok).Methods("DELETE")
+	SetMethodNotAllowedHandler(router)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+		expectedAllow  string
+	}{
+		{"GET to stream endpoint", http.MethodGet, "/api/v1/logs/stream", http.StatusMethodNotAllowed, "POST"},
+		{"PUT to stream endpoint", http.MethodPut, "/api/v1/logs/stream", http.StatusMethodNotAllowed, "POST"},
+		{"POST to recent endpoint", http.MethodPost, "/api/v1/logs/recent", http.StatusMethodNotAllowed, "GET"},
+		{"GET to nested admin route", http.MethodGet, "/api/v1/admin/collection", http.StatusMethodNotAllowed, "DELETE"},
+		{"POST to stream endpoint", http.MethodPost, "/api/v1/logs/stream", http.StatusOK, ""},
+		{"Unknown path stays 404", http.MethodGet, "/api/v1/logs/unknown", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedAllow, rr.Header().Get("Allow"))
+			if tt.expectedStatus == http.StatusMethodNotAllowed {
+				var response models.BatchResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.False(t, response.Success)
+				assert.Contains(t, response.Errors[0], tt.expectedAllow)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+// ErrSchemaViolation is returned by processStream in strict schema mode when a
+// line does not conform to the configured log schema
+var ErrSchemaViolation = errors.New("log entry does not match schema")
+
+// ErrMissingMetadata is returned by Enqueue for entries lacking a required
+// metadata key
+var ErrMissingMetadata = errors.New("log entry is missing required metadata")
+
+// LoadLogSchema compiles the JSON Schema at path for validating incoming lines
+func LoadLogSchema(path string) (*jsonschema.Schema, error) {
+	schema, err := jsonschema.NewCompiler().Compile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile log schema %s: %w", path, err)
+	}
+	return schema, nil
+}
+
+// SetLogSchema validates every incoming line against schema before it is
+// transformed. Non-conforming lines are counted as invalid and skipped, or
+// reject the whole request with 400 when strict is set. A nil schema disables
+// validation.
+func (h *StreamHandler) SetLogSchema(schema *jsonschema.Schema, strict bool) {
+	h.logSchema = schema
+	h.strictSchema = strict
+}
+
+// validateSchema checks a raw JSON line against the configured log schema
+func (h *StreamHandler) validateSchema(line string) error {
+	if h.logSchema == nil {
+		return nil
+	}
+
+	value, err := jsonschema.UnmarshalJSON(strings.NewReader(line))
+	if err != nil {
+		return err
+	}
+	if err := h.logSchema.Validate(value); err != nil {
+		return fmt.Errorf("%w: %v", ErrSchemaViolation, err)
+	}
+	return nil
+}
+
+// SetRequiredMetadataKeys makes Enqueue treat entries whose metadata lacks any
+// of keys, or holds it empty, as invalid. In stream requests such entries are
+// skipped, or reject the whole request with 400 when strict is set.
+func (h *StreamHandler) SetRequiredMetadataKeys(keys []string, strict bool) {
+	h.requiredMetadataKeys = keys
+	h.strictMetadata = strict
+}
+
+// checkRequiredMetadata returns an ErrMissingMetadata error naming the first
+// required key entry lacks
+func (h *StreamHandler) checkRequiredMetadata(entry *models.LogEntry) error {
+	for _, key := range h.requiredMetadataKeys {
+		value, ok := entry.Metadata[key]
+		if !ok || value == nil || value == "" {
+			return fmt.Errorf("%w: %s", ErrMissingMetadata, key)
+		}
+	}
+	return nil
+}
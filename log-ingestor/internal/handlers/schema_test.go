@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+const serviceSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["service"],
+	"properties": {
+		"service": {"type": "string", "minLength": 1}
+	}
+}`
+
+// writeSchema writes a schema document to a temporary file and returns its path
+func writeSchema(t *testing.T, schema string) string {
+	path := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(path, []byte(schema), 0o600))
+	return path
+}
+
+func TestLoadLogSchema_Invalid(t *testing.T) {
+	_, err := LoadLogSchema(writeSchema(t, `{"type": 42}`))
+	assert.Error(t, err)
+
+	_, err = LoadLogSchema(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestStreamHandler_HandleStream_LogSchema(t *testing.T) {
+	schema, err := LoadLogSchema(writeSchema(t, serviceSchema))
+	require.NoError(t, err)
+
+	now := time.Now().UnixMilli()
+	requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "conforming", "source": "test", "service": "billing"}
+{"timestamp": %d, "message": "missing service", "source": "test"}
+{"timestamp": %d, "message": "empty service", "source": "test", "service": ""}`, now, now, now)
+
+	tests := []struct {
+		name              string
+		strict            bool
+		expectedStatus    int
+		expectedProcessed int
+	}{
+		{"Lenient skips non-conforming entries", false, http.StatusOK, 1},
+		{"Strict rejects the request", true, http.StatusBadRequest, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(MockStreamStorage)
+			handler := newTestStreamHandler(mockStorage, 100)
+			handler.SetLogSchema(schema, tt.strict)
+
+			mockStorage.On("StoreBatch", mock.Anything, mock.MatchedBy(func(logs []*models.LogEntry) bool {
+				return len(logs) == 1 && logs[0].Message == "conforming"
+			})).Return(nil).Once()
+
+			req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
+			req.Header.Set("Content-Type", "application/x-ndjson")
+
+			rr := httptest.NewRecorder()
+			handler.HandleStream(rr, req)
+
+			// Wait for worker to process entries
+			time.Sleep(100 * time.Millisecond)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			var response models.BatchResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedProcessed, response.ProcessedCount)
+			if tt.strict {
+				assert.False(t, response.Success)
+				require.Len(t, response.Errors, 1)
+				assert.Contains(t, response.Errors[0], ErrSchemaViolation.Error())
+				assert.Equal(t, float64(1), testutil.ToFloat64(handler.metrics.invalidLines))
+			} else {
+				assert.True(t, response.Success)
+				assert.Equal(t, float64(2), testutil.ToFloat64(handler.metrics.invalidLines))
+			}
+
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStreamHandler_HandleStream_RequiredMetadataKeys(t *testing.T) {
+	now := time.Now().UnixMilli()
+	requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "compliant", "source": "test", "metadata": {"namespace": "prod", "pod_name": "api-0"}}
+{"timestamp": %d, "message": "missing pod", "source": "test", "metadata": {"namespace": "prod"}}
+{"timestamp": %d, "message": "empty namespace", "source": "test", "metadata": {"namespace": "", "pod_name": "api-1"}}
+{"timestamp": %d, "message": "no metadata", "source": "test"}`, now, now, now, now)
+
+	tests := []struct {
+		name              string
+		strict            bool
+		expectedStatus    int
+		expectedProcessed int
+		expectedInvalid   float64
+	}{
+		{"Lenient drops entries lacking a key", false, http.StatusOK, 1, 3},
+		{"Strict rejects the request", true, http.StatusBadRequest, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(MockStreamStorage)
+			handler := newTestStreamHandler(mockStorage, 100)
+			handler.SetRequiredMetadataKeys([]string{"namespace", "pod_name"}, tt.strict)
+
+			mockStorage.On("StoreBatch", mock.Anything, mock.MatchedBy(func(logs []*models.LogEntry) bool {
+				return len(logs) == 1 && logs[0].Message == "compliant"
+			})).Return(nil).Once()
+
+			req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
+			req.Header.Set("Content-Type", "application/x-ndjson")
+
+			rr := httptest.NewRecorder()
+			handler.HandleStream(rr, req)
+
+			// Wait for worker to process entries
+			time.Sleep(100 * time.Millisecond)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			var response models.BatchResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedProcessed, response.ProcessedCount)
+			if tt.strict {
+				require.Len(t, response.Errors, 1)
+				assert.Contains(t, response.Errors[0], "pod_name")
+			}
+			assert.Equal(t, tt.expectedInvalid, testutil.ToFloat64(handler.metrics.invalidReasons.WithLabelValues(InvalidReasonMetadata)))
+
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+func TestStreamHandler_HandleStream_Signature(t *testing.T) {
+	secret := []byte("shared-secret")
+	requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "signed", "source": "test"}`, time.Now().UnixMilli())
+	validSignature := hex.EncodeToString(SignBody(secret, []byte(requestBody)))
+
+	tests := []struct {
+		name           string
+		secret         string
+		body           string
+		signature      string
+		expectedStatus int
+	}{
+		{"Valid signature accepted", string(secret), requestBody, validSignature, http.StatusOK},
+		{"Prefixed signature accepted", string(secret), requestBody, "sha256=" + validSignature, http.StatusOK},
+		{"Tampered body rejected", string(secret), strings.Replace(requestBody, "signed", "forged", 1), validSignature, http.StatusUnauthorized},
+		{"Missing signature rejected", string(secret), requestBody, "", http.StatusUnauthorized},
+		{"Malformed signature rejected", string(secret), requestBody, "not-hex", http.StatusUnauthorized},
+		{"Verification disabled", "", requestBody, "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(MockStreamStorage)
+			handler := newTestStreamHandler(mockStorage, 100)
+			handler.SetSigningSecret(tt.secret)
+			if tt.expectedStatus == http.StatusOK {
+				mockStorage.On("StoreBatch", mock.Anything, mock.MatchedBy(func(logs []*models.LogEntry) bool {
+					return len(logs) == 1 && logs[0].Message == "signed"
+				})).Return(nil).Once()
+			}
+
+			req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/x-ndjson")
+			if tt.signature != "" {
+				req.Header.Set(SignatureHeader, tt.signature)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.HandleStream(rr, req)
+
+			// Wait for worker to process entries
+			time.Sleep(100 * time.Millisecond)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus != http.StatusOK {
+				mockStorage.AssertNotCalled(t, "StoreBatch", mock.Anything, mock.Anything)
+			}
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStreamHandler_HandleStream_SignedBodyTooLarge(t *testing.T) {
+	secret := []byte("shared-secret")
+	requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "%s", "source": "test"}`, time.Now().UnixMilli(), strings.Repeat("x", 1024))
+
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+	handler.SetSigningSecret(string(secret))
+	handler.SetMaxRequestSize(512)
+
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set(SignatureHeader, hex.EncodeToString(SignBody(secret, []byte(requestBody))))
+
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	mockStorage.AssertNotCalled(t, "StoreBatch", mock.Anything, mock.Anything)
+}
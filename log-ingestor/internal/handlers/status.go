@@ -0,0 +1,25 @@
+package handlers
+
+import "net/http"
+
+// StatusRecorder wraps a ResponseWriter to capture the status code written by
+// a handler, for middleware that logs or audits the outcome
+type StatusRecorder struct {
+	http.ResponseWriter
+	StatusCode int
+}
+
+// NewStatusRecorder wraps w, reporting 200 until a handler writes another status
+func NewStatusRecorder(w http.ResponseWriter) *StatusRecorder {
+	return &StatusRecorder{ResponseWriter: w, StatusCode: http.StatusOK}
+}
+
+func (rw *StatusRecorder) WriteHeader(code int) {
+	rw.StatusCode = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap exposes the underlying ResponseWriter to http.ResponseController
+func (rw *StatusRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
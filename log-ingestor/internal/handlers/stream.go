@@ -2,16 +2,33 @@ package handlers
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/santhosh-tekuri/jsonschema/v6"
 	"github.com/sirupsen/logrus"
+	"github.com/timberline/log-ingestor/internal/dlq"
 	"github.com/timberline/log-ingestor/internal/models"
 	"github.com/timberline/log-ingestor/internal/storage"
+	"github.com/timberline/log-ingestor/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // FlexibleTimestamp can unmarshal both string and numeric timestamps
@@ -64,6 +81,10 @@ type FluentBitLogEntry struct {
 	Log        string                 `json:"log"`                  // The log message content
 	Kubernetes map[string]interface{} `json:"kubernetes,omitempty"` // Kubernetes metadata
 	Source     string                 `json:"source,omitempty"`     // Source identifier
+
+	// Container image fields some Fluent Bit inputs emit beside the kubernetes map
+	ContainerImage string `json:"container_image,omitempty"`
+	ContainerHash  string `json:"container_hash,omitempty"`
 }
 
 // transformFluentBitEntry converts a Fluent Bit log entry to our internal format
@@ -94,15 +115,121 @@ func (fb *FluentBitLogEntry) transformToLogEntry() *models.LogEntry {
 		entry.Source = "unknown"
 	}
 
+	// Keep container image and hash under stable top-level metadata keys
+	// wherever Fluent Bit placed them
+	entry.Metadata = promoteMetadata(entry.Metadata, models.ContainerImageKey, fb.ContainerImage)
+	entry.Metadata = promoteMetadata(entry.Metadata, models.ContainerHashKey, fb.ContainerHash)
+
 	return entry
 }
 
+// promoteMetadata sets metadata[key] to value unless value is empty or the key
+// is already set, allocating metadata when needed
+func promoteMetadata(metadata map[string]interface{}, key, value string) map[string]interface{} {
+	if value == "" {
+		return metadata
+	}
+	if existing, ok := metadata[key].(string); ok && existing != "" {
+		return metadata
+	}
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata[key] = value
+	return metadata
+}
+
+// DefaultMaxLineSize is the longest JSON line accepted when no limit is configured
+const DefaultMaxLineSize = 1024 * 1024
+
+// StorageRetryAfter is how long clients are asked to back off while storage is unavailable
+const StorageRetryAfter = 5 * time.Second
+
+// ErrQueueFull is returned by Enqueue when the processing channel has no room
+var ErrQueueFull = errors.New("log channel full")
+
+// ErrDraining is returned by Enqueue once Drain has closed the processing channel
+var ErrDraining = errors.New("log queue is draining")
+
+// ErrEmptyMessage is returned by Enqueue for entries whose message is empty or whitespace
+var ErrEmptyMessage = errors.New("message is empty")
+
 type StreamHandler struct {
 	storage      storage.StorageInterface
 	logger       *logrus.Logger
 	metrics      *StreamMetrics
 	maxBatchSize int
+	batchTimeout time.Duration
 	logChannel   chan *models.LogEntry
+
+	// maxLineSize is the longest accepted line; longer lines are skipped
+	maxLineSize int
+
+	// maxRequestSize caps the raw request body in bytes; zero means no limit
+	maxRequestSize int64
+
+	// deadLetter receives entries that could not be stored; nil disables it
+	deadLetter dlq.Sink
+
+	// flattenMetadata collapses nested metadata objects into dot-notation keys
+	flattenMetadata bool
+
+	// componentFields are metadata keys copied into metadata.component
+	componentFields []string
+
+	// timestampBounds limits accepted timestamps; zero values use the defaults
+	timestampBounds models.TimestampBounds
+
+	// clampFutureTimestamps moves slightly-future timestamps back to now
+	clampFutureTimestamps bool
+
+	// asyncStorage answers 202 once entries are queued and 429 when the queue is full
+	asyncStorage bool
+
+	// skipEmptyMessages rejects entries whose message is only whitespace
+	skipEmptyMessages bool
+
+	// decodeBase64 decodes base64-encoded lines and messages
+	decodeBase64 bool
+
+	// detectStackTraces marks messages that look like stack traces in metadata.is_stacktrace
+	detectStackTraces bool
+
+	// logSchema validates raw lines when set; strictSchema rejects the request
+	// on the first non-conforming line instead of skipping it
+	logSchema    *jsonschema.Schema
+	strictSchema bool
+
+	// requiredMetadataKeys must be present in every entry's metadata;
+	// strictMetadata rejects the request on the first entry lacking one
+	requiredMetadataKeys []string
+	strictMetadata       bool
+
+	// skewedVersions records the client minor schema versions already logged
+	// as skewed, up to maxSkewedVersions
+	skewedMu       sync.Mutex
+	skewedVersions map[int]struct{}
+
+	// invalidLog rate-limits logging of invalid lines; nil logs every one
+	invalidLog *logSampler
+
+	// signingSecret is the shared HMAC key requests must be signed with; empty disables verification
+	signingSecret []byte
+
+	// dropPatterns drop entries whose message matches any of them
+	dropPatterns []*regexp.Regexp
+
+	// workers tracks running StartWorker goroutines so Drain can wait for them
+	workers sync.WaitGroup
+
+	// drainMu guards draining, so Enqueue never publishes to the closed channel
+	// of requests still running when Drain is called
+	drainMu  sync.RWMutex
+	draining bool
+
+	// unavailableUntil holds the UnixNano time until which new streams are
+	// rejected because the worker last failed to reach storage
+	unavailableUntil atomic.Int64
 }
 
 type StreamMetrics struct {
@@ -112,10 +239,16 @@ type StreamMetrics struct {
 	batchesCreated  prometheus.Counter
 	errorsTotal     prometheus.Counter
 	invalidLines    prometheus.Counter
+	oversizedLines  prometheus.Counter
+	invalidReasons  *prometheus.CounterVec
 	queueSize       prometheus.Gauge
+	collectionLag   prometheus.Histogram
+
+	batchDistinctSources prometheus.Histogram
+	droppedByPattern     prometheus.Counter
 }
 
-func NewStreamHandler(storage storage.StorageInterface, maxBatchSize int, logChannel chan *models.LogEntry) *StreamHandler {
+func NewStreamHandler(storage storage.StorageInterface, maxBatchSize int, batchTimeout time.Duration, logChannel chan *models.LogEntry) *StreamHandler {
 	metrics := &StreamMetrics{
 		requestsTotal: prometheus.NewCounter(prometheus.CounterOpts{
 			Name: "log_ingestor_stream_requests_total",
@@ -142,10 +275,32 @@ func NewStreamHandler(storage storage.StorageInterface, maxBatchSize int, logCha
 			Name: "log_ingestor_stream_invalid_lines_total",
 			Help: "Total number of invalid JSON lines",
 		}),
+		oversizedLines: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "log_ingestor_stream_oversized_lines_total",
+			Help: "Total number of lines skipped for exceeding the maximum line size",
+		}),
+		droppedByPattern: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "log_ingestor_stream_dropped_by_pattern_total",
+			Help: "Total number of entries dropped for matching INGEST_DROP_PATTERNS",
+		}),
+		invalidReasons: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "log_ingestor_invalid_lines",
+			Help: "Number of invalid lines by reason",
+		}, []string{"reason"}),
 		queueSize: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "log_ingestor_queue_size",
 			Help: "Current number of log entries in the processing queue",
 		}),
+		collectionLag: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "log_ingestor_collection_lag_seconds",
+			Help:    "Time from the collector reading a log to the ingestor receiving it",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300},
+		}),
+		batchDistinctSources: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "log_ingestor_batch_distinct_sources",
+			Help:    "Number of distinct sources in each stored batch",
+			Buckets: []float64{1, 2, 3, 5, 10, 20, 50, 100},
+		}),
 	}
 
 	// Register metrics, ignoring duplicate registration errors for tests
@@ -155,17 +310,91 @@ func NewStreamHandler(storage storage.StorageInterface, maxBatchSize int, logCha
 	_ = prometheus.DefaultRegisterer.Register(metrics.batchesCreated)
 	_ = prometheus.DefaultRegisterer.Register(metrics.errorsTotal)
 	_ = prometheus.DefaultRegisterer.Register(metrics.invalidLines)
+	_ = prometheus.DefaultRegisterer.Register(metrics.oversizedLines)
+	_ = prometheus.DefaultRegisterer.Register(metrics.droppedByPattern)
+	_ = prometheus.DefaultRegisterer.Register(metrics.invalidReasons)
 	_ = prometheus.DefaultRegisterer.Register(metrics.queueSize)
+	_ = prometheus.DefaultRegisterer.Register(metrics.collectionLag)
+	_ = prometheus.DefaultRegisterer.Register(metrics.batchDistinctSources)
 
 	return &StreamHandler{
 		storage:      storage,
 		logger:       logrus.StandardLogger(),
 		metrics:      metrics,
 		maxBatchSize: maxBatchSize,
+		batchTimeout: batchTimeout,
 		logChannel:   logChannel,
+		maxLineSize:  DefaultMaxLineSize,
+		invalidLog:   &logSampler{interval: DefaultInvalidLineLogInterval},
+
+		skipEmptyMessages: true,
 	}
 }
 
+// SetMaxLineSize configures the longest line accepted in a stream
+func (h *StreamHandler) SetMaxLineSize(maxLineSize int) {
+	h.maxLineSize = maxLineSize
+}
+
+// SetMaxRequestSize configures the largest raw request body accepted, before
+// any Content-Encoding is decoded. Larger requests are answered with 413.
+func (h *StreamHandler) SetMaxRequestSize(maxRequestSize int64) {
+	h.maxRequestSize = maxRequestSize
+}
+
+// SetDeadLetterSink configures where entries that fail to store are written
+func (h *StreamHandler) SetDeadLetterSink(sink dlq.Sink) {
+	h.deadLetter = sink
+}
+
+// SetFlattenMetadata enables flattening nested metadata into dot-notation keys
+func (h *StreamHandler) SetFlattenMetadata(flatten bool) {
+	h.flattenMetadata = flatten
+}
+
+// SetComponentFields configures metadata keys (in priority order) whose value
+// is copied into metadata.component
+func (h *StreamHandler) SetComponentFields(fields []string) {
+	h.componentFields = fields
+}
+
+// SetTimestampBounds configures how far entry timestamps may deviate from now
+func (h *StreamHandler) SetTimestampBounds(bounds models.TimestampBounds) {
+	h.timestampBounds = bounds
+}
+
+// SetAsyncStorage makes the stream endpoint answer 202 Accepted once entries
+// are queued, and 429 Too Many Requests when the queue is full instead of
+// silently dropping the remaining entries
+func (h *StreamHandler) SetAsyncStorage(async bool) {
+	h.asyncStorage = async
+}
+
+// SetClampFutureTimestamps enables clamping future timestamps to the current
+// time before validation, instead of rejecting those beyond the allowed skew
+func (h *StreamHandler) SetClampFutureTimestamps(clamp bool) {
+	h.clampFutureTimestamps = clamp
+}
+
+// SetSkipEmptyMessages controls whether entries whose message is empty or only
+// whitespace after transformation are counted as invalid and skipped
+func (h *StreamHandler) SetSkipEmptyMessages(skip bool) {
+	h.skipEmptyMessages = skip
+}
+
+// SetDecodeBase64 enables decoding lines and messages that are base64 of
+// printable UTF-8 text; anything else is left as-is
+func (h *StreamHandler) SetDecodeBase64(decode bool) {
+	h.decodeBase64 = decode
+}
+
+// SetDetectStackTraces enables setting metadata.is_stacktrace on entries whose
+// message looks like an exception or stack trace, so they can be prioritized
+// in search
+func (h *StreamHandler) SetDetectStackTraces(detect bool) {
+	h.detectStackTraces = detect
+}
+
 func (h *StreamHandler) HandleStream(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 	h.metrics.requestsTotal.Inc()
@@ -173,16 +402,100 @@ func (h *StreamHandler) HandleStream(w http.ResponseWriter, r *http.Request) {
 	// Ensure proper content type for JSON Lines
 	contentType := r.Header.Get("Content-Type")
 	if contentType != "application/x-ndjson" && contentType != "application/json" {
-		h.writeErrorResponse(w, http.StatusBadRequest, "Content-Type must be application/x-ndjson or application/json")
+		writeErrorResponse(w, http.StatusBadRequest, "Content-Type must be application/x-ndjson or application/json")
 		h.metrics.errorsTotal.Inc()
 		return
 	}
 
-	// Process the stream
-	processedCount, err := h.processStream(r)
+	// Reject payloads in a format version this ingestor does not understand
+	if err := h.checkSchemaVersion(r.Header.Get(SchemaVersionHeader)); err != nil {
+		h.logger.WithError(err).Warn("Rejecting stream with unsupported schema version")
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		h.metrics.errorsTotal.Inc()
+		return
+	}
+
+	if h.maxRequestSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.maxRequestSize)
+	}
+
+	// Reject bodies not signed with the shared secret
+	if err := h.verifySignature(r); err != nil {
+		if isRequestTooLarge(err) {
+			writeErrorResponse(w, http.StatusRequestEntityTooLarge, "Request body too large")
+			h.metrics.errorsTotal.Inc()
+			return
+		}
+		h.logger.WithError(err).WithField("remote_addr", r.RemoteAddr).Warn("Rejecting stream with invalid signature")
+		writeErrorResponse(w, http.StatusUnauthorized, err.Error())
+		h.metrics.errorsTotal.Inc()
+		return
+	}
+
+	// Ask clients to back off while storage is unreachable rather than queueing
+	// entries that cannot be written
+	if !h.StorageAvailable() {
+		writeUnavailableResponse(w, "Storage unavailable, retry later")
+		h.metrics.errorsTotal.Inc()
+		return
+	}
+
+	// Transparently decompress the body according to Content-Encoding
+	body, err := decodeBody(r)
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to decode request body")
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, errUnsupportedEncoding) {
+			statusCode = http.StatusUnsupportedMediaType
+		}
+		writeErrorResponse(w, statusCode, err.Error())
+		h.metrics.errorsTotal.Inc()
+		return
+	}
+	r.Body = body
+
+	// Process the stream within the caller's trace, if it sent one
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := tracing.Tracer().Start(ctx, "processStream", trace.WithSpanKind(trace.SpanKindServer))
+	processedCount, err := h.processStream(r.WithContext(ctx))
+	span.SetAttributes(attribute.Int("processed_count", processedCount))
+	tracing.End(span, err)
+	if errors.Is(err, ErrQueueFull) {
+		h.logger.WithField("processed_count", processedCount).Warn("Log queue full, asking client to retry")
+		w.Header().Set("Retry-After", strconv.Itoa(int(StorageRetryAfter.Seconds())))
+		writeBatchResponse(w, http.StatusTooManyRequests, models.BatchResponse{
+			Success:        false,
+			ProcessedCount: processedCount,
+			Errors:         []string{"Log queue full, retry later"},
+		})
+		return
+	}
+	if errors.Is(err, ErrDraining) {
+		writeUnavailableResponse(w, "Service is shutting down")
+		h.metrics.errorsTotal.Inc()
+		return
+	}
+	if errors.Is(err, ErrSchemaViolation) || errors.Is(err, ErrMissingMetadata) {
+		writeBatchResponse(w, http.StatusBadRequest, models.BatchResponse{
+			Success:        false,
+			ProcessedCount: processedCount,
+			Errors:         []string{err.Error()},
+		})
+		h.metrics.errorsTotal.Inc()
+		return
+	}
+	if isRequestTooLarge(err) {
+		writeBatchResponse(w, http.StatusRequestEntityTooLarge, models.BatchResponse{
+			Success:        false,
+			ProcessedCount: processedCount,
+			Errors:         []string{"Request body too large"},
+		})
+		h.metrics.errorsTotal.Inc()
+		return
+	}
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to process stream")
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Stream processing error")
+		writeErrorResponse(w, http.StatusInternalServerError, "Stream processing error")
 		h.metrics.errorsTotal.Inc()
 		return
 	}
@@ -190,15 +503,15 @@ func (h *StreamHandler) HandleStream(w http.ResponseWriter, r *http.Request) {
 	// Update metrics
 	h.metrics.requestDuration.Observe(time.Since(startTime).Seconds())
 
-	// Send success response
-	response := models.BatchResponse{
+	// Send success response; in async mode the entries are only queued so far
+	statusCode := http.StatusOK
+	if h.asyncStorage {
+		statusCode = http.StatusAccepted
+	}
+	writeBatchResponse(w, statusCode, models.BatchResponse{
 		Success:        true,
 		ProcessedCount: processedCount,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(response)
+	})
 
 	h.logger.WithFields(logrus.Fields{
 		"processed_count": processedCount,
@@ -207,10 +520,21 @@ func (h *StreamHandler) HandleStream(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *StreamHandler) processStream(r *http.Request) (int, error) {
+	maxLineSize := h.maxLineSize
+	if maxLineSize <= 0 {
+		maxLineSize = DefaultMaxLineSize
+	}
+
 	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 0, min(4096, maxLineSize)), maxLineSize)
+	scanner.Split(skipLongLines(maxLineSize, func() {
+		h.metrics.oversizedLines.Inc()
+		h.recordInvalidLine(InvalidReasonOversized, logrus.Fields{"max_line_size": maxLineSize}, nil)
+	}))
 	defer func() { _ = r.Body.Close() }()
 
 	totalProcessed := 0
+	spanContext := trace.SpanContextFromContext(r.Context())
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -220,6 +544,14 @@ func (h *StreamHandler) processStream(r *http.Request) (int, error) {
 			continue
 		}
 
+		var decoders []string
+		if h.decodeBase64 {
+			decoders = append(decoders, "base64")
+			if decoded, ok := models.DecodeBase64Text(line); ok {
+				line = decoded
+			}
+		}
+
 		// DEBUG: Log raw line from Fluent Bit
 		h.logger.WithField("raw_line", line).Debug("Received raw line from Fluent Bit")
 
@@ -227,15 +559,16 @@ func (h *StreamHandler) processStream(r *http.Request) (int, error) {
 		var logEntry *models.LogEntry
 		var directLogEntry models.LogEntry
 
+		decoders = append(decoders, "log_entry")
 		if err := json.Unmarshal([]byte(line), &directLogEntry); err == nil && directLogEntry.Message != "" {
 			// Successfully parsed as direct LogEntry format
 			logEntry = &directLogEntry
 		} else {
 			// Try to parse as Fluent Bit format
+			decoders = append(decoders, "fluent_bit")
 			var fluentBitEntry FluentBitLogEntry
 			if err := json.Unmarshal([]byte(line), &fluentBitEntry); err != nil {
-				h.logger.WithError(err).WithField("line", line).Warn("Failed to parse JSON line")
-				h.metrics.invalidLines.Inc()
+				h.recordInvalidLine(InvalidReasonNotJSON, logrus.Fields{"line": truncateLine(line), "decoders": decoders}, err)
 				continue
 			}
 
@@ -243,26 +576,32 @@ func (h *StreamHandler) processStream(r *http.Request) (int, error) {
 			logEntry = fluentBitEntry.transformToLogEntry()
 		}
 
+		if err := h.validateSchema(line); err != nil {
+			h.recordInvalidLine(InvalidReasonSchema, logrus.Fields{"line": truncateLine(line), "decoders": decoders}, err)
+			if h.strictSchema {
+				return totalProcessed, err
+			}
+			continue
+		}
+
 		// DEBUG: Log transformed entry structure
 		h.logger.WithField("transformed_entry", logEntry).Debug("Transformed log entry structure")
+		logEntry.SpanContext = spanContext
 
-		// Validate log entry
-		if err := logEntry.Validate(); err != nil {
-			h.logger.WithError(err).WithField("entry", logEntry).Warn("Invalid log entry")
-			h.metrics.invalidLines.Inc()
+		if err := h.Enqueue(logEntry); err != nil {
+			// Apply backpressure in async mode rather than dropping the rest of the stream
+			if h.asyncStorage && errors.Is(err, ErrQueueFull) {
+				return totalProcessed, err
+			}
+			if h.strictMetadata && errors.Is(err, ErrMissingMetadata) {
+				return totalProcessed, err
+			}
+			if errors.Is(err, ErrDraining) {
+				return totalProcessed, err
+			}
 			continue
 		}
-
-		// Publish to channel for async processing
-		select {
-		case h.logChannel <- logEntry:
-			h.metrics.linesProcessed.Inc()
-			totalProcessed++
-		default:
-			// Channel is full, log warning but don't block
-			h.logger.Warn("Log channel full, dropping log entry")
-			h.metrics.errorsTotal.Inc()
-		}
+		totalProcessed++
 	}
 
 	// Check for scanner errors
@@ -273,12 +612,214 @@ func (h *StreamHandler) processStream(r *http.Request) (int, error) {
 	return totalProcessed, nil
 }
 
-// StartWorker starts a worker goroutine that processes log entries from the channel
+// writeDeadLetter hands the entries of a failed batch to the dead-letter sink
+func (h *StreamHandler) writeDeadLetter(ctx context.Context, batch []*models.LogEntry, storeErr error) {
+	if h.deadLetter == nil {
+		return
+	}
+
+	failed := batch
+	var batchErr *storage.BatchError
+	if errors.As(storeErr, &batchErr) {
+		failed = batchErr.Failed
+	}
+
+	if err := h.deadLetter.Write(ctx, failed); err != nil {
+		h.logger.WithError(err).WithField("entries", len(failed)).Error("Failed to write entries to dead-letter sink")
+		return
+	}
+	h.logger.WithField("entries", len(failed)).Warn("Wrote failed entries to dead-letter sink")
+}
+
+// storeBatch writes batch to storage, marking storage unavailable and sending
+// failed entries to the dead-letter sink on error. It returns how many entries
+// could not be stored.
+func (h *StreamHandler) storeBatch(ctx context.Context, batch []*models.LogEntry) int {
+	h.metrics.batchesCreated.Inc()
+	h.metrics.batchDistinctSources.Observe(float64(distinctSources(batch)))
+	err := h.storage.StoreBatch(ctx, batch)
+	if err == nil {
+		h.unavailableUntil.Store(0)
+		return 0
+	}
+
+	h.logger.WithError(err).WithField("batch_size", len(batch)).Error("Failed to store batch")
+	h.metrics.errorsTotal.Inc()
+	if storage.IsUnavailable(err) {
+		h.unavailableUntil.Store(time.Now().Add(StorageRetryAfter).UnixNano())
+	}
+	h.writeDeadLetter(ctx, batch, err)
+
+	var batchErr *storage.BatchError
+	if errors.As(err, &batchErr) {
+		return len(batchErr.Failed)
+	}
+	return len(batch)
+}
+
+// distinctSources returns the number of different sources in batch
+func distinctSources(batch []*models.LogEntry) int {
+	sources := make(map[string]struct{}, len(batch))
+	for _, entry := range batch {
+		sources[entry.Source] = struct{}{}
+	}
+	return len(sources)
+}
+
+// Enqueue normalizes and validates entry and publishes it to the worker pool
+// without blocking. It is shared by every ingestion path.
+func (h *StreamHandler) Enqueue(entry *models.LogEntry) error {
+	if collectedAt, ok := entry.CollectedAt(); ok {
+		lag := time.Since(time.UnixMilli(collectedAt))
+		h.metrics.collectionLag.Observe(max(lag, 0).Seconds())
+	}
+	if h.decodeBase64 && entry.DecodeBase64Message() {
+		h.logger.WithField("source", entry.Source).Debug("Decoded base64 message")
+	}
+	if h.flattenMetadata {
+		entry.FlattenMetadata()
+	}
+	if len(h.componentFields) > 0 {
+		entry.ExtractComponent(h.componentFields)
+	}
+	if h.detectStackTraces {
+		entry.MarkStackTrace()
+	}
+
+	if h.clampFutureTimestamps && entry.ClampFutureTimestamp() {
+		h.logger.WithField("source", entry.Source).Debug("Clamped future timestamp to now")
+	}
+
+	if h.matchesDropPattern(entry) {
+		h.metrics.droppedByPattern.Inc()
+		return ErrDroppedByPattern
+	}
+
+	if h.skipEmptyMessages && strings.TrimSpace(entry.Message) == "" {
+		h.recordInvalidLine(InvalidReasonEmptyMessage, logrus.Fields{"source": entry.Source}, nil)
+		return ErrEmptyMessage
+	}
+
+	// Validate log entry
+	if err := entry.ValidateWithBounds(h.timestampBounds); err != nil {
+		h.recordInvalidLine(InvalidReasonValidation, logrus.Fields{"source": entry.Source, "message": truncateLine(entry.Message)}, err)
+		return err
+	}
+	if err := h.checkRequiredMetadata(entry); err != nil {
+		h.recordInvalidLine(InvalidReasonMetadata, logrus.Fields{"source": entry.Source, "message": truncateLine(entry.Message)}, err)
+		return err
+	}
+
+	// Publish to channel for async processing
+	h.drainMu.RLock()
+	defer h.drainMu.RUnlock()
+	if h.draining {
+		return ErrDraining
+	}
+	select {
+	case h.logChannel <- entry:
+		h.metrics.linesProcessed.Inc()
+		return nil
+	default:
+		// Channel is full, log warning but don't block
+		h.logger.Warn("Log channel full, dropping log entry")
+		h.metrics.errorsTotal.Inc()
+		return ErrQueueFull
+	}
+}
+
+// StorageAvailable reports whether new entries should be accepted, i.e. the
+// workers have not recently failed to reach storage
+func (h *StreamHandler) StorageAvailable() bool {
+	return time.Now().UnixNano() >= h.unavailableUntil.Load()
+}
+
+// skipLongLines returns a bufio.SplitFunc like bufio.ScanLines that discards
+// lines which do not fit in maxSize bytes instead of aborting the scan, calling
+// onSkip once for each discarded line.
+func skipLongLines(maxSize int, onSkip func()) bufio.SplitFunc {
+	discarding := false
+
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if discarding {
+			if i := bytes.IndexByte(data, '\n'); i >= 0 {
+				discarding = false
+				onSkip()
+				return i + 1, nil, nil
+			}
+			if atEOF {
+				discarding = false
+				onSkip()
+			}
+			return len(data), nil, nil
+		}
+
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		if advance == 0 && token == nil && err == nil && len(data) >= maxSize {
+			// The buffer is full without a newline; drop the rest of this line
+			discarding = true
+			return len(data), nil, nil
+		}
+		return advance, token, err
+	}
+}
+
+// Drain closes the log channel and waits for the workers to flush the entries
+// still queued. It must only be called once, and the workers' context must stay
+// live until it returns. Entries enqueued afterwards, e.g. by requests outliving
+// a timed-out server shutdown, are rejected with ErrDraining.
+func (h *StreamHandler) Drain(ctx context.Context) error {
+	h.drainMu.Lock()
+	h.logger.WithField("queued", len(h.logChannel)).Info("Draining log queue")
+	h.draining = true
+	close(h.logChannel)
+	h.drainMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timed out draining log queue: %w", ctx.Err())
+	}
+}
+
+// StartWorker starts a worker goroutine that processes log entries from the channel.
+// Entries are accumulated into batches that are flushed to storage when they reach
+// maxBatchSize or when batchTimeout has elapsed since the first entry was added.
 func (h *StreamHandler) StartWorker(ctx context.Context) {
+	h.workers.Add(1)
+	defer h.workers.Done()
+
 	// Update queue size metric periodically
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
+	batch := make([]*models.LogEntry, 0, h.maxBatchSize)
+
+	// flushTimer is only armed while a partial batch is pending
+	var flushTimer *time.Timer
+	var flushC <-chan time.Time
+
+	flush := func() {
+		if flushTimer != nil {
+			flushTimer.Stop()
+			flushTimer = nil
+			flushC = nil
+		}
+		if len(batch) == 0 {
+			return
+		}
+
+		h.storeBatch(ctx, batch)
+		batch = make([]*models.LogEntry, 0, h.maxBatchSize)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -287,19 +828,26 @@ func (h *StreamHandler) StartWorker(ctx context.Context) {
 
 		case logEntry, ok := <-h.logChannel:
 			if !ok {
-				// Channel closed, exit
+				// Channel closed, store what we have and exit
+				flush()
 				return
 			}
 
 			// Update queue size metric
 			h.metrics.queueSize.Set(float64(len(h.logChannel)))
 
-			// Store log entry directly
-			if err := h.storage.StoreLog(ctx, logEntry); err != nil {
-				h.logger.WithError(err).Error("Failed to store log")
-				h.metrics.errorsTotal.Inc()
+			batch = append(batch, logEntry)
+			if len(batch) >= h.maxBatchSize {
+				flush()
+			} else if flushTimer == nil {
+				flushTimer = time.NewTimer(h.batchTimeout)
+				flushC = flushTimer.C
 			}
 
+		case <-flushC:
+			// Batch timeout elapsed, flush the partial batch
+			flush()
+
 		case <-ticker.C:
 			// Periodic queue size update (in case queue is idle)
 			h.metrics.queueSize.Set(float64(len(h.logChannel)))
@@ -307,12 +855,74 @@ func (h *StreamHandler) StartWorker(ctx context.Context) {
 	}
 }
 
-func (h *StreamHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
-	response := models.BatchResponse{
+// errUnsupportedEncoding is returned by decodeBody for unknown Content-Encoding values
+var errUnsupportedEncoding = errors.New("unsupported Content-Encoding")
+
+// decodeBody wraps the request body in a decompressing reader matching its
+// Content-Encoding header. Supported encodings are gzip and zstd; an absent or
+// identity encoding returns the body unchanged.
+func decodeBody(r *http.Request) (io.ReadCloser, error) {
+	encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+
+	switch encoding {
+	case "", "identity":
+		return r.Body, nil
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		return &decodedBody{Reader: reader, closers: []io.Closer{reader, r.Body}}, nil
+	case "zstd":
+		decoder, err := zstd.NewReader(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd body: %w", err)
+		}
+		return &decodedBody{Reader: decoder, closers: []io.Closer{decoder.IOReadCloser(), r.Body}}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedEncoding, encoding)
+	}
+}
+
+// decodedBody reads from a decompressor and closes it along with the underlying body
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (d *decodedBody) Close() error {
+	var firstErr error
+	for _, c := range d.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// writeUnavailableResponse writes a 503 with a Retry-After hint so clients back off
+func writeUnavailableResponse(w http.ResponseWriter, message string) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(StorageRetryAfter.Seconds())))
+	writeErrorResponse(w, http.StatusServiceUnavailable, message)
+}
+
+// isRequestTooLarge reports whether err came from reading past the
+// MaxBytesReader limit on the request body
+func isRequestTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// writeErrorResponse writes a failed BatchResponse with the given status code
+func writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
+	writeBatchResponse(w, statusCode, models.BatchResponse{
 		Success: false,
 		Errors:  []string{message},
-	}
+	})
+}
 
+// writeBatchResponse writes response as JSON with the given status code
+func writeBatchResponse(w http.ResponseWriter, statusCode int, response models.BatchResponse) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	_ = json.NewEncoder(w).Encode(response)
@@ -10,6 +10,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/timberline/log-ingestor/internal/models"
 )
 
@@ -22,8 +23,10 @@ func TestStreamHandler_FluentBitRealData(t *testing.T) {
 	fluentBitData := `{"date":1758402234.132,"log":"2025-09-20T21:03:54.132201507Z stderr F time=\"2025-09-20T21:03:54Z\" level=warning msg=\"Invalid log entry\"","kubernetes":{"pod_name":"log-ingestor-68b874f5df-p448n","namespace_name":"timberline","pod_id":"4e1ed8d6-e55f-4e8c-8af9-a92c9bbb4006","labels":{"app":"log-ingestor","pod-template-hash":"68b874f5df"},"host":"timberline-test-worker","pod_ip":"10.244.1.13","container_name":"log-ingestor","docker_id":"9edc32d6f0098c36c371dc23c7e2cc9ff8994f9fbd89b6a9a883fa119cc1f20e","container_hash":"sha256:784156a830ef6d365fa46f9a025f8f7581713d57130623d6b6b21a94bac4a8de","container_image":"docker.io/timberline/log-ingestor:latest"},"source":"fluent-bit"}
 {"date":1758402235.456,"log":"2025-09-20T21:03:55.456789012Z stdout F {\"level\":\"info\",\"msg\":\"Processing request\",\"service\":\"log-ingestor\"}","kubernetes":{"pod_name":"log-ingestor-68b874f5df-p448n","namespace_name":"timberline","container_name":"log-ingestor"},"source":"fluent-bit"}`
 
-	// Mock storage expects two individual log calls with transformed entries
-	mockStorage.On("StoreLog", mock.Anything, mock.AnythingOfType("*models.LogEntry")).Return(nil).Times(2)
+	// Mock storage expects a single batch with both transformed entries
+	mockStorage.On("StoreBatch", mock.Anything, mock.MatchedBy(func(logs []*models.LogEntry) bool {
+		return len(logs) == 2
+	})).Return(nil).Once()
 
 	// Create request
 	req := httptest.NewRequest("POST", "/api/v1/logs/stream", bytes.NewBufferString(fluentBitData))
@@ -60,8 +63,10 @@ func TestStreamHandler_ExpectedFormat(t *testing.T) {
 	expectedData := `{"timestamp":1758402234132,"message":"2025-09-20T21:03:54.132201507Z stderr F time=\"2025-09-20T21:03:54Z\" level=warning msg=\"Invalid log entry\"","source":"fluent-bit","metadata":{"level":"warning","container_name":"log-ingestor","namespace":"timberline","pod_name":"log-ingestor-68b874f5df-p448n","labels":{"app":"log-ingestor"}}}
 {"timestamp":1758402235456,"message":"{\"level\":\"info\",\"msg\":\"Processing request\",\"service\":\"log-ingestor\"}","source":"fluent-bit","metadata":{"level":"info","container_name":"log-ingestor","namespace":"timberline","pod_name":"log-ingestor-68b874f5df-p448n"}}`
 
-	// Mock storage expects two individual log calls
-	mockStorage.On("StoreLog", mock.Anything, mock.AnythingOfType("*models.LogEntry")).Return(nil).Times(2)
+	// Mock storage expects a single batch with both entries
+	mockStorage.On("StoreBatch", mock.Anything, mock.MatchedBy(func(logs []*models.LogEntry) bool {
+		return len(logs) == 2
+	})).Return(nil).Once()
 
 	// Create request
 	req := httptest.NewRequest("POST", "/api/v1/logs/stream", bytes.NewBufferString(expectedData))
@@ -152,3 +157,46 @@ func TestFluentBitTransformation(t *testing.T) {
 	transformedNoSource := entryNoSource.transformToLogEntry()
 	assert.Equal(t, "unknown", transformedNoSource.Source) // Should default to "unknown"
 }
+
+func TestFluentBitLogEntry_PromotesContainerImage(t *testing.T) {
+	tests := []struct {
+		name          string
+		line          string
+		expectedImage interface{}
+		expectedHash  interface{}
+	}{
+		{
+			name:          "Kubernetes filter fields",
+			line:          `{"date":1758402234.132,"log":"started","kubernetes":{"pod_name":"api-7d9f","container_name":"api","container_hash":"sha256:784156a830ef","container_image":"docker.io/timberline/api:1.4.2"},"source":"fluent-bit"}`,
+			expectedImage: "docker.io/timberline/api:1.4.2",
+			expectedHash:  "sha256:784156a830ef",
+		},
+		{
+			name:          "Record-level fields",
+			line:          `{"date":1758402234.132,"log":"started","container_image":"docker.io/timberline/api:1.4.2","container_hash":"sha256:784156a830ef","source":"fluent-bit"}`,
+			expectedImage: "docker.io/timberline/api:1.4.2",
+			expectedHash:  "sha256:784156a830ef",
+		},
+		{
+			name:          "Kubernetes fields take precedence",
+			line:          `{"date":1758402234.132,"log":"started","kubernetes":{"container_image":"docker.io/timberline/api:1.4.2"},"container_image":"api:latest","source":"fluent-bit"}`,
+			expectedImage: "docker.io/timberline/api:1.4.2",
+		},
+		{
+			name: "Absent",
+			line: `{"date":1758402234.132,"log":"started","kubernetes":{"pod_name":"api-7d9f"},"source":"fluent-bit"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fb FluentBitLogEntry
+			require.NoError(t, json.Unmarshal([]byte(tt.line), &fb))
+
+			entry := fb.transformToLogEntry()
+
+			assert.Equal(t, tt.expectedImage, entry.Metadata[models.ContainerImageKey])
+			assert.Equal(t, tt.expectedHash, entry.Metadata[models.ContainerHashKey])
+		})
+	}
+}
@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -10,10 +14,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/timberline/log-ingestor/internal/models"
 	"github.com/timberline/log-ingestor/internal/storage"
 )
@@ -49,10 +57,32 @@ func newTestStreamHandler(storage storage.StorageInterface, maxBatchSize int) *S
 			Name: "log_ingestor_stream_invalid_lines_total",
 			Help: "Total number of invalid JSON lines",
 		}),
+		oversizedLines: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "log_ingestor_stream_oversized_lines_total",
+			Help: "Total number of lines skipped for exceeding the maximum line size",
+		}),
+		droppedByPattern: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "log_ingestor_stream_dropped_by_pattern_total",
+			Help: "Total number of entries dropped for matching INGEST_DROP_PATTERNS",
+		}),
+		invalidReasons: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "log_ingestor_invalid_lines",
+			Help: "Number of invalid lines by reason",
+		}, []string{"reason"}),
 		queueSize: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "log_ingestor_queue_size",
 			Help: "Current number of log entries in the processing queue",
 		}),
+		collectionLag: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "log_ingestor_collection_lag_seconds",
+			Help:    "Time from the collector reading a log to the ingestor receiving it",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300},
+		}),
+		batchDistinctSources: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "log_ingestor_batch_distinct_sources",
+			Help:    "Number of distinct sources in each stored batch",
+			Buckets: []float64{1, 2, 3, 5, 10, 20, 50, 100},
+		}),
 	}
 
 	// Register with custom registry
@@ -62,7 +92,12 @@ func newTestStreamHandler(storage storage.StorageInterface, maxBatchSize int) *S
 	registry.MustRegister(metrics.batchesCreated)
 	registry.MustRegister(metrics.errorsTotal)
 	registry.MustRegister(metrics.invalidLines)
+	registry.MustRegister(metrics.oversizedLines)
+	registry.MustRegister(metrics.droppedByPattern)
+	registry.MustRegister(metrics.invalidReasons)
 	registry.MustRegister(metrics.queueSize)
+	registry.MustRegister(metrics.collectionLag)
+	registry.MustRegister(metrics.batchDistinctSources)
 
 	// Create channel for log processing
 	logChannel := make(chan *models.LogEntry, 1000)
@@ -72,7 +107,11 @@ func newTestStreamHandler(storage storage.StorageInterface, maxBatchSize int) *S
 		logger:       logrus.New(),
 		metrics:      metrics,
 		maxBatchSize: maxBatchSize,
+		batchTimeout: 20 * time.Millisecond,
 		logChannel:   logChannel,
+		maxLineSize:  DefaultMaxLineSize,
+
+		skipEmptyMessages: true,
 	}
 
 	// Start worker goroutine for tests
@@ -92,6 +131,11 @@ func (m *MockStreamStorage) StoreLog(ctx context.Context, log *models.LogEntry)
 	return args.Error(0)
 }
 
+func (m *MockStreamStorage) StoreBatch(ctx context.Context, logs []*models.LogEntry) error {
+	args := m.Called(ctx, logs)
+	return args.Error(0)
+}
+
 func (m *MockStreamStorage) Connect(ctx context.Context) error {
 	args := m.Called(ctx)
 	return args.Error(0)
@@ -140,10 +184,10 @@ func TestStreamHandler_HandleStream_Success(t *testing.T) {
 
 	requestBody := strings.Join(jsonLines, "\n")
 
-	// Mock storage expects two individual log calls
-	mockStorage.On("StoreLog", mock.Anything, mock.MatchedBy(func(log *models.LogEntry) bool {
-		return log.Message == "Test log message 1" || log.Message == "Test log message 2"
-	})).Return(nil).Times(2)
+	// Mock storage expects both entries in a single batch
+	mockStorage.On("StoreBatch", mock.Anything, mock.MatchedBy(func(logs []*models.LogEntry) bool {
+		return len(logs) == 2 && logs[0].Message == "Test log message 1" && logs[1].Message == "Test log message 2"
+	})).Return(nil).Once()
 
 	// Create request
 	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
@@ -200,10 +244,10 @@ func TestStreamHandler_HandleStream_InvalidJSON(t *testing.T) {
 invalid json line
 {"timestamp": %d, "message": "another valid", "source": "test"}`, now, now+1000)
 
-	// Mock storage expects two individual log calls with only valid entries
-	mockStorage.On("StoreLog", mock.Anything, mock.MatchedBy(func(log *models.LogEntry) bool {
-		return log.Message == "valid" || log.Message == "another valid"
-	})).Return(nil).Times(2)
+	// Mock storage expects a single batch with only valid entries
+	mockStorage.On("StoreBatch", mock.Anything, mock.MatchedBy(func(logs []*models.LogEntry) bool {
+		return len(logs) == 2 && logs[0].Message == "valid" && logs[1].Message == "another valid"
+	})).Return(nil).Once()
 
 	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
 	req.Header.Set("Content-Type", "application/x-ndjson")
@@ -226,6 +270,574 @@ invalid json line
 	mockStorage.AssertExpectations(t)
 }
 
+func TestStreamHandler_HandleStream_CompressedBody(t *testing.T) {
+	compressors := map[string]func(t *testing.T, data []byte) []byte{
+		"gzip": func(t *testing.T, data []byte) []byte {
+			var buf bytes.Buffer
+			gw := gzip.NewWriter(&buf)
+			_, err := gw.Write(data)
+			require.NoError(t, err)
+			require.NoError(t, gw.Close())
+			return buf.Bytes()
+		},
+		"zstd": func(t *testing.T, data []byte) []byte {
+			encoder, err := zstd.NewWriter(nil)
+			require.NoError(t, err)
+			defer func() { _ = encoder.Close() }()
+			return encoder.EncodeAll(data, nil)
+		},
+	}
+
+	for encoding, compress := range compressors {
+		t.Run(encoding, func(t *testing.T) {
+			mockStorage := new(MockStreamStorage)
+			handler := newTestStreamHandler(mockStorage, 100)
+
+			now := time.Now().UnixMilli()
+			requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "first", "source": "test"}
+{"timestamp": %d, "message": "second", "source": "test"}`, now, now+1)
+
+			mockStorage.On("StoreBatch", mock.Anything, mock.MatchedBy(func(logs []*models.LogEntry) bool {
+				return len(logs) == 2 && logs[0].Message == "first" && logs[1].Message == "second"
+			})).Return(nil).Once()
+
+			req := httptest.NewRequest("POST", "/api/v1/logs/stream", bytes.NewReader(compress(t, []byte(requestBody))))
+			req.Header.Set("Content-Type", "application/x-ndjson")
+			req.Header.Set("Content-Encoding", encoding)
+
+			rr := httptest.NewRecorder()
+			handler.HandleStream(rr, req)
+
+			// Wait for worker to process entries
+			time.Sleep(100 * time.Millisecond)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response models.BatchResponse
+			err := json.Unmarshal(rr.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			assert.Equal(t, 2, response.ProcessedCount)
+
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStreamHandler_HandleStream_UnsupportedEncoding(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("Content-Encoding", "br")
+
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+	mockStorage.AssertNotCalled(t, "StoreBatch")
+}
+
+func TestStreamHandler_HandleStream_InvalidGzipBody(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader("not gzip"))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("Content-Encoding", "gzip")
+
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockStorage.AssertNotCalled(t, "StoreBatch")
+}
+
+func TestStreamHandler_HandleStream_TimestampBounds(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+
+	backfill := time.Now().AddDate(-12, 0, 0).UnixMilli()
+	line := fmt.Sprintf(`{"timestamp": %d, "message": "archived", "source": "test"}`, backfill)
+
+	// Rejected with the default 10 year bound
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(line))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	var response models.BatchResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, 0, response.ProcessedCount)
+
+	// Accepted once the bound is widened
+	handler.SetTimestampBounds(models.TimestampBounds{MaxAge: 20 * 365 * 24 * time.Hour})
+	mockStorage.On("StoreBatch", mock.Anything, mock.MatchedBy(func(logs []*models.LogEntry) bool {
+		return len(logs) == 1 && logs[0].Message == "archived"
+	})).Return(nil).Once()
+
+	req = httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(line))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rr = httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	// Wait for worker to process entries
+	time.Sleep(100 * time.Millisecond)
+
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.ProcessedCount)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestStreamHandler_HandleStream_ClampFutureTimestamps(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+	handler.SetClampFutureTimestamps(true)
+
+	// Well past the default one hour skew, so it would otherwise be rejected
+	future := time.Now().Add(48 * time.Hour).UnixMilli()
+	line := fmt.Sprintf(`{"timestamp": %d, "message": "skewed", "source": "test", "metadata": {"level": "INFO"}}`, future)
+
+	mockStorage.On("StoreBatch", mock.Anything, mock.MatchedBy(func(logs []*models.LogEntry) bool {
+		return len(logs) == 1 &&
+			logs[0].Timestamp <= time.Now().UnixMilli() &&
+			logs[0].Metadata["_clamped"] == true &&
+			logs[0].Metadata["level"] == "INFO"
+	})).Return(nil).Once()
+
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(line))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	// Wait for worker to process entries
+	time.Sleep(100 * time.Millisecond)
+
+	var response models.BatchResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.ProcessedCount)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestStreamHandler_HandleStream_AsyncStorageAccepted(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+	handler.SetAsyncStorage(true)
+
+	mockStorage.On("StoreBatch", mock.Anything, mock.Anything).Return(nil).Once()
+
+	line := fmt.Sprintf(`{"timestamp": %d, "message": "queued", "source": "test"}`, time.Now().UnixMilli())
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(line))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	// Wait for worker to process entries
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	var response models.BatchResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.True(t, response.Success)
+	assert.Equal(t, 1, response.ProcessedCount)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestStreamHandler_HandleStream_AsyncStorageQueueFull(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	// No worker is started, so the single queue slot is never freed
+	handler := NewStreamHandler(mockStorage, 100, time.Second, make(chan *models.LogEntry, 1))
+	handler.SetAsyncStorage(true)
+
+	now := time.Now().UnixMilli()
+	requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "first", "source": "test"}
+{"timestamp": %d, "message": "second", "source": "test"}
+{"timestamp": %d, "message": "third", "source": "test"}`, now, now, now)
+
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "5", rr.Header().Get("Retry-After"))
+
+	var response models.BatchResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.False(t, response.Success)
+	assert.Equal(t, 1, response.ProcessedCount)
+	assert.Len(t, handler.logChannel, 1)
+}
+
+func TestStreamHandler_HandleStream_QueueFullDropsWithoutAsyncStorage(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	handler := NewStreamHandler(mockStorage, 100, time.Second, make(chan *models.LogEntry, 1))
+
+	now := time.Now().UnixMilli()
+	requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "first", "source": "test"}
+{"timestamp": %d, "message": "second", "source": "test"}`, now, now)
+
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response models.BatchResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.ProcessedCount)
+}
+
+func TestStreamHandler_Drain_FlushesQueuedEntries(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	// A long batch timeout means only draining flushes the partial batch
+	handler := NewStreamHandler(mockStorage, 100, time.Hour, make(chan *models.LogEntry, 10))
+
+	mockStorage.On("StoreBatch", mock.Anything, mock.MatchedBy(func(logs []*models.LogEntry) bool {
+		return len(logs) == 2
+	})).Return(nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go handler.StartWorker(ctx)
+
+	now := time.Now().UnixMilli()
+	require.NoError(t, handler.Enqueue(&models.LogEntry{Timestamp: now, Message: "first"}))
+	require.NoError(t, handler.Enqueue(&models.LogEntry{Timestamp: now, Message: "second"}))
+
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer drainCancel()
+	require.Eventually(t, func() bool { return len(handler.logChannel) == 0 }, time.Second, 10*time.Millisecond)
+	require.NoError(t, handler.Drain(drainCtx))
+
+	mockStorage.AssertExpectations(t)
+}
+
+func TestStreamHandler_Drain_TimesOut(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	handler := NewStreamHandler(mockStorage, 100, time.Hour, make(chan *models.LogEntry, 10))
+
+	// Storage blocks until the test ends, so the worker cannot finish draining
+	release := make(chan struct{})
+	defer close(release)
+	mockStorage.On("StoreBatch", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		<-release
+	}).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go handler.StartWorker(ctx)
+
+	require.NoError(t, handler.Enqueue(&models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "stuck"}))
+	require.Eventually(t, func() bool { return len(handler.logChannel) == 0 }, time.Second, 10*time.Millisecond)
+
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer drainCancel()
+	err := handler.Drain(drainCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestStreamHandler_HandleStream_SkipsOversizedLine(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+	handler.SetMaxLineSize(64 * 1024)
+
+	now := time.Now().UnixMilli()
+	requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "before", "source": "test"}
+{"timestamp": %d, "message": "%s", "source": "test"}
+{"timestamp": %d, "message": "after", "source": "test"}`, now, now, strings.Repeat("x", 70*1024), now)
+
+	mockStorage.On("StoreBatch", mock.Anything, mock.MatchedBy(func(logs []*models.LogEntry) bool {
+		return len(logs) == 2 && logs[0].Message == "before" && logs[1].Message == "after"
+	})).Return(nil).Once()
+
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	// Wait for worker to process entries
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response models.BatchResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, response.ProcessedCount)
+	assert.Equal(t, float64(1), testutil.ToFloat64(handler.metrics.oversizedLines))
+	assert.Equal(t, float64(1), testutil.ToFloat64(handler.metrics.invalidLines))
+
+	mockStorage.AssertExpectations(t)
+}
+
+func TestStreamHandler_HandleStream_RequestTooLarge(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+	handler.SetMaxRequestSize(1024)
+
+	var lines []string
+	for i := 0; i < 50; i++ {
+		lines = append(lines, fmt.Sprintf(`{"timestamp": %d, "message": "line %d", "source": "test"}`, time.Now().UnixMilli(), i))
+	}
+	mockStorage.On("StoreBatch", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(strings.Join(lines, "\n")))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	assert.Equal(t, float64(1), testutil.ToFloat64(handler.metrics.errorsTotal))
+}
+
+func TestStreamHandler_HandleStream_SkipEmptyMessages(t *testing.T) {
+	now := time.Now().UnixMilli()
+	requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "kept", "source": "test"}
+{"timestamp": %d, "message": "   ", "source": "test"}
+{"date": %d.0, "log": "", "source": "fluent-bit"}
+{"date": %d.0, "log": "\t\n", "source": "fluent-bit"}`, now, now, now/1000, now/1000)
+
+	tests := []struct {
+		name              string
+		skip              bool
+		expectedProcessed int
+		expectedInvalid   float64
+	}{
+		// The empty Fluent Bit message fails validation either way
+		{"Enabled", true, 1, 3},
+		{"Disabled", false, 3, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(MockStreamStorage)
+			handler := newTestStreamHandler(mockStorage, 100)
+			handler.SetSkipEmptyMessages(tt.skip)
+
+			mockStorage.On("StoreBatch", mock.Anything, mock.MatchedBy(func(logs []*models.LogEntry) bool {
+				return len(logs) == tt.expectedProcessed && logs[0].Message == "kept"
+			})).Return(nil).Once()
+
+			req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
+			req.Header.Set("Content-Type", "application/x-ndjson")
+
+			rr := httptest.NewRecorder()
+			handler.HandleStream(rr, req)
+
+			// Wait for worker to process entries
+			time.Sleep(100 * time.Millisecond)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response models.BatchResponse
+			err := json.Unmarshal(rr.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedProcessed, response.ProcessedCount)
+			assert.Equal(t, tt.expectedInvalid, testutil.ToFloat64(handler.metrics.invalidLines))
+
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStreamHandler_HandleStream_DecodeBase64(t *testing.T) {
+	now := time.Now().UnixMilli()
+	encodedLine := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf(`{"timestamp": %d, "message": "encoded line", "source": "test"}`, now)))
+	requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "%s", "source": "test"}
+{"date": %d.0, "log": "%s", "source": "fluent-bit"}
+{"timestamp": %d, "message": "plain text", "source": "test"}
+%s`, now, base64.StdEncoding.EncodeToString([]byte("encoded message")),
+		now/1000, base64.StdEncoding.EncodeToString([]byte("encoded log field")),
+		now, encodedLine)
+
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+	handler.SetDecodeBase64(true)
+
+	var messages []string
+	mockStorage.On("StoreBatch", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		for _, log := range args.Get(1).([]*models.LogEntry) {
+			messages = append(messages, log.Message)
+		}
+	}).Once()
+
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	// Wait for worker to process entries
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []string{"encoded message", "encoded log field", "plain text", "encoded line"}, messages)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestStreamHandler_HandleStream_CollectionLag(t *testing.T) {
+	now := time.Now().UnixMilli()
+	requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "stamped", "source": "test", "metadata": {"_collected_at": %d}}
+{"timestamp": %d, "message": "garbage stamp", "source": "test", "metadata": {"_collected_at": "soon"}}
+{"timestamp": %d, "message": "unstamped", "source": "test"}`, now, now-2000, now, now)
+
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+	mockStorage.On("StoreBatch", mock.Anything, mock.Anything).Return(nil).Once()
+
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	// Wait for worker to process entries
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Only the valid stamp is observed, with a lag of about two seconds
+	var metric dto.Metric
+	require.NoError(t, handler.metrics.collectionLag.Write(&metric))
+	assert.Equal(t, uint64(1), metric.GetHistogram().GetSampleCount())
+	assert.InDelta(t, 2.0, metric.GetHistogram().GetSampleSum(), 1.0)
+}
+
+func TestStreamHandler_HandleStream_BatchDistinctSources(t *testing.T) {
+	now := time.Now().UnixMilli()
+	requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "a", "source": "nginx"}
+{"timestamp": %d, "message": "b", "source": "app"}
+{"timestamp": %d, "message": "c", "source": "nginx"}
+{"timestamp": %d, "message": "d", "source": "db"}`, now, now, now, now)
+
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+	mockStorage.On("StoreBatch", mock.Anything, mock.Anything).Return(nil).Once()
+
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	// Wait for worker to process entries
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockStorage.AssertExpectations(t)
+
+	// One batch with three distinct sources
+	var metric dto.Metric
+	require.NoError(t, handler.metrics.batchDistinctSources.Write(&metric))
+	assert.Equal(t, uint64(1), metric.GetHistogram().GetSampleCount())
+	assert.Equal(t, 3.0, metric.GetHistogram().GetSampleSum())
+}
+
+func TestStreamHandler_HandleStream_AcceptsLongLineWithinDefault(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+
+	longMessage := strings.Repeat("y", 100*1024)
+	requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "%s", "source": "test"}`, time.Now().UnixMilli(), longMessage)
+
+	mockStorage.On("StoreBatch", mock.Anything, mock.MatchedBy(func(logs []*models.LogEntry) bool {
+		return len(logs) == 1 && logs[0].Message == longMessage
+	})).Return(nil).Once()
+
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	// Wait for worker to process entries
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, float64(0), testutil.ToFloat64(handler.metrics.oversizedLines))
+	mockStorage.AssertExpectations(t)
+}
+
+func TestSkipLongLines_TrailingOversizedLine(t *testing.T) {
+	skipped := 0
+	scanner := bufio.NewScanner(strings.NewReader("ok\n" + strings.Repeat("z", 100)))
+	scanner.Buffer(make([]byte, 0, 16), 16)
+	scanner.Split(skipLongLines(16, func() { skipped++ }))
+
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	assert.NoError(t, scanner.Err())
+	assert.Equal(t, []string{"ok"}, lines)
+	assert.Equal(t, 1, skipped)
+}
+
+func TestStreamHandler_HandleStream_FlattenMetadata(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+	handler.SetFlattenMetadata(true)
+
+	requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "nested", "source": "test", "metadata": {"level": "INFO", "kubernetes": {"namespace_name": "prod", "labels": {"app": "api"}}}}`, time.Now().UnixMilli())
+
+	mockStorage.On("StoreBatch", mock.Anything, mock.MatchedBy(func(logs []*models.LogEntry) bool {
+		if len(logs) != 1 {
+			return false
+		}
+		metadata := logs[0].Metadata
+		return len(metadata) == 3 &&
+			metadata["level"] == "INFO" &&
+			metadata["kubernetes.namespace_name"] == "prod" &&
+			metadata["kubernetes.labels.app"] == "api"
+	})).Return(nil).Once()
+
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	// Wait for worker to process entries
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestStreamHandler_HandleStream_DetectStackTraces(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+	handler.SetDetectStackTraces(true)
+
+	now := time.Now().UnixMilli()
+	requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "java.io.IOException: disk full\n\tat com.example.Writer.flush(Writer.java:88)", "source": "test"}
+{"timestamp": %d, "message": "request completed in 12ms", "source": "test"}`, now, now)
+
+	var marked []interface{}
+	mockStorage.On("StoreBatch", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		for _, log := range args.Get(1).([]*models.LogEntry) {
+			marked = append(marked, log.Metadata[models.StackTraceKey])
+		}
+	}).Once()
+
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	// Wait for worker to process entries
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []interface{}{true, nil}, marked)
+	mockStorage.AssertExpectations(t)
+}
+
 func TestStreamHandler_HandleStream_EmptyStream(t *testing.T) {
 	mockStorage := new(MockStreamStorage)
 	handler := newTestStreamHandler(mockStorage, 100)
@@ -267,8 +879,13 @@ func TestStreamHandler_HandleStream_BatchSizeLimiting(t *testing.T) {
 
 	requestBody := strings.Join(jsonLines, "\n")
 
-	// Expect 5 individual log calls (all entries processed individually)
-	mockStorage.On("StoreLog", mock.Anything, mock.AnythingOfType("*models.LogEntry")).Return(nil).Times(5)
+	// Expect two full batches and one partial batch flushed by the batch timeout
+	mockStorage.On("StoreBatch", mock.Anything, mock.MatchedBy(func(logs []*models.LogEntry) bool {
+		return len(logs) == 2
+	})).Return(nil).Times(2)
+	mockStorage.On("StoreBatch", mock.Anything, mock.MatchedBy(func(logs []*models.LogEntry) bool {
+		return len(logs) == 1
+	})).Return(nil).Once()
 
 	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
 	req.Header.Set("Content-Type", "application/x-ndjson")
@@ -303,7 +920,7 @@ func TestStreamHandler_HandleStream_StorageError(t *testing.T) {
 	line, _ := json.Marshal(entry)
 
 	// Mock storage returns error
-	mockStorage.On("StoreLog", mock.Anything, mock.Anything).Return(assert.AnError)
+	mockStorage.On("StoreBatch", mock.Anything, mock.Anything).Return(assert.AnError)
 
 	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(string(line)))
 	req.Header.Set("Content-Type", "application/x-ndjson")
@@ -326,3 +943,191 @@ func TestStreamHandler_HandleStream_StorageError(t *testing.T) {
 
 	mockStorage.AssertExpectations(t)
 }
+
+func TestStreamHandler_HandleStream_StorageUnavailable(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+
+	line := fmt.Sprintf(`{"timestamp": %d, "message": "first", "source": "test"}`, time.Now().UnixMilli())
+
+	// The worker fails to reach storage for the first batch
+	mockStorage.On("StoreBatch", mock.Anything, mock.Anything).Return(fmt.Errorf("failed to store 1 of 1 logs: %w", storage.ErrNotConnected)).Once()
+
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(line))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Wait for worker to process entries
+	time.Sleep(100 * time.Millisecond)
+
+	// Subsequent streams are rejected with a back-off hint
+	req = httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(line))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rr = httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "5", rr.Header().Get("Retry-After"))
+
+	var response models.BatchResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.False(t, response.Success)
+
+	// Once the back-off window has passed, streams are accepted again
+	handler.unavailableUntil.Store(time.Now().Add(-time.Second).UnixNano())
+	mockStorage.On("StoreBatch", mock.Anything, mock.Anything).Return(nil).Once()
+
+	req = httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(line))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rr = httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Zero(t, handler.unavailableUntil.Load())
+	mockStorage.AssertExpectations(t)
+}
+
+// MockDeadLetterSink is a mock implementation of dlq.Sink
+type MockDeadLetterSink struct {
+	mock.Mock
+}
+
+func (m *MockDeadLetterSink) Write(ctx context.Context, entries []*models.LogEntry) error {
+	args := m.Called(ctx, entries)
+	return args.Error(0)
+}
+
+func TestStreamHandler_StartWorker_WritesFailedEntriesToDeadLetter(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	deadLetter := new(MockDeadLetterSink)
+	handler := newTestStreamHandler(mockStorage, 100)
+	handler.SetDeadLetterSink(deadLetter)
+
+	now := time.Now().UnixMilli()
+	requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "stored", "source": "test"}
+{"timestamp": %d, "message": "lost", "source": "test"}`, now, now)
+
+	// Storage reports only the second entry as failed
+	failed := []*models.LogEntry{{Timestamp: now, Message: "lost", Source: "test"}}
+	mockStorage.On("StoreBatch", mock.Anything, mock.Anything).
+		Return(&storage.BatchError{Failed: failed, Total: 2, Err: assert.AnError}).Once()
+	deadLetter.On("Write", mock.Anything, failed).Return(nil).Once()
+
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	// Wait for worker to process entries
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockStorage.AssertExpectations(t)
+	deadLetter.AssertExpectations(t)
+}
+
+func TestStreamHandler_StartWorker_DeadLetterDisabled(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+
+	mockStorage.On("StoreBatch", mock.Anything, mock.Anything).Return(assert.AnError).Once()
+
+	line := fmt.Sprintf(`{"timestamp": %d, "message": "lost", "source": "test"}`, time.Now().UnixMilli())
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(line))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	// Wait for worker to process entries
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Nil(t, handler.deadLetter)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestStreamHandler_StartWorker_FlushesPartialBatchAfterTimeout(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+
+	stored := make(chan []*models.LogEntry, 1)
+	mockStorage.On("StoreBatch", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			stored <- args.Get(1).([]*models.LogEntry)
+		}).Return(nil).Once()
+
+	now := time.Now().UnixMilli()
+	handler.logChannel <- &models.LogEntry{Timestamp: now, Message: "first", Source: "test"}
+	handler.logChannel <- &models.LogEntry{Timestamp: now, Message: "second", Source: "test"}
+
+	// Nothing is stored before the batch timeout elapses
+	select {
+	case <-stored:
+		t.Fatal("Expected partial batch to wait for the batch timeout")
+	case <-time.After(5 * time.Millisecond):
+	}
+
+	select {
+	case logs := <-stored:
+		assert.Len(t, logs, 2)
+		assert.Equal(t, "first", logs[0].Message)
+		assert.Equal(t, "second", logs[1].Message)
+	case <-time.After(time.Second):
+		t.Fatal("Expected partial batch to be flushed after the batch timeout")
+	}
+
+	mockStorage.AssertExpectations(t)
+}
+
+func TestStreamHandler_StartWorker_FlushesOnChannelClose(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+	handler.batchTimeout = time.Hour // Only the channel close should trigger the flush
+
+	done := make(chan struct{})
+	mockStorage.On("StoreBatch", mock.Anything, mock.MatchedBy(func(logs []*models.LogEntry) bool {
+		return len(logs) == 1
+	})).Run(func(args mock.Arguments) {
+		close(done)
+	}).Return(nil).Once()
+
+	handler.logChannel <- &models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "last", Source: "test"}
+	close(handler.logChannel)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected pending batch to be flushed when the channel closes")
+	}
+
+	mockStorage.AssertExpectations(t)
+}
+
+func TestStreamHandler_Enqueue_AfterDrain(t *testing.T) {
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), time.Second)
+	defer drainCancel()
+	require.NoError(t, handler.Drain(drainCtx))
+
+	// A request still running after the server shutdown timed out must not panic
+	err := handler.Enqueue(&models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "late", Source: "test"})
+	assert.ErrorIs(t, err, ErrDraining)
+
+	requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "late", "source": "test"}`, time.Now().UnixMilli())
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+	mockStorage.AssertNotCalled(t, "StoreBatch", mock.Anything, mock.Anything)
+}
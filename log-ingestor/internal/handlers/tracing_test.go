@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// useSpanRecorder installs a global tracer provider recording spans in memory
+// and W3C trace context propagation for the duration of the test
+func useSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+	return recorder
+}
+
+func TestStreamHandler_HandleStream_Tracing(t *testing.T) {
+	recorder := useSpanRecorder(t)
+
+	mockStorage := new(MockStreamStorage)
+	handler := newTestStreamHandler(mockStorage, 100)
+
+	var stored []*models.LogEntry
+	mockStorage.On("StoreBatch", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		stored = args.Get(1).([]*models.LogEntry)
+	}).Once()
+
+	requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "traced", "source": "test"}`, time.Now().UnixMilli())
+	req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	rr := httptest.NewRecorder()
+	handler.HandleStream(rr, req)
+
+	// Wait for worker to process entries
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// The stream span continues the caller's trace
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "processStream", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+
+	// Queued entries carry the stream span so storing them joins the trace
+	require.Len(t, stored, 1)
+	assert.Equal(t, span.SpanContext().SpanID(), stored[0].SpanContext.SpanID())
+	mockStorage.AssertExpectations(t)
+}
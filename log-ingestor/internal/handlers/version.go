@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// SchemaVersionHeader carries the payload format version a client speaks
+	SchemaVersionHeader = "X-Timberline-Schema"
+
+	// SchemaMajorVersion is the payload format major version this ingestor
+	// understands; requests declaring another major version are rejected
+	SchemaMajorVersion = 1
+	// SchemaMinorVersion is the newest minor revision this ingestor knows
+	SchemaMinorVersion = 0
+
+	// maxSkewedVersions bounds the minor versions remembered as already
+	// logged, since clients choose the header value
+	maxSkewedVersions = 32
+)
+
+// checkSchemaVersion validates the schema version declared in the request
+// header. Requests without the header are accepted as the current version.
+// Versions with a different minor revision are accepted but logged once per
+// minor version so collector and ingestor skew is visible.
+func (h *StreamHandler) checkSchemaVersion(header string) error {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return nil
+	}
+
+	major, minor, err := parseSchemaVersion(header)
+	if err != nil {
+		return err
+	}
+	if major != SchemaMajorVersion {
+		return fmt.Errorf("unsupported %s %s: this ingestor supports v%d", SchemaVersionHeader, header, SchemaMajorVersion)
+	}
+
+	if minor != SchemaMinorVersion && h.firstSkew(minor) {
+		h.logger.WithFields(logrus.Fields{
+			"client_version":   fmt.Sprintf("v%d.%d", major, minor),
+			"ingestor_version": fmt.Sprintf("v%d.%d", SchemaMajorVersion, SchemaMinorVersion),
+		}).Warn("Client payload schema version differs from ingestor")
+	}
+	return nil
+}
+
+// firstSkew records minor as logged and reports whether it was new. Once
+// maxSkewedVersions are remembered further versions are no longer logged.
+func (h *StreamHandler) firstSkew(minor int) bool {
+	h.skewedMu.Lock()
+	defer h.skewedMu.Unlock()
+
+	if _, seen := h.skewedVersions[minor]; seen || len(h.skewedVersions) >= maxSkewedVersions {
+		return false
+	}
+	if h.skewedVersions == nil {
+		h.skewedVersions = make(map[int]struct{})
+	}
+	h.skewedVersions[minor] = struct{}{}
+	return true
+}
+
+// parseSchemaVersion parses versions of the form "v1" or "v1.2"
+func parseSchemaVersion(version string) (int, int, error) {
+	digits, ok := strings.CutPrefix(strings.ToLower(version), "v")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid %s %q: expected a version like v1", SchemaVersionHeader, version)
+	}
+
+	majorText, minorText, hasMinor := strings.Cut(digits, ".")
+	major, err := strconv.Atoi(majorText)
+	if err != nil || major < 0 {
+		return 0, 0, fmt.Errorf("invalid %s %q: expected a version like v1", SchemaVersionHeader, version)
+	}
+	minor := 0
+	if hasMinor {
+		if minor, err = strconv.Atoi(minorText); err != nil || minor < 0 {
+			return 0, 0, fmt.Errorf("invalid %s %q: expected a version like v1", SchemaVersionHeader, version)
+		}
+	}
+	return major, minor, nil
+}
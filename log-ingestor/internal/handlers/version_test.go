@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+func TestStreamHandler_HandleStream_SchemaVersion(t *testing.T) {
+	tests := []struct {
+		name           string
+		version        string
+		expectedStatus int
+		expectedSkew   bool
+	}{
+		{"No header", "", http.StatusOK, false},
+		{"Matching version", "v1", http.StatusOK, false},
+		{"Newer minor version", "v1.3", http.StatusOK, true},
+		{"Unknown major version", "v2", http.StatusBadRequest, false},
+		{"Malformed version", "latest", http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(MockStreamStorage)
+			handler := newTestStreamHandler(mockStorage, 100)
+			logger, hook := logtest.NewNullLogger()
+			handler.logger = logger
+			if tt.expectedStatus == http.StatusOK {
+				mockStorage.On("StoreBatch", mock.Anything, mock.Anything).Return(nil).Once()
+			}
+
+			requestBody := fmt.Sprintf(`{"timestamp": %d, "message": "versioned", "source": "test"}`, time.Now().UnixMilli())
+			newRequest := func() *http.Request {
+				req := httptest.NewRequest("POST", "/api/v1/logs/stream", strings.NewReader(requestBody))
+				req.Header.Set("Content-Type", "application/x-ndjson")
+				if tt.version != "" {
+					req.Header.Set(SchemaVersionHeader, tt.version)
+				}
+				return req
+			}
+
+			rr := httptest.NewRecorder()
+			handler.HandleStream(rr, newRequest())
+
+			// Wait for worker to process entries
+			time.Sleep(100 * time.Millisecond)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus != http.StatusOK {
+				var response models.BatchResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Contains(t, response.Errors[0], SchemaVersionHeader)
+				mockStorage.AssertNotCalled(t, "StoreBatch", mock.Anything, mock.Anything)
+				return
+			}
+			mockStorage.AssertExpectations(t)
+
+			skewWarnings := func() int {
+				count := 0
+				for _, entry := range hook.AllEntries() {
+					if entry.Message == "Client payload schema version differs from ingestor" {
+						count++
+					}
+				}
+				return count
+			}
+			if !tt.expectedSkew {
+				assert.Zero(t, skewWarnings())
+				return
+			}
+			assert.Equal(t, 1, skewWarnings())
+
+			// Skew is logged once per version, not per request
+			mockStorage.On("StoreBatch", mock.Anything, mock.Anything).Return(nil).Once()
+			handler.HandleStream(httptest.NewRecorder(), newRequest())
+			time.Sleep(100 * time.Millisecond)
+			assert.Equal(t, 1, skewWarnings())
+		})
+	}
+}
+
+func TestStreamHandler_CheckSchemaVersion_BoundedSkewLog(t *testing.T) {
+	handler := newTestStreamHandler(new(MockStreamStorage), 100)
+	logger, hook := logtest.NewNullLogger()
+	handler.logger = logger
+
+	// Spellings of the same version are logged once
+	for _, version := range []string{"v1.01", "v1.001", "V1.1"} {
+		require.NoError(t, handler.checkSchemaVersion(version))
+	}
+	assert.Len(t, hook.AllEntries(), 1)
+
+	// Arbitrarily many distinct versions do not grow the set without bound
+	for minor := 2; minor < 10*maxSkewedVersions; minor++ {
+		require.NoError(t, handler.checkSchemaVersion(fmt.Sprintf("v1.%d", minor)))
+	}
+	assert.Len(t, handler.skewedVersions, maxSkewedVersions)
+	assert.Len(t, hook.AllEntries(), maxSkewedVersions)
+}
@@ -0,0 +1,217 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: ingestor.proto
+
+package ingestorpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// LogEntry mirrors the JSON log entry accepted by /api/v1/logs/stream.
+type LogEntry struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Unix timestamp in milliseconds
+	Timestamp     int64            `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Message       string           `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Source        string           `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
+	Metadata      *structpb.Struct `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogEntry) Reset() {
+	*x = LogEntry{}
+	mi := &file_ingestor_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogEntry) ProtoMessage() {}
+
+func (x *LogEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_ingestor_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogEntry.ProtoReflect.Descriptor instead.
+func (*LogEntry) Descriptor() ([]byte, []int) {
+	return file_ingestor_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LogEntry) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *LogEntry) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *LogEntry) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *LogEntry) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type StreamLogsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Number of entries queued for storage
+	ProcessedCount int64 `protobuf:"varint,1,opt,name=processed_count,json=processedCount,proto3" json:"processed_count,omitempty"`
+	// Number of entries rejected as invalid or dropped because the queue was full
+	RejectedCount int64 `protobuf:"varint,2,opt,name=rejected_count,json=rejectedCount,proto3" json:"rejected_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamLogsResponse) Reset() {
+	*x = StreamLogsResponse{}
+	mi := &file_ingestor_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamLogsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamLogsResponse) ProtoMessage() {}
+
+func (x *StreamLogsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ingestor_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamLogsResponse.ProtoReflect.Descriptor instead.
+func (*StreamLogsResponse) Descriptor() ([]byte, []int) {
+	return file_ingestor_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StreamLogsResponse) GetProcessedCount() int64 {
+	if x != nil {
+		return x.ProcessedCount
+	}
+	return 0
+}
+
+func (x *StreamLogsResponse) GetRejectedCount() int64 {
+	if x != nil {
+		return x.RejectedCount
+	}
+	return 0
+}
+
+var File_ingestor_proto protoreflect.FileDescriptor
+
+const file_ingestor_proto_rawDesc = "" +
+	"\n" +
+	"\x0eingestor.proto\x12\x16timberline.ingestor.v1\x1a\x1cgoogle/protobuf/struct.proto\"\x8f\x01\n" +
+	"\bLogEntry\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x16\n" +
+	"\x06source\x18\x03 \x01(\tR\x06source\x123\n" +
+	"\bmetadata\x18\x04 \x01(\v2\x17.google.protobuf.StructR\bmetadata\"d\n" +
+	"\x12StreamLogsResponse\x12'\n" +
+	"\x0fprocessed_count\x18\x01 \x01(\x03R\x0eprocessedCount\x12%\n" +
+	"\x0erejected_count\x18\x02 \x01(\x03R\rrejectedCount2k\n" +
+	"\vLogIngestor\x12\\\n" +
+	"\n" +
+	"StreamLogs\x12 .timberline.ingestor.v1.LogEntry\x1a*.timberline.ingestor.v1.StreamLogsResponse(\x01B8Z6github.com/timberline/log-ingestor/internal/ingestorpbb\x06proto3"
+
+var (
+	file_ingestor_proto_rawDescOnce sync.Once
+	file_ingestor_proto_rawDescData []byte
+)
+
+func file_ingestor_proto_rawDescGZIP() []byte {
+	file_ingestor_proto_rawDescOnce.Do(func() {
+		file_ingestor_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ingestor_proto_rawDesc), len(file_ingestor_proto_rawDesc)))
+	})
+	return file_ingestor_proto_rawDescData
+}
+
+var file_ingestor_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_ingestor_proto_goTypes = []any{
+	(*LogEntry)(nil),           // 0: timberline.ingestor.v1.LogEntry
+	(*StreamLogsResponse)(nil), // 1: timberline.ingestor.v1.StreamLogsResponse
+	(*structpb.Struct)(nil),    // 2: google.protobuf.Struct
+}
+var file_ingestor_proto_depIdxs = []int32{
+	2, // 0: timberline.ingestor.v1.LogEntry.metadata:type_name -> google.protobuf.Struct
+	0, // 1: timberline.ingestor.v1.LogIngestor.StreamLogs:input_type -> timberline.ingestor.v1.LogEntry
+	1, // 2: timberline.ingestor.v1.LogIngestor.StreamLogs:output_type -> timberline.ingestor.v1.StreamLogsResponse
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_ingestor_proto_init() }
+func file_ingestor_proto_init() {
+	if File_ingestor_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ingestor_proto_rawDesc), len(file_ingestor_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ingestor_proto_goTypes,
+		DependencyIndexes: file_ingestor_proto_depIdxs,
+		MessageInfos:      file_ingestor_proto_msgTypes,
+	}.Build()
+	File_ingestor_proto = out.File
+	file_ingestor_proto_goTypes = nil
+	file_ingestor_proto_depIdxs = nil
+}
@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"runtime"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterBuildInfo registers a log_ingestor_build_info gauge with value 1,
+// labeled with the service version and the Go version it was built with, so
+// fleet versions can be tracked in Prometheus. Duplicate registration errors
+// are ignored.
+func RegisterBuildInfo(registerer prometheus.Registerer, version string) {
+	buildInfo := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "log_ingestor_build_info",
+		Help: "Build information about the running log ingestor, always 1",
+	}, []string{"version", "go_version"})
+	buildInfo.WithLabelValues(version, runtime.Version()).Set(1)
+
+	_ = registerer.Register(buildInfo)
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRegisterBuildInfo(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	RegisterBuildInfo(registry, "1.2.3")
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	for _, family := range families {
+		if family.GetName() != "log_ingestor_build_info" {
+			continue
+		}
+		if len(family.GetMetric()) != 1 {
+			t.Fatalf("Expected 1 build info series, got %d", len(family.GetMetric()))
+		}
+		metric := family.GetMetric()[0]
+
+		labels := make(map[string]string)
+		for _, label := range metric.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		if labels["version"] != "1.2.3" {
+			t.Errorf("Expected version label 1.2.3, got %q", labels["version"])
+		}
+		if labels["go_version"] != runtime.Version() {
+			t.Errorf("Expected go_version label %s, got %q", runtime.Version(), labels["go_version"])
+		}
+		if metric.GetGauge().GetValue() != 1 {
+			t.Errorf("Expected build info value 1, got %v", metric.GetGauge().GetValue())
+		}
+		return
+	}
+	t.Fatal("Expected log_ingestor_build_info to be registered")
+}
+
+func TestRegisterBuildInfo_DuplicateRegistration(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	RegisterBuildInfo(registry, "1.2.3")
+	RegisterBuildInfo(registry, "1.2.3") // must not panic
+}
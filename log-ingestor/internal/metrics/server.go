@@ -2,6 +2,8 @@ package metrics
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"net/http"
 	"strconv"
 	"time"
@@ -10,9 +12,17 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// Default timeouts applied to the metrics HTTP server
+	DefaultReadTimeout  = 5 * time.Second
+	DefaultWriteTimeout = 10 * time.Second
+	DefaultIdleTimeout  = 15 * time.Second
+)
+
 type Server struct {
-	server *http.Server
-	logger *logrus.Logger
+	server   *http.Server
+	listener net.Listener
+	logger   *logrus.Logger
 }
 
 func NewServer(port int, logger *logrus.Logger) *Server {
@@ -22,9 +32,9 @@ func NewServer(port int, logger *logrus.Logger) *Server {
 	server := &http.Server{
 		Addr:         ":" + strconv.Itoa(port),
 		Handler:      mux,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  15 * time.Second,
+		ReadTimeout:  DefaultReadTimeout,
+		WriteTimeout: DefaultWriteTimeout,
+		IdleTimeout:  DefaultIdleTimeout,
 	}
 
 	return &Server{
@@ -33,10 +43,42 @@ func NewServer(port int, logger *logrus.Logger) *Server {
 	}
 }
 
+// SetTimeouts overrides the HTTP server timeouts, e.g. for scrapers on slow
+// networks. Zero values keep the current setting. Must be called before Start.
+func (s *Server) SetTimeouts(read, write, idle time.Duration) {
+	if read > 0 {
+		s.server.ReadTimeout = read
+	}
+	if write > 0 {
+		s.server.WriteTimeout = write
+	}
+	if idle > 0 {
+		s.server.IdleTimeout = idle
+	}
+}
+
+// Listen binds the metrics port without serving, so bind failures such as a
+// port already in use are returned to the caller instead of surfacing later
+// in the serving goroutine. Start calls it when it has not been called yet.
+func (s *Server) Listen() error {
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to bind metrics server to %s: %w", s.server.Addr, err)
+	}
+	s.listener = listener
+	return nil
+}
+
 func (s *Server) Start() error {
-	s.logger.WithField("address", s.server.Addr).Info("Starting metrics server")
+	if s.listener == nil {
+		if err := s.Listen(); err != nil {
+			return err
+		}
+	}
+
+	s.logger.WithField("address", s.listener.Addr().String()).Info("Starting metrics server")
 
-	if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	if err := s.server.Serve(s.listener); err != nil && err != http.ErrServerClosed {
 		return err
 	}
 
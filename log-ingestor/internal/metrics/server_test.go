@@ -2,8 +2,11 @@ package metrics
 
 import (
 	"context"
+	"errors"
+	"net"
 	"net/http"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 
@@ -107,6 +110,59 @@ func TestServer_Start_InvalidPort(t *testing.T) {
 	}
 }
 
+func TestServer_Listen_PortInUse(t *testing.T) {
+	occupied, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Failed to occupy a port: %v", err)
+	}
+	defer func() { _ = occupied.Close() }()
+
+	server := NewServer(occupied.Addr().(*net.TCPAddr).Port, logrus.New())
+
+	err = server.Listen()
+	if err == nil {
+		t.Fatal("Expected bind error for port in use, got nil")
+	}
+	if !errors.Is(err, syscall.EADDRINUSE) {
+		t.Errorf("Expected EADDRINUSE, got %v", err)
+	}
+
+	// Start surfaces the same error instead of only logging it
+	if err := server.Start(); err == nil {
+		t.Error("Expected Start to return the bind error, got nil")
+	}
+}
+
+func TestServer_Listen_ThenStart(t *testing.T) {
+	server := NewServer(0, logrus.New())
+	if err := server.Listen(); err != nil {
+		t.Fatalf("Expected no error binding a free port, got %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Start()
+	}()
+
+	resp, err := http.Get("http://" + server.listener.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatalf("Expected metrics endpoint to be served, got %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := server.Stop(ctx); err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Expected Start to return nil after shutdown, got %v", err)
+	}
+}
+
 func TestServer_Stop(t *testing.T) {
 	server := NewServer(0, logrus.New())
 
@@ -176,6 +232,39 @@ func TestServer_Configuration(t *testing.T) {
 	}
 }
 
+func TestServer_SetTimeouts(t *testing.T) {
+	tests := []struct {
+		name          string
+		read          time.Duration
+		write         time.Duration
+		idle          time.Duration
+		expectedRead  time.Duration
+		expectedWrite time.Duration
+		expectedIdle  time.Duration
+	}{
+		{"All set", 30 * time.Second, time.Minute, 2 * time.Minute, 30 * time.Second, time.Minute, 2 * time.Minute},
+		{"Zero keeps defaults", 0, 0, 0, 5 * time.Second, 10 * time.Second, 15 * time.Second},
+		{"Partial", 0, 45 * time.Second, 0, 5 * time.Second, 45 * time.Second, 15 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer(9090, logrus.New())
+			server.SetTimeouts(tt.read, tt.write, tt.idle)
+
+			if server.server.ReadTimeout != tt.expectedRead {
+				t.Errorf("Expected ReadTimeout %v, got %v", tt.expectedRead, server.server.ReadTimeout)
+			}
+			if server.server.WriteTimeout != tt.expectedWrite {
+				t.Errorf("Expected WriteTimeout %v, got %v", tt.expectedWrite, server.server.WriteTimeout)
+			}
+			if server.server.IdleTimeout != tt.expectedIdle {
+				t.Errorf("Expected IdleTimeout %v, got %v", tt.expectedIdle, server.server.IdleTimeout)
+			}
+		})
+	}
+}
+
 func TestServer_HandlerSetup(t *testing.T) {
 	server := NewServer(9090, logrus.New())
 
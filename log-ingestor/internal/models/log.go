@@ -1,11 +1,52 @@
 package models
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
+const (
+	// DefaultMaxFutureSkew is how far in the future a timestamp may be by default
+	DefaultMaxFutureSkew = time.Hour
+	// DefaultMaxAge is how old a timestamp may be by default
+	DefaultMaxAge = 10 * 365 * 24 * time.Hour
+
+	// ComponentKey is the metadata key holding the emitting logger or component
+	ComponentKey = "component"
+	// EnvKey is the metadata key holding the environment name attached by the collector
+	EnvKey = "env"
+	// ContainerImageKey and ContainerHashKey are the metadata keys holding the
+	// image and image digest of the container that emitted the log
+	ContainerImageKey = "container_image"
+	ContainerHashKey  = "container_hash"
+	// CollectedAtKey is the metadata key holding when the collector read the
+	// log, in Unix milliseconds
+	CollectedAtKey = "_collected_at"
+	// SimilarCountKey is the metadata key holding how many similar logs were
+	// already stored when the log was stored as an additional example
+	SimilarCountKey = "_similar_count"
+	// StackTraceKey is the metadata key marking messages that look like an
+	// exception or stack trace
+	StackTraceKey = "is_stacktrace"
+)
+
+// TimestampBounds limits how far log timestamps may deviate from the current time.
+// Zero fields fall back to the defaults.
+type TimestampBounds struct {
+	MaxFutureSkew time.Duration
+	MaxAge        time.Duration
+}
+
 // LogEntry represents a generic log entry with minimal required fields
 // and flexible metadata for different log sources
 type LogEntry struct {
@@ -14,6 +55,23 @@ type LogEntry struct {
 	Source         string                 `json:"source,omitempty"`   // Optional source identifier (service, application, etc.)
 	Metadata       map[string]interface{} `json:"metadata,omitempty"` // Generic metadata for additional context
 	DuplicateCount int64                  `json:"duplicate_count"`    // Number of duplicate occurrences of this log
+
+	// IdempotencyKey optionally identifies an entry across client resends
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+
+	// SpanContext identifies the span of the request that received the entry,
+	// so spans for storing it join the request's trace
+	SpanContext trace.SpanContext `json:"-"`
+}
+
+// StoredLog is a log entry as persisted in storage, including its storage ID
+type StoredLog struct {
+	ID             int64                  `json:"id"`
+	Timestamp      int64                  `json:"timestamp"`
+	Message        string                 `json:"message"`
+	Source         string                 `json:"source"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	DuplicateCount int64                  `json:"duplicate_count"`
 }
 
 type LogBatch struct {
@@ -26,6 +84,46 @@ type BatchResponse struct {
 	Errors         []string `json:"errors,omitempty"`
 }
 
+type LogsResponse struct {
+	Logs  []*StoredLog `json:"logs"`
+	Count int          `json:"count"`
+}
+
+// SearchRequest is a similarity search over stored logs. Limit and Ef are
+// optional; Ef overrides the configured HNSW search ef for this request.
+// Filters restricts matches by source or promoted metadata fields, and by
+// timestamp with "after" and "before" in Unix milliseconds.
+type SearchRequest struct {
+	Query   string                 `json:"query"`
+	Limit   int                    `json:"limit,omitempty"`
+	Ef      int                    `json:"ef,omitempty"`
+	Filters map[string]interface{} `json:"filters,omitempty"`
+}
+
+// SearchMatch is a stored log returned by a similarity search with its score
+type SearchMatch struct {
+	StoredLog
+	Score float32 `json:"score"`
+}
+
+type SearchResponse struct {
+	Matches []*SearchMatch `json:"matches"`
+	Count   int            `json:"count"`
+}
+
+type AdminResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// FlushResponse reports the entries written by a forced queue flush
+type FlushResponse struct {
+	Success      bool `json:"success"`
+	FlushedCount int  `json:"flushed_count"`
+	FailedCount  int  `json:"failed_count"`
+	BatchCount   int  `json:"batch_count"`
+}
+
 type HealthResponse struct {
 	Status    string        `json:"status"`
 	Timestamp time.Time     `json:"timestamp"`
@@ -40,7 +138,13 @@ type HealthCheck struct {
 	Message string `json:"message,omitempty"`
 }
 
+// Validate checks required fields and that the timestamp is within the default bounds
 func (l *LogEntry) Validate() error {
+	return l.ValidateWithBounds(TimestampBounds{})
+}
+
+// ValidateWithBounds checks required fields and that the timestamp is within the given bounds
+func (l *LogEntry) ValidateWithBounds(bounds TimestampBounds) error {
 	if l.Timestamp == 0 {
 		return errors.New("timestamp is required")
 	}
@@ -48,21 +152,70 @@ func (l *LogEntry) Validate() error {
 		return errors.New("message is required")
 	}
 
-	// Validate timestamp is reasonable (not in the future by more than 1 hour, not older than 10 years)
+	maxFutureSkew := bounds.MaxFutureSkew
+	if maxFutureSkew <= 0 {
+		maxFutureSkew = DefaultMaxFutureSkew
+	}
+	maxAge := bounds.MaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultMaxAge
+	}
+
+	// Validate timestamp is reasonable (not too far in the future, not too old)
 	now := time.Now().UnixMilli()
-	oneHourFromNow := now + (60 * 60 * 1000)              // 1 hour in milliseconds
-	tenYearsAgo := now - (10 * 365 * 24 * 60 * 60 * 1000) // 10 years in milliseconds
+	latest := now + maxFutureSkew.Milliseconds()
+	earliest := now - maxAge.Milliseconds()
 
-	if l.Timestamp > oneHourFromNow {
-		return errors.New("timestamp cannot be more than 1 hour in the future")
+	if l.Timestamp > latest {
+		return fmt.Errorf("timestamp cannot be more than %s in the future", humanDuration(maxFutureSkew))
 	}
-	if l.Timestamp < tenYearsAgo {
-		return errors.New("timestamp cannot be older than 10 years")
+	if l.Timestamp < earliest {
+		return fmt.Errorf("timestamp cannot be older than %s", humanDuration(maxAge))
 	}
 
 	return nil
 }
 
+// ClampFutureTimestamp moves a timestamp that is ahead of now back to now and
+// records metadata._clamped=true, so entries from nodes with skewed clocks are
+// kept even beyond the allowed future skew. It reports whether the timestamp
+// was changed.
+func (l *LogEntry) ClampFutureTimestamp() bool {
+	now := time.Now().UnixMilli()
+	if l.Timestamp <= now {
+		return false
+	}
+
+	l.Timestamp = now
+	if l.Metadata == nil {
+		l.Metadata = make(map[string]interface{})
+	}
+	l.Metadata["_clamped"] = true
+	return true
+}
+
+// humanDuration formats whole years, days or hours in words, e.g. "10 years"
+func humanDuration(d time.Duration) string {
+	units := []struct {
+		size time.Duration
+		name string
+	}{
+		{365 * 24 * time.Hour, "year"},
+		{24 * time.Hour, "day"},
+		{time.Hour, "hour"},
+	}
+	for _, unit := range units {
+		if d%unit.size == 0 {
+			n := int64(d / unit.size)
+			if n == 1 {
+				return "1 " + unit.name
+			}
+			return fmt.Sprintf("%d %ss", n, unit.name)
+		}
+	}
+	return d.String()
+}
+
 // GetLevel returns the log level from metadata, with a default fallback
 func (l *LogEntry) GetLevel() string {
 	if l.Metadata == nil {
@@ -108,6 +261,116 @@ func (l *LogEntry) GetStringFromMetadata(key, fallback string) string {
 	return fallback
 }
 
+// FlattenMetadata collapses nested metadata objects into dot-notation keys,
+// e.g. {"kubernetes": {"namespace_name": "prod"}} becomes
+// {"kubernetes.namespace_name": "prod"}. Arrays and empty objects are kept as values.
+func (l *LogEntry) FlattenMetadata() {
+	if len(l.Metadata) == 0 {
+		return
+	}
+
+	flat := make(map[string]interface{}, len(l.Metadata))
+	flattenInto(flat, "", l.Metadata)
+	l.Metadata = flat
+}
+
+func flattenInto(dst map[string]interface{}, prefix string, src map[string]interface{}) {
+	for key, value := range src {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenInto(dst, key, nested)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// ExtractComponent copies the first non-empty string found under one of keys
+// (e.g. "logger", "component", "caller") into metadata.component. An existing
+// component value is left untouched. It reports whether a value was set.
+func (l *LogEntry) ExtractComponent(keys []string) bool {
+	if l.Metadata == nil {
+		return false
+	}
+	if existing, ok := l.Metadata[ComponentKey].(string); ok && existing != "" {
+		return false
+	}
+
+	for _, key := range keys {
+		if value, ok := l.Metadata[key].(string); ok && value != "" {
+			l.Metadata[ComponentKey] = value
+			return true
+		}
+	}
+	return false
+}
+
+// DecodeBase64Message replaces the message with its base64-decoded form when
+// it is valid base64 of printable UTF-8 text. It reports whether it decoded.
+func (l *LogEntry) DecodeBase64Message() bool {
+	decoded, ok := DecodeBase64Text(l.Message)
+	if ok {
+		l.Message = decoded
+	}
+	return ok
+}
+
+// DecodeBase64Text decodes s when it is standard base64 whose content is valid
+// UTF-8 without control characters other than whitespace, so plain text that
+// happens to be valid base64 is usually left alone
+func DecodeBase64Text(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", false
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(decoded) == 0 || !utf8.Valid(decoded) {
+		return "", false
+	}
+	text := string(decoded)
+	for _, r := range text {
+		if unicode.IsControl(r) && !unicode.IsSpace(r) {
+			return "", false
+		}
+	}
+	return text, true
+}
+
+// CollectedAt returns the collection time stamped by the collector in Unix
+// milliseconds. Missing, non-numeric or non-positive values report false.
+func (l *LogEntry) CollectedAt() (int64, bool) {
+	var millis float64
+	switch value := l.Metadata[CollectedAtKey].(type) {
+	case float64:
+		millis = value
+	case int64:
+		millis = float64(value)
+	case int:
+		millis = float64(value)
+	case json.Number:
+		parsed, err := value.Float64()
+		if err != nil {
+			return 0, false
+		}
+		millis = parsed
+	case string:
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, false
+		}
+		millis = parsed
+	default:
+		return 0, false
+	}
+	if millis <= 0 || math.IsNaN(millis) || math.IsInf(millis, 0) {
+		return 0, false
+	}
+	return int64(millis), true
+}
+
 // MetadataAsJSON returns the metadata as JSON bytes for storage
 func (l *LogEntry) MetadataAsJSON() ([]byte, error) {
 	if l.Metadata == nil {
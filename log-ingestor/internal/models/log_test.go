@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -104,6 +105,317 @@ func TestLogEntryValidate(t *testing.T) {
 	}
 }
 
+func TestLogEntryValidateWithBounds(t *testing.T) {
+	now := time.Now().UnixMilli()
+	backfill := LogEntry{
+		Timestamp: now - (12 * 365 * 24 * 60 * 60 * 1000), // 12 years ago
+		Message:   "Archived message",
+	}
+	skewed := LogEntry{
+		Timestamp: now + (3 * 60 * 60 * 1000), // 3 hours in future
+		Message:   "Skewed message",
+	}
+
+	tests := []struct {
+		name     string
+		logEntry LogEntry
+		bounds   TimestampBounds
+		errorMsg string
+	}{
+		{
+			name:     "Backfill rejected by default",
+			logEntry: backfill,
+			bounds:   TimestampBounds{},
+			errorMsg: "timestamp cannot be older than 10 years",
+		},
+		{
+			name:     "Backfill accepted with widened max age",
+			logEntry: backfill,
+			bounds:   TimestampBounds{MaxAge: 20 * 365 * 24 * time.Hour},
+		},
+		{
+			name:     "Backfill rejected with narrowed max age",
+			logEntry: LogEntry{Timestamp: now - (3 * 24 * 60 * 60 * 1000), Message: "Old"},
+			bounds:   TimestampBounds{MaxAge: 48 * time.Hour},
+			errorMsg: "timestamp cannot be older than 2 days",
+		},
+		{
+			name:     "Clock skew accepted with widened future skew",
+			logEntry: skewed,
+			bounds:   TimestampBounds{MaxFutureSkew: 4 * time.Hour},
+		},
+		{
+			name:     "Clock skew rejected with default future skew",
+			logEntry: skewed,
+			bounds:   TimestampBounds{MaxAge: 20 * 365 * 24 * time.Hour},
+			errorMsg: "timestamp cannot be more than 1 hour in the future",
+		},
+		{
+			name:     "Odd duration formatted verbatim",
+			logEntry: skewed,
+			bounds:   TimestampBounds{MaxFutureSkew: 90 * time.Minute},
+			errorMsg: "timestamp cannot be more than 1h30m0s in the future",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.logEntry.ValidateWithBounds(tt.bounds)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Expected no error for %s, got %v", tt.name, err)
+				}
+			} else if err == nil {
+				t.Errorf("Expected error for %s, got nil", tt.name)
+			} else if err.Error() != tt.errorMsg {
+				t.Errorf("Expected error message '%s', got '%s'", tt.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestLogEntryClampFutureTimestamp(t *testing.T) {
+	now := time.Now().UnixMilli()
+
+	tests := []struct {
+		name        string
+		timestamp   int64
+		wantClamped bool
+	}{
+		{
+			name:        "Near-future timestamp clamped",
+			timestamp:   now + (30 * 1000), // 30 seconds in future
+			wantClamped: true,
+		},
+		{
+			name:        "Past timestamp untouched",
+			timestamp:   now - (60 * 1000),
+			wantClamped: false,
+		},
+		{
+			name:        "Timestamp beyond default skew clamped",
+			timestamp:   now + (3 * 60 * 60 * 1000), // 3 hours in future
+			wantClamped: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := LogEntry{Timestamp: tt.timestamp, Message: "Test"}
+
+			clamped := entry.ClampFutureTimestamp()
+
+			if clamped != tt.wantClamped {
+				t.Fatalf("Expected clamped=%v, got %v", tt.wantClamped, clamped)
+			}
+			if !clamped {
+				if entry.Timestamp != tt.timestamp {
+					t.Errorf("Expected timestamp to stay %d, got %d", tt.timestamp, entry.Timestamp)
+				}
+				if entry.Metadata != nil {
+					t.Errorf("Expected no metadata, got %v", entry.Metadata)
+				}
+				return
+			}
+			if entry.Timestamp > time.Now().UnixMilli() {
+				t.Errorf("Expected timestamp to be clamped to now, got %d", entry.Timestamp)
+			}
+			if entry.Metadata["_clamped"] != true {
+				t.Errorf("Expected metadata._clamped to be true, got %v", entry.Metadata["_clamped"])
+			}
+			if err := entry.Validate(); err != nil {
+				t.Errorf("Expected clamped entry to pass validation, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLogEntryExtractComponent(t *testing.T) {
+	keys := []string{"logger", "component", "caller"}
+
+	tests := []struct {
+		name      string
+		metadata  map[string]interface{}
+		wantSet   bool
+		component interface{}
+	}{
+		{
+			name:      "From logger",
+			metadata:  map[string]interface{}{"logger": "com.example.OrderService"},
+			wantSet:   true,
+			component: "com.example.OrderService",
+		},
+		{
+			name:      "Logger takes priority over caller",
+			metadata:  map[string]interface{}{"caller": "main.go:42", "logger": "http"},
+			wantSet:   true,
+			component: "http",
+		},
+		{
+			name:      "Existing component kept",
+			metadata:  map[string]interface{}{"component": "scheduler", "logger": "http"},
+			wantSet:   false,
+			component: "scheduler",
+		},
+		{
+			name:      "Non-string values ignored",
+			metadata:  map[string]interface{}{"logger": 42, "caller": "main.go:42"},
+			wantSet:   true,
+			component: "main.go:42",
+		},
+		{
+			name:      "No matching keys",
+			metadata:  map[string]interface{}{"level": "INFO"},
+			wantSet:   false,
+			component: nil,
+		},
+		{
+			name:      "Nil metadata",
+			metadata:  nil,
+			wantSet:   false,
+			component: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := LogEntry{Timestamp: time.Now().UnixMilli(), Message: "Test", Metadata: tt.metadata}
+
+			if set := entry.ExtractComponent(keys); set != tt.wantSet {
+				t.Errorf("Expected set=%v, got %v", tt.wantSet, set)
+			}
+			if got := entry.Metadata[ComponentKey]; got != tt.component {
+				t.Errorf("Expected component %v, got %v", tt.component, got)
+			}
+		})
+	}
+}
+
+func TestDecodeBase64Text(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		decoded bool
+	}{
+		{"Base64 text", "Y29ubmVjdGlvbiByZWZ1c2VkIHRvIGRiOjU0MzI=", "connection refused to db:5432", true},
+		{"Surrounding whitespace", " ZXJyb3I6IHRpbWVvdXQ=\n", "error: timeout", true},
+		{"Multi-line text", "bGluZSAxCmxpbmUgMg==", "line 1\nline 2", true},
+		{"Plain text", "connection refused", "", false},
+		{"Valid base64 of binary data", "AAECAw==", "", false},
+		{"Valid base64 of invalid UTF-8", "test", "", false},
+		{"Empty", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, decoded := DecodeBase64Text(tt.input)
+			if decoded != tt.decoded {
+				t.Errorf("Expected decoded=%v, got %v", tt.decoded, decoded)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLogEntryDecodeBase64Message(t *testing.T) {
+	entry := LogEntry{Message: "ZGlzayBmdWxs"}
+	if !entry.DecodeBase64Message() || entry.Message != "disk full" {
+		t.Errorf("Expected message to be decoded, got %q", entry.Message)
+	}
+
+	entry = LogEntry{Message: "disk full"}
+	if entry.DecodeBase64Message() || entry.Message != "disk full" {
+		t.Errorf("Expected message to be unchanged, got %q", entry.Message)
+	}
+}
+
+func TestLogEntryCollectedAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected int64
+		ok       bool
+	}{
+		{"JSON number", float64(1700000000123), 1700000000123, true},
+		{"Integer", int64(1700000000123), 1700000000123, true},
+		{"Numeric string", "1700000000123", 1700000000123, true},
+		{"Missing", nil, 0, false},
+		{"Garbage string", "yesterday", 0, false},
+		{"Zero", float64(0), 0, false},
+		{"Negative", float64(-5), 0, false},
+		{"Wrong type", true, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := LogEntry{Metadata: map[string]interface{}{}}
+			if tt.value != nil {
+				entry.Metadata[CollectedAtKey] = tt.value
+			}
+
+			got, ok := entry.CollectedAt()
+			if ok != tt.ok || got != tt.expected {
+				t.Errorf("Expected (%d, %v), got (%d, %v)", tt.expected, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestLogEntryFlattenMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name:     "Nil metadata",
+			metadata: nil,
+			expected: nil,
+		},
+		{
+			name:     "Already flat",
+			metadata: map[string]interface{}{"level": "INFO"},
+			expected: map[string]interface{}{"level": "INFO"},
+		},
+		{
+			name: "Nested kubernetes object",
+			metadata: map[string]interface{}{
+				"level": "ERROR",
+				"kubernetes": map[string]interface{}{
+					"namespace_name": "prod",
+					"pod_name":       "api-7d9f",
+					"labels": map[string]interface{}{
+						"app": "api",
+					},
+					"annotations": map[string]interface{}{},
+					"ports":       []interface{}{80, 443},
+				},
+			},
+			expected: map[string]interface{}{
+				"level":                     "ERROR",
+				"kubernetes.namespace_name": "prod",
+				"kubernetes.pod_name":       "api-7d9f",
+				"kubernetes.labels.app":     "api",
+				"kubernetes.annotations":    map[string]interface{}{},
+				"kubernetes.ports":          []interface{}{80, 443},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := LogEntry{Metadata: tt.metadata}
+			entry.FlattenMetadata()
+			if !reflect.DeepEqual(entry.Metadata, tt.expected) {
+				t.Errorf("Expected metadata %v, got %v", tt.expected, entry.Metadata)
+			}
+		})
+	}
+}
+
 func TestLogEntryGetLevel(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+// stackTracePatterns match common exception and stack trace signatures:
+// Java/JavaScript frames, Java exception headers, Go panics and goroutine
+// dumps. Python tracebacks are matched by their fixed header.
+var stackTracePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?m)^(?:\t| {2,})at \S`),
+	regexp.MustCompile(`(?m)\b[\w$.]*Exception(?::\s|:$|$)`),
+	regexp.MustCompile(`Exception in thread "`),
+	regexp.MustCompile(`(?m)^goroutine \d+ \[`),
+	regexp.MustCompile(`(?m)^panic: `),
+}
+
+// pythonTracebackHeader starts every Python traceback
+const pythonTracebackHeader = "Traceback (most recent call last)"
+
+// LooksLikeStackTrace reports whether message contains a common exception or
+// stack trace signature
+func LooksLikeStackTrace(message string) bool {
+	if strings.Contains(message, pythonTracebackHeader) {
+		return true
+	}
+	for _, pattern := range stackTracePatterns {
+		if pattern.MatchString(message) {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkStackTrace sets metadata.is_stacktrace to true when the message looks
+// like a stack trace. It reports whether the entry was marked.
+func (l *LogEntry) MarkStackTrace() bool {
+	if !LooksLikeStackTrace(l.Message) {
+		return false
+	}
+	if l.Metadata == nil {
+		l.Metadata = make(map[string]interface{})
+	}
+	l.Metadata[StackTraceKey] = true
+	return true
+}
@@ -0,0 +1,75 @@
+package models
+
+import "testing"
+
+func TestLooksLikeStackTrace(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    bool
+	}{
+		{
+			name: "Java exception",
+			message: "java.lang.NullPointerException: user is null\n" +
+				"\tat com.example.UserService.load(UserService.java:42)\n" +
+				"\tat com.example.Api.handle(Api.java:17)",
+			want: true,
+		},
+		{
+			name:    "Java exception header only",
+			message: `Exception in thread "main" java.lang.IllegalStateException`,
+			want:    true,
+		},
+		{
+			name: "Python traceback",
+			message: "Traceback (most recent call last):\n" +
+				"  File \"app.py\", line 10, in <module>\n" +
+				"    main()\n" +
+				"ValueError: invalid literal for int()",
+			want: true,
+		},
+		{
+			name: "Go panic",
+			message: "panic: runtime error: index out of range [3] with length 2\n\n" +
+				"goroutine 1 [running]:\n" +
+				"main.main()\n" +
+				"\t/app/main.go:12 +0x1d",
+			want: true,
+		},
+		{
+			name:    "Go goroutine dump",
+			message: "goroutine 42 [chan receive]:\nmain.worker(0xc000010000)",
+			want:    true,
+		},
+		{
+			name:    "Ordinary error",
+			message: "failed to connect to db: connection refused",
+			want:    false,
+		},
+		{
+			name:    "Mentions exceptions in prose",
+			message: "Exception handling is enabled for 3 goroutines at startup",
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LooksLikeStackTrace(tt.message); got != tt.want {
+				t.Errorf("LooksLikeStackTrace() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogEntryMarkStackTrace(t *testing.T) {
+	entry := LogEntry{Message: "Traceback (most recent call last):\n  File \"x.py\", line 1"}
+	if !entry.MarkStackTrace() || entry.Metadata[StackTraceKey] != true {
+		t.Errorf("Expected entry to be marked as a stack trace, got %v", entry.Metadata)
+	}
+
+	plain := LogEntry{Message: "request completed"}
+	if plain.MarkStackTrace() || plain.Metadata != nil {
+		t.Errorf("Expected plain entry to be left unmarked, got %v", plain.Metadata)
+	}
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Placeholders substituted for variable parts of a message by Templatize
+const (
+	TemplateUUID = "<UUID>"
+	TemplateIP   = "<IP>"
+	TemplateHex  = "<HEX>"
+	TemplateNum  = "<NUM>"
+)
+
+var (
+	templateUUIDPattern = regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`)
+	templateIPPattern   = regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}(?::\d+)?\b`)
+	templateHexPattern  = regexp.MustCompile(`\b(?:0[xX][0-9a-fA-F]+|[0-9a-fA-F]{8,})\b`)
+	// Numbers may be followed by a unit, as in "125ms"
+	templateNumPattern = regexp.MustCompile(`\b\d+(?:\.\d+)?`)
+)
+
+// Templatize replaces UUIDs, IPv4 addresses, hex values and numbers in message
+// with placeholders, so messages differing only by such values, like
+// "user 123 failed" and "user 456 failed", share the template "user <NUM> failed"
+func Templatize(message string) string {
+	message = templateUUIDPattern.ReplaceAllLiteralString(message, TemplateUUID)
+	message = templateIPPattern.ReplaceAllLiteralString(message, TemplateIP)
+	message = templateHexPattern.ReplaceAllStringFunc(message, func(match string) string {
+		// Unprefixed runs must mix digits and letters to count as hex;
+		// plain numbers are left to the number rule
+		prefixed := strings.HasPrefix(match, "0x") || strings.HasPrefix(match, "0X")
+		if !prefixed && (!strings.ContainsAny(match, "0123456789") || !strings.ContainsAny(match, "abcdefABCDEF")) {
+			return match
+		}
+		return TemplateHex
+	})
+	return templateNumPattern.ReplaceAllLiteralString(message, TemplateNum)
+}
@@ -0,0 +1,55 @@
+package models
+
+import "testing"
+
+func TestTemplatize(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []string
+		want     string
+	}{
+		{
+			name:     "Numbers",
+			messages: []string{"Request took 125ms, retried 3 times", "Request took 80ms, retried 0 times", "Request took 1.5ms, retried 12 times"},
+			want:     "Request took <NUM>ms, retried <NUM> times",
+		},
+		{
+			name: "UUIDs",
+			messages: []string{
+				"Order 3f2504e0-4f89-11d3-9a0c-0305e82c3301 shipped",
+				"Order 9B2E5C7A-1D44-4A0B-8F6E-2C7D9E1A3B55 shipped",
+			},
+			want: "Order <UUID> shipped",
+		},
+		{
+			name:     "IP addresses with and without port",
+			messages: []string{"Connection from 10.0.0.1:5432 refused", "Connection from 192.168.12.200 refused"},
+			want:     "Connection from <IP> refused",
+		},
+		{
+			name:     "Hex values",
+			messages: []string{"Segfault at 0x7ffd5e3c in worker", "Segfault at 0xDEADBEEF in worker", "Segfault at 4f3a9c2e in worker"},
+			want:     "Segfault at <HEX> in worker",
+		},
+		{
+			name:     "Words containing digits are kept",
+			messages: []string{"user42 logged in via oauth2"},
+			want:     "user42 logged in via oauth2",
+		},
+		{
+			name:     "Templates are unchanged",
+			messages: []string{"Order <UUID> took <NUM>ms from <IP>"},
+			want:     "Order <UUID> took <NUM>ms from <IP>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, message := range tt.messages {
+				if got := Templatize(message); got != tt.want {
+					t.Errorf("Templatize(%q) = %q, want %q", message, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"time"
+)
+
+// FieldBucketTimestamp holds the log timestamp rounded down to the configured
+// bucket granularity, so near-simultaneous events group on one value
+const FieldBucketTimestamp = "bucket_ts"
+
+// SetTimestampBucket enables storing each log's timestamp rounded down to
+// granularity in an indexed bucket_ts field for grouping queries. The original
+// timestamp is stored unchanged. Like scalar fields, the bucket field is added
+// when the collection is created; zero disables it.
+func (m *MilvusClient) SetTimestampBucket(granularity time.Duration) {
+	m.timestampBucket = granularity
+}
+
+// bucketTimestamp rounds a Unix millisecond timestamp down to a multiple of
+// granularity
+func bucketTimestamp(timestamp int64, granularity time.Duration) int64 {
+	size := granularity.Milliseconds()
+	if size <= 1 {
+		return timestamp
+	}
+	bucket := timestamp - timestamp%size
+	if timestamp < 0 && timestamp%size != 0 {
+		bucket -= size
+	}
+	return bucket
+}
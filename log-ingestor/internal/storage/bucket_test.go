@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+func TestBucketTimestamp(t *testing.T) {
+	tests := []struct {
+		name        string
+		timestamp   int64
+		granularity time.Duration
+		expected    int64
+	}{
+		{"Second bucket", 1700000000123, time.Second, 1700000000000},
+		{"Second boundary", 1700000001000, time.Second, 1700000001000},
+		{"Last millisecond of a second", 1700000000999, time.Second, 1700000000000},
+		{"Minute bucket", 1700000000123, time.Minute, 1699999980000},
+		{"Minute boundary", 1700000040000, time.Minute, 1700000040000},
+		{"Last millisecond of a minute", 1700000099999, time.Minute, 1700000040000},
+		{"Sub-millisecond granularity is a no-op", 1700000000123, time.Microsecond, 1700000000123},
+		{"Negative timestamps round down", -1500, time.Second, -2000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, bucketTimestamp(tt.timestamp, tt.granularity))
+		})
+	}
+}
+
+func TestMilvusClient_StoreLog_TimestampBucket(t *testing.T) {
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newTestMilvusClient(api, mockEmbedding)
+	client.similarityThreshold = 0 // Disable dedup search
+	client.SetTimestampBucket(time.Second)
+
+	timestamp := time.Now().Truncate(time.Second).UnixMilli() - 1000 + 250
+	var buckets, timestamps []int64
+	mockEmbedding.On("GetEmbedding", mock.Anything, "tick").Return(make([]float32, 768), nil).Once()
+	api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(1), nil).Run(func(args mock.Arguments) {
+		req, err := args.Get(1).(milvusclient.InsertOption).InsertRequest(&entity.Collection{Schema: client.collectionSchema()})
+		require.NoError(t, err)
+		for _, field := range req.GetFieldsData() {
+			switch field.GetFieldName() {
+			case FieldBucketTimestamp:
+				buckets = field.GetScalars().GetLongData().GetData()
+			case FieldTimestamp:
+				timestamps = field.GetScalars().GetLongData().GetData()
+			}
+		}
+	}).Once()
+
+	require.NoError(t, client.StoreLog(context.Background(), &models.LogEntry{Timestamp: timestamp, Message: "tick"}))
+
+	assert.Equal(t, []int64{timestamp - 250}, buckets)
+	assert.Equal(t, []int64{timestamp}, timestamps, "original timestamp is stored unchanged")
+}
+
+func TestMilvusClient_FilterExpression_TimestampBucket(t *testing.T) {
+	client := newTestMilvusClient(&MockMilvusAPI{}, &MockEmbeddingService{})
+	filters := map[string]interface{}{FieldBucketTimestamp: float64(1700000000000)}
+
+	_, err := client.filterExpression(filters)
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+
+	client.SetTimestampBucket(time.Minute)
+	expr, err := client.filterExpression(filters)
+	require.NoError(t, err)
+	assert.Equal(t, "bucket_ts == 1700000000000", expr)
+
+	_, err = client.filterExpression(map[string]interface{}{FieldBucketTimestamp: "now"})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// dedupBypassProbeEvery is how often a store still searches while dedup is
+	// bypassed, so that recovering search latency is noticed
+	dedupBypassProbeEvery = 20
+	// searchLatencyWeight is the weight of the newest sample in the latency average
+	searchLatencyWeight = 0.5
+)
+
+// latencyGuard tracks a moving average of dedup search latency and decides
+// when searches should be skipped because Milvus is too slow
+type latencyGuard struct {
+	threshold  time.Duration
+	probeEvery int
+
+	mu        sync.Mutex
+	average   time.Duration
+	bypassing bool
+	skipped   int
+}
+
+// allow reports whether a dedup search should run. While bypassing, every
+// probeEvery-th call is still allowed to measure the current latency.
+func (g *latencyGuard) allow() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.bypassing {
+		return true
+	}
+	g.skipped++
+	if g.skipped >= g.probeEvery {
+		g.skipped = 0
+		return true
+	}
+	return false
+}
+
+// observe records a search latency, returning whether searches are now
+// bypassed and whether that changed
+func (g *latencyGuard) observe(latency time.Duration) (bypassing, changed bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.average == 0 {
+		g.average = latency
+	} else {
+		g.average += time.Duration(searchLatencyWeight * float64(latency-g.average))
+	}
+
+	was := g.bypassing
+	g.bypassing = g.average > g.threshold
+	if g.bypassing != was {
+		g.skipped = 0
+	}
+	return g.bypassing, g.bypassing != was
+}
+
+// SetDedupBypassLatency enables skipping the dedup search, inserting logs
+// directly, while the average search latency exceeds threshold. Searches
+// resume once the latency recovers. Zero disables the bypass.
+func (m *MilvusClient) SetDedupBypassLatency(threshold time.Duration) {
+	if threshold <= 0 {
+		m.searchGuard = nil
+		return
+	}
+	m.searchGuard = &latencyGuard{threshold: threshold, probeEvery: dedupBypassProbeEvery}
+}
+
+// allowDedupSearch reports whether StoreLog should search for duplicates,
+// counting the stores that skip it
+func (m *MilvusClient) allowDedupSearch() bool {
+	if m.searchGuard == nil || m.searchGuard.allow() {
+		return true
+	}
+	m.dedupBypassed.Inc()
+	return false
+}
+
+// observeSearchLatency feeds a dedup search latency to the bypass guard
+func (m *MilvusClient) observeSearchLatency(latency time.Duration) {
+	if m.searchGuard == nil {
+		return
+	}
+
+	bypassing, changed := m.searchGuard.observe(latency)
+	if !changed {
+		return
+	}
+	fields := logrus.Fields{
+		"latency":   latency.String(),
+		"threshold": m.searchGuard.threshold.String(),
+	}
+	if bypassing {
+		m.logger.WithFields(fields).Warn("Dedup search latency too high, bypassing deduplication")
+	} else {
+		m.logger.WithFields(fields).Info("Dedup search latency recovered, resuming deduplication")
+	}
+}
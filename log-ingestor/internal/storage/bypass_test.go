@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+func TestLatencyGuard(t *testing.T) {
+	guard := &latencyGuard{threshold: 100 * time.Millisecond, probeEvery: 3}
+
+	bypassing, changed := guard.observe(50 * time.Millisecond)
+	assert.False(t, bypassing)
+	assert.False(t, changed)
+	assert.True(t, guard.allow())
+
+	// Average rises above the threshold
+	bypassing, changed = guard.observe(250 * time.Millisecond)
+	assert.True(t, bypassing)
+	assert.True(t, changed)
+
+	// Only every third search is let through as a probe
+	assert.False(t, guard.allow())
+	assert.False(t, guard.allow())
+	assert.True(t, guard.allow())
+
+	// Average falls back below the threshold
+	bypassing, changed = guard.observe(10 * time.Millisecond)
+	assert.False(t, bypassing)
+	assert.True(t, changed)
+	assert.True(t, guard.allow())
+}
+
+func TestMilvusClient_StoreLog_DedupBypass(t *testing.T) {
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newTestMilvusClient(api, mockEmbedding)
+	client.SetDedupBypassLatency(15 * time.Millisecond)
+	client.searchGuard.probeEvery = 2
+
+	mockEmbedding.On("GetEmbedding", mock.Anything, mock.Anything).Return(make([]float32, 768), nil)
+	api.On("Search", mock.Anything, mock.Anything).Return([]milvusclient.ResultSet{}, nil).
+		Run(func(mock.Arguments) { time.Sleep(40 * time.Millisecond) }).Once()
+	api.On("Search", mock.Anything, mock.Anything).Return([]milvusclient.ResultSet{}, nil)
+	api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(1), nil)
+
+	store := func() {
+		log := &models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "upstream timed out"}
+		require.NoError(t, client.StoreLog(context.Background(), log))
+	}
+
+	// A slow search turns on the bypass
+	store()
+	api.AssertNumberOfCalls(t, "Search", 1)
+	store()
+	api.AssertNumberOfCalls(t, "Search", 1)
+	assert.Equal(t, float64(1), testutil.ToFloat64(client.dedupBypassed))
+
+	// A fast probe lowers the average, but not yet below the threshold
+	store()
+	store()
+	api.AssertNumberOfCalls(t, "Search", 2)
+	assert.Equal(t, float64(2), testutil.ToFloat64(client.dedupBypassed))
+
+	// A second fast probe recovers and searches resume on every store
+	store()
+	store()
+	api.AssertNumberOfCalls(t, "Search", 4)
+	assert.Equal(t, float64(2), testutil.ToFloat64(client.dedupBypassed))
+	api.AssertNumberOfCalls(t, "Insert", 6)
+}
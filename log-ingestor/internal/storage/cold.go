@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/timberline/log-ingestor/internal/dlq"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+// SetColdSink sets an append-only sink that receives each log excluded from
+// Milvus as a duplicate, keeping the raw instance in cheap storage while only
+// its occurrence is counted. A nil sink disables it.
+func (m *MilvusClient) SetColdSink(sink dlq.Sink) {
+	m.coldSink = sink
+}
+
+// archiveExcluded writes an excluded duplicate to the cold sink. Failures are
+// logged rather than returned, since the duplicate has already been counted.
+func (m *MilvusClient) archiveExcluded(ctx context.Context, log *models.LogEntry) {
+	if m.coldSink == nil {
+		return
+	}
+	if err := m.coldSink.Write(ctx, []*models.LogEntry{log}); err != nil {
+		m.logger.WithError(err).Warn("Failed to write excluded duplicate to cold storage")
+	}
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+// recordingSink collects the entries written to it
+type recordingSink struct {
+	entries []*models.LogEntry
+}
+
+func (s *recordingSink) Write(ctx context.Context, entries []*models.LogEntry) error {
+	s.entries = append(s.entries, entries...)
+	return nil
+}
+
+func TestMilvusClient_StoreLog_ColdSink(t *testing.T) {
+	tests := []struct {
+		name         string
+		scores       []float32
+		expectInsert bool
+	}{
+		{"Excluded duplicate goes to cold sink", []float32{0.99, 0.98, 0.97}, false},
+		{"Stored example is not archived", []float32{0.99}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &MockMilvusAPI{}
+			mockEmbedding := &MockEmbeddingService{}
+			client := newTestMilvusClient(api, mockEmbedding)
+			client.SetDuplicateFlushInterval(time.Minute) // record increments without touching Milvus
+			sink := &recordingSink{}
+			client.SetColdSink(sink)
+
+			ids := []int64{42, 43, 44}[:len(tt.scores)]
+			mockEmbedding.On("GetEmbedding", mock.Anything, "disk usage 91%").
+				Return(make([]float32, 768), nil).Once()
+			api.On("Search", mock.Anything, mock.Anything).Return(searchResultSet(ids, tt.scores), nil).Once()
+			if tt.expectInsert {
+				api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(50), nil).Once()
+			}
+
+			log := &models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "disk usage 91%", Source: "node-exporter"}
+			require.NoError(t, client.StoreLog(context.Background(), log))
+
+			if tt.expectInsert {
+				assert.Empty(t, sink.entries)
+			} else {
+				api.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+				require.Len(t, sink.entries, 1)
+				assert.Equal(t, "disk usage 91%", sink.entries[0].Message)
+				assert.Equal(t, "node-exporter", sink.entries[0].Source)
+			}
+			api.AssertExpectations(t)
+		})
+	}
+}
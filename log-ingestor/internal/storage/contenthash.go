@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+const (
+	// FieldContentHash holds the SHA-256 of an entry's source and message
+	FieldContentHash = "content_hash"
+
+	// contentHashLength is the length of a hex-encoded SHA-256
+	contentHashLength = 2 * sha256.Size
+)
+
+// ErrContentHashDisabled is returned by content hash lookups when content
+// hashes are not stored
+var ErrContentHashDisabled = errors.New("content hashes are not stored")
+
+// ContentHash returns the hex-encoded SHA-256 identifying an entry's content,
+// the same for every occurrence of a message from a source
+func ContentHash(source, message string) string {
+	sum := sha256.Sum256([]byte(source + "\x00" + message))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetContentHash enables storing each entry's ContentHash in an indexed scalar
+// field, for exact-duplicate lookups and filters. Unless idempotency keys are
+// enabled, an entry whose content hash and timestamp are both already stored,
+// or were recently counted as a duplicate, is treated as a client resend and
+// skipped. Like scalar fields, the hash field is added when the collection is
+// created.
+func (m *MilvusClient) SetContentHash(enabled bool) {
+	m.storeContentHash = enabled
+}
+
+// HasContentHash reports whether a log with the given content hash is stored
+func (m *MilvusClient) HasContentHash(ctx context.Context, hash string) (bool, error) {
+	if !m.storeContentHash {
+		return false, ErrContentHashDisabled
+	}
+	return m.contentHashStored(ctx, fmt.Sprintf("%s == %s", FieldContentHash, strconv.Quote(hash)))
+}
+
+// isResend reports whether log is already stored with the same content and
+// timestamp, i.e. a client retry of an entry that was stored. It costs a query
+// per entry, and cannot tell a retry from a distinct event with identical
+// source, message and millisecond timestamp, which is dropped as well.
+func (m *MilvusClient) isResend(ctx context.Context, log *models.LogEntry) (bool, error) {
+	return m.contentHashStored(ctx, fmt.Sprintf("%s == %s && %s == %d",
+		FieldContentHash, strconv.Quote(ContentHash(log.Source, log.Message)), FieldTimestamp, log.Timestamp))
+}
+
+// contentHashStored reports whether any log matches filter
+func (m *MilvusClient) contentHashStored(ctx context.Context, filter string) (bool, error) {
+	if !m.connected {
+		return false, ErrNotConnected
+	}
+
+	queryOption := milvusclient.NewQueryOption(m.collection).
+		WithFilter(filter).
+		WithOutputFields(FieldID).
+		WithLimit(1)
+
+	result, err := m.client.Query(ctx, queryOption)
+	if err != nil {
+		return false, fmt.Errorf("failed to query content hash: %w", err)
+	}
+	return result.ResultCount > 0, nil
+}
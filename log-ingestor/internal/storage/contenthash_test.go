@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+func TestContentHash(t *testing.T) {
+	// The hash is stable, so it can be computed by clients and across restarts
+	assert.Equal(t, "a14d0f2048b84bf0ef87cd4feb8d9566059cdb6be175785e2a89dc3bd1b221fe", ContentHash("api", "started"))
+	assert.Len(t, ContentHash("", ""), contentHashLength)
+
+	// Source and message are separated, so shifting text between them changes the hash
+	assert.NotEqual(t, ContentHash("api", "started"), ContentHash("ap", "istarted"))
+	assert.NotEqual(t, ContentHash("api", "started"), ContentHash("worker", "started"))
+}
+
+func TestMilvusClient_HasContentHash(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+
+	_, err := client.HasContentHash(context.Background(), ContentHash("api", "started"))
+	require.ErrorIs(t, err, ErrContentHashDisabled)
+
+	client.SetContentHash(true)
+
+	var filter string
+	api.On("Query", mock.Anything, mock.Anything).Return(milvusclient.ResultSet{ResultCount: 1}, nil).Run(func(args mock.Arguments) {
+		request, err := args.Get(1).(milvusclient.QueryOption).Request()
+		require.NoError(t, err)
+		filter = request.GetExpr()
+	}).Once()
+
+	found, err := client.HasContentHash(context.Background(), ContentHash("api", "started"))
+
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, `content_hash == "a14d0f2048b84bf0ef87cd4feb8d9566059cdb6be175785e2a89dc3bd1b221fe"`, filter)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_StoreBatch_ContentHashResend(t *testing.T) {
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newTestMilvusClient(api, mockEmbedding)
+	client.similarityThreshold = 0 // Disable dedup search
+	client.SetContentHash(true)
+
+	now := time.Now().UnixMilli()
+	newBatch := func() []*models.LogEntry {
+		return []*models.LogEntry{{Timestamp: now, Message: "started", Source: "api"}}
+	}
+
+	// First attempt: nothing stored yet, the entry is inserted with its hash
+	var filter string
+	var insertedHashes []string
+	api.On("Query", mock.Anything, mock.Anything).Return(milvusclient.ResultSet{}, nil).Run(func(args mock.Arguments) {
+		request, err := args.Get(1).(milvusclient.QueryOption).Request()
+		require.NoError(t, err)
+		filter = request.GetExpr()
+	}).Once()
+	mockEmbedding.On("GetEmbedding", mock.Anything, "started").Return(make([]float32, 768), nil).Once()
+	api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(1), nil).Run(func(args mock.Arguments) {
+		req, err := args.Get(1).(milvusclient.InsertOption).InsertRequest(&entity.Collection{Schema: client.collectionSchema()})
+		require.NoError(t, err)
+		for _, field := range req.GetFieldsData() {
+			if field.GetFieldName() == FieldContentHash {
+				insertedHashes = append(insertedHashes, field.GetScalars().GetStringData().GetData()...)
+			}
+		}
+	}).Once()
+
+	require.NoError(t, client.StoreBatch(context.Background(), newBatch()))
+	assert.Equal(t, []string{ContentHash("api", "started")}, insertedHashes)
+	assert.Equal(t, fmt.Sprintf(`content_hash == "%s" && timestamp == %d`, ContentHash("api", "started"), now), filter)
+
+	// Resend: the same content and timestamp are stored, so nothing is embedded or inserted
+	api.On("Query", mock.Anything, mock.Anything).Return(milvusclient.ResultSet{ResultCount: 1}, nil).Once()
+
+	require.NoError(t, client.StoreBatch(context.Background(), newBatch()))
+
+	mockEmbedding.AssertNumberOfCalls(t, "GetEmbedding", 1)
+	api.AssertNumberOfCalls(t, "Insert", 1)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_StoreLog_ContentHashResendOfDeduplicatedEntry(t *testing.T) {
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newTestMilvusClient(api, mockEmbedding)
+	client.SetContentHash(true)
+	client.SetDuplicateFlushInterval(time.Minute) // record increments without touching Milvus
+
+	// The entry is counted as a duplicate of log 42, so its hash is never inserted
+	api.On("Query", mock.Anything, mock.Anything).Return(milvusclient.ResultSet{}, nil).Once()
+	mockEmbedding.On("GetEmbedding", mock.Anything, "payment declined").Return(make([]float32, 768), nil).Once()
+	api.On("Search", mock.Anything, mock.Anything).
+		Return(searchResultSet([]int64{42, 43, 44}, []float32{0.99, 0.98, 0.97}), nil).Once()
+
+	now := time.Now().UnixMilli()
+	newLog := func(timestamp int64) *models.LogEntry {
+		return &models.LogEntry{Timestamp: timestamp, Message: "payment declined", Source: "api"}
+	}
+	require.NoError(t, client.StoreLog(context.Background(), newLog(now)))
+	assert.Equal(t, map[int64]int64{42: 1}, client.pendingDuplicates)
+
+	// Resends are recognized without a lookup and not counted again
+	require.NoError(t, client.StoreLog(context.Background(), newLog(now)))
+	require.NoError(t, client.StoreBatch(context.Background(), []*models.LogEntry{newLog(now)}))
+	assert.Equal(t, map[int64]int64{42: 1}, client.pendingDuplicates)
+
+	// A new occurrence at another time is still counted
+	api.On("Query", mock.Anything, mock.Anything).Return(milvusclient.ResultSet{}, nil).Once()
+	mockEmbedding.On("GetEmbedding", mock.Anything, "payment declined").Return(make([]float32, 768), nil).Once()
+	api.On("Search", mock.Anything, mock.Anything).
+		Return(searchResultSet([]int64{42, 43, 44}, []float32{0.99, 0.98, 0.97}), nil).Once()
+
+	require.NoError(t, client.StoreLog(context.Background(), newLog(now+1)))
+
+	assert.Equal(t, map[int64]int64{42: 2}, client.pendingDuplicates)
+	api.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	api.AssertExpectations(t)
+	mockEmbedding.AssertExpectations(t)
+}
+
+func TestMilvusClient_CollectionSchema_ContentHash(t *testing.T) {
+	client := newTestMilvusClient(&MockMilvusAPI{}, &MockEmbeddingService{})
+	for _, field := range client.collectionSchema().Fields {
+		assert.NotEqual(t, FieldContentHash, field.Name)
+	}
+
+	client.SetContentHash(true)
+	fields := client.collectionSchema().Fields
+	last := fields[len(fields)-1]
+	assert.Equal(t, FieldContentHash, last.Name)
+	assert.Equal(t, "64", last.TypeParams["max_length"])
+	assert.False(t, isValidScalarFieldName(FieldContentHash))
+
+	expr, err := client.filterExpression(map[string]interface{}{FieldContentHash: "abc"})
+	require.NoError(t, err)
+	assert.Equal(t, `content_hash == "abc"`, expr)
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/milvus-io/milvus/client/v2/column"
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+	"github.com/sirupsen/logrus"
+)
+
+// SetDuplicateFlushInterval makes duplicate hits accumulate in memory and be
+// written by RunDuplicateFlusher as one batched upsert per interval, instead
+// of a query and upsert per hit. Zero keeps per-hit updates.
+func (m *MilvusClient) SetDuplicateFlushInterval(interval time.Duration) {
+	m.duplicateFlushInterval = interval
+}
+
+// recordDuplicate counts a duplicate hit for logID, either immediately or in
+// the pending batch when aggregation is enabled
+func (m *MilvusClient) recordDuplicate(ctx context.Context, logID int64) error {
+	if m.duplicateFlushInterval <= 0 {
+		return m.UpdateDuplicateCount(ctx, logID)
+	}
+
+	m.duplicatesMu.Lock()
+	defer m.duplicatesMu.Unlock()
+
+	if m.pendingDuplicates == nil {
+		m.pendingDuplicates = make(map[int64]int64)
+	}
+	m.pendingDuplicates[logID]++
+	return nil
+}
+
+// RunDuplicateFlusher flushes pending duplicate counts every flush interval
+// until ctx is cancelled, then flushes once more. It returns immediately when
+// aggregation is disabled.
+func (m *MilvusClient) RunDuplicateFlusher(ctx context.Context) {
+	if m.duplicateFlushInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(m.duplicateFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := m.FlushDuplicateCounts(flushCtx); err != nil {
+				m.logger.WithError(err).Error("Failed to flush duplicate counts on shutdown")
+			}
+			cancel()
+			return
+
+		case <-ticker.C:
+			if err := m.FlushDuplicateCounts(ctx); err != nil {
+				m.logger.WithError(err).Warn("Failed to flush duplicate counts, will retry")
+			}
+		}
+	}
+}
+
+// FlushDuplicateCounts adds all pending increments to the stored duplicate
+// counts using a single query and a single partial upsert. On failure the
+// increments are kept and retried on the next flush.
+func (m *MilvusClient) FlushDuplicateCounts(ctx context.Context) error {
+	m.duplicatesMu.Lock()
+	pending := m.pendingDuplicates
+	m.pendingDuplicates = nil
+	m.duplicatesMu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if err := m.flushDuplicateCounts(ctx, pending); err != nil {
+		m.requeueDuplicates(pending)
+		return err
+	}
+
+	return nil
+}
+
+func (m *MilvusClient) flushDuplicateCounts(ctx context.Context, pending map[int64]int64) error {
+	if !m.connected {
+		return ErrNotConnected
+	}
+
+	ids := make([]int64, 0, len(pending))
+	for id := range pending {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	idStrs := make([]string, len(ids))
+	for i, id := range ids {
+		idStrs[i] = strconv.FormatInt(id, 10)
+	}
+
+	queryOption := milvusclient.NewQueryOption(m.collection).
+		WithFilter(fmt.Sprintf("%s in [%s]", FieldID, strings.Join(idStrs, ", "))).
+		WithOutputFields(FieldID, FieldDuplicateCount)
+
+	result, err := m.client.Query(ctx, queryOption)
+	if err != nil {
+		return fmt.Errorf("failed to query duplicate counts: %w", err)
+	}
+	if result.ResultCount == 0 {
+		m.logger.WithField("log_ids", ids).Warn("Logs for pending duplicate counts no longer exist")
+		return nil
+	}
+
+	idCol, ok := result.GetColumn(FieldID).(*column.ColumnInt64)
+	if !ok {
+		return fmt.Errorf("failed to extract ID column")
+	}
+	duplicateCountCol, ok := result.GetColumn(FieldDuplicateCount).(*column.ColumnInt64)
+	if !ok {
+		return fmt.Errorf("failed to extract duplicate count column")
+	}
+
+	updatedIDs := make([]int64, idCol.Len())
+	updatedCounts := make([]int64, idCol.Len())
+	for i, id := range idCol.Data() {
+		updatedIDs[i] = id
+		updatedCounts[i] = duplicateCountCol.Data()[i] + pending[id]
+	}
+
+	upsertOption := milvusclient.NewColumnBasedInsertOption(m.collection).WithColumns(
+		column.NewColumnInt64(FieldID, updatedIDs),
+		column.NewColumnInt64(FieldDuplicateCount, updatedCounts),
+	).WithPartialUpdate(true)
+	if _, err := m.client.Upsert(ctx, upsertOption); err != nil {
+		return fmt.Errorf("failed to update duplicate counts: %w", err)
+	}
+	m.lastStore.SetToCurrentTime()
+
+	m.logger.WithFields(logrus.Fields{
+		"logs":    len(updatedIDs),
+		"pending": len(pending),
+	}).Debug("Flushed duplicate counts")
+
+	return nil
+}
+
+// requeueDuplicates merges increments from a failed flush back into the pending batch
+func (m *MilvusClient) requeueDuplicates(increments map[int64]int64) {
+	m.duplicatesMu.Lock()
+	defer m.duplicatesMu.Unlock()
+
+	if m.pendingDuplicates == nil {
+		m.pendingDuplicates = make(map[int64]int64, len(increments))
+	}
+	for id, n := range increments {
+		m.pendingDuplicates[id] += n
+	}
+}
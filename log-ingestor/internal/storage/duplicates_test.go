@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/milvus-io/milvus/client/v2/column"
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// duplicateCountResultSet builds a query result with IDs and their stored duplicate counts
+func duplicateCountResultSet(ids, counts []int64) milvusclient.ResultSet {
+	return milvusclient.ResultSet{
+		ResultCount: len(ids),
+		Fields: milvusclient.DataSet{
+			column.NewColumnInt64(FieldID, ids),
+			column.NewColumnInt64(FieldDuplicateCount, counts),
+		},
+	}
+}
+
+// upsertedCounts decodes the duplicate counts written by an upsert, keyed by ID
+func upsertedCounts(t *testing.T, client *MilvusClient, option milvusclient.UpsertOption) map[int64]int64 {
+	req, err := option.UpsertRequest(&entity.Collection{Schema: client.collectionSchema()})
+	require.NoError(t, err)
+	assert.True(t, req.GetPartialUpdate())
+
+	var ids, counts []int64
+	for _, field := range req.GetFieldsData() {
+		switch field.GetFieldName() {
+		case FieldID:
+			ids = field.GetScalars().GetLongData().GetData()
+		case FieldDuplicateCount:
+			counts = field.GetScalars().GetLongData().GetData()
+		}
+	}
+	require.Len(t, counts, len(ids))
+
+	result := make(map[int64]int64, len(ids))
+	for i, id := range ids {
+		result[id] = counts[i]
+	}
+	return result
+}
+
+func TestMilvusClient_FlushDuplicateCounts_CoalescesIncrements(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+	client.SetDuplicateFlushInterval(time.Minute)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, client.recordDuplicate(context.Background(), 7))
+	}
+	require.NoError(t, client.recordDuplicate(context.Background(), 9))
+
+	// Nothing is written until the flush
+	api.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+	api.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+
+	api.On("Query", mock.Anything, mock.Anything).Return(duplicateCountResultSet([]int64{7, 9}, []int64{3, 1}), nil).Once()
+	var upserted map[int64]int64
+	api.On("Upsert", mock.Anything, mock.Anything).Return(milvusclient.UpsertResult{}, nil).Run(func(args mock.Arguments) {
+		upserted = upsertedCounts(t, client, args.Get(1).(milvusclient.UpsertOption))
+	}).Once()
+
+	require.NoError(t, client.FlushDuplicateCounts(context.Background()))
+
+	assert.Equal(t, map[int64]int64{7: 8, 9: 2}, upserted)
+	api.AssertExpectations(t)
+
+	// The batch is cleared after a successful flush
+	require.NoError(t, client.FlushDuplicateCounts(context.Background()))
+	api.AssertNumberOfCalls(t, "Upsert", 1)
+}
+
+func TestMilvusClient_FlushDuplicateCounts_RetriesAfterFailure(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+	client.SetDuplicateFlushInterval(time.Minute)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, client.recordDuplicate(context.Background(), 7))
+	}
+
+	api.On("Query", mock.Anything, mock.Anything).Return(duplicateCountResultSet([]int64{7}, []int64{1}), nil).Twice()
+	api.On("Upsert", mock.Anything, mock.Anything).Return(milvusclient.UpsertResult{}, assert.AnError).Once()
+
+	err := client.FlushDuplicateCounts(context.Background())
+	assert.ErrorIs(t, err, assert.AnError)
+
+	// Increments recorded in the meantime are merged with the failed batch
+	require.NoError(t, client.recordDuplicate(context.Background(), 7))
+
+	var upserted map[int64]int64
+	api.On("Upsert", mock.Anything, mock.Anything).Return(milvusclient.UpsertResult{}, nil).Run(func(args mock.Arguments) {
+		upserted = upsertedCounts(t, client, args.Get(1).(milvusclient.UpsertOption))
+	}).Once()
+
+	require.NoError(t, client.FlushDuplicateCounts(context.Background()))
+	assert.Equal(t, map[int64]int64{7: 5}, upserted)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_RecordDuplicate_ImmediateWhenDisabled(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+
+	api.On("Query", mock.Anything, mock.Anything).Return(duplicateCountResultSet([]int64{7}, []int64{3}), nil).Once()
+	var upserted map[int64]int64
+	api.On("Upsert", mock.Anything, mock.Anything).Return(milvusclient.UpsertResult{}, nil).Run(func(args mock.Arguments) {
+		upserted = upsertedCounts(t, client, args.Get(1).(milvusclient.UpsertOption))
+	}).Once()
+
+	require.NoError(t, client.recordDuplicate(context.Background(), 7))
+
+	assert.Equal(t, map[int64]int64{7: 4}, upserted)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_RunDuplicateFlusher_FlushesOnShutdown(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+	client.SetDuplicateFlushInterval(time.Hour)
+
+	require.NoError(t, client.recordDuplicate(context.Background(), 7))
+	require.NoError(t, client.recordDuplicate(context.Background(), 7))
+
+	api.On("Query", mock.Anything, mock.Anything).Return(duplicateCountResultSet([]int64{7}, []int64{1}), nil).Once()
+	api.On("Upsert", mock.Anything, mock.Anything).Return(milvusclient.UpsertResult{}, nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		client.RunDuplicateFlusher(ctx)
+		close(done)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("flusher did not stop")
+	}
+	api.AssertExpectations(t)
+}
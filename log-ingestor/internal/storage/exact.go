@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+)
+
+// exactDuplicateCache is an LRU of message hashes to the stored log that
+// identical messages were last counted against, kept as a SearchResult. Exact repeats can then be
+// counted without an embedding call or similarity search.
+type exactDuplicateCache struct {
+	size int
+
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]*list.Element
+	order   *list.List // front is most recently used
+}
+
+type exactDuplicateEntry struct {
+	hash  [sha256.Size]byte
+	match SearchResult
+}
+
+func newExactDuplicateCache(size int) *exactDuplicateCache {
+	return &exactDuplicateCache{
+		size:    size,
+		entries: make(map[[sha256.Size]byte]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get returns the matched log remembered for hash
+func (c *exactDuplicateCache) get(hash [sha256.Size]byte) (SearchResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[hash]
+	if !ok {
+		return SearchResult{}, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*exactDuplicateEntry).match, true
+}
+
+// add remembers match for hash, evicting the least recently used entry when full
+func (c *exactDuplicateCache) add(hash [sha256.Size]byte, match SearchResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[hash]; ok {
+		c.order.MoveToFront(elem)
+		elem.Value.(*exactDuplicateEntry).match = match
+		return
+	}
+
+	c.entries[hash] = c.order.PushFront(&exactDuplicateEntry{hash: hash, match: match})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*exactDuplicateEntry).hash)
+	}
+}
+
+// remove forgets hash, e.g. when its log no longer exists
+func (c *exactDuplicateCache) remove(hash [sha256.Size]byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[hash]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, hash)
+	}
+}
+
+// reset forgets every entry
+func (c *exactDuplicateCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[[sha256.Size]byte]*list.Element)
+	c.order.Init()
+}
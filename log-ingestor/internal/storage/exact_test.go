@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+func TestExactDuplicateCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newExactDuplicateCache(2)
+	a := sha256.Sum256([]byte("a"))
+	b := sha256.Sum256([]byte("b"))
+	c := sha256.Sum256([]byte("c"))
+
+	cache.add(a, SearchResult{ID: 1})
+	cache.add(b, SearchResult{ID: 2})
+	_, _ = cache.get(a) // a is now more recent than b
+	cache.add(c, SearchResult{ID: 3})
+
+	match, ok := cache.get(a)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), match.ID)
+	_, ok = cache.get(b)
+	assert.False(t, ok)
+	match, ok = cache.get(c)
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), match.ID)
+
+	cache.reset()
+	_, ok = cache.get(a)
+	assert.False(t, ok)
+}
+
+func TestMilvusClient_StoreLog_ExactDuplicateSkipsEmbedding(t *testing.T) {
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newTestMilvusClient(api, mockEmbedding)
+	client.SetExactDuplicateCacheSize(10)
+	client.SetDuplicateFlushInterval(time.Minute) // record increments without touching Milvus
+
+	mockEmbedding.On("GetEmbedding", mock.Anything, "connection reset by peer").
+		Return(make([]float32, 768), nil).Once()
+	api.On("Search", mock.Anything, mock.Anything).
+		Return(searchResultSet([]int64{42, 43, 44}, []float32{0.99, 0.98, 0.97}), nil).Once()
+
+	newLog := func() *models.LogEntry {
+		return &models.LogEntry{
+			Timestamp: time.Now().UnixMilli(),
+			Message:   "connection reset by peer",
+			Source:    "api",
+		}
+	}
+
+	// The first occurrence goes through embedding and search
+	require.NoError(t, client.StoreLog(context.Background(), newLog()))
+	// Exact repeats are counted against the same log without either
+	require.NoError(t, client.StoreLog(context.Background(), newLog()))
+	require.NoError(t, client.StoreLog(context.Background(), newLog()))
+
+	mockEmbedding.AssertNumberOfCalls(t, "GetEmbedding", 1)
+	api.AssertNumberOfCalls(t, "Search", 1)
+	api.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	assert.Equal(t, map[int64]int64{42: 3}, client.pendingDuplicates)
+}
+
+func TestMilvusClient_StoreLog_ExactDuplicateRequiresExclusion(t *testing.T) {
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newTestMilvusClient(api, mockEmbedding)
+	client.SetExactDuplicateCacheSize(10)
+
+	// Only one similar example exists, below MinExamplesBeforeExclusion, so
+	// every occurrence is stored and nothing is remembered
+	mockEmbedding.On("GetEmbedding", mock.Anything, "disk almost full").
+		Return(make([]float32, 768), nil).Twice()
+	api.On("Search", mock.Anything, mock.Anything).
+		Return(searchResultSet([]int64{42}, []float32{0.99}), nil).Twice()
+	api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(50), nil).Twice()
+
+	for i := 0; i < 2; i++ {
+		log := &models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "disk almost full"}
+		require.NoError(t, client.StoreLog(context.Background(), log))
+	}
+
+	mockEmbedding.AssertExpectations(t)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_StoreLog_ExactDuplicateFallsBackWhenUpdateFails(t *testing.T) {
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newTestMilvusClient(api, mockEmbedding)
+	client.SetExactDuplicateCacheSize(10)
+
+	// The remembered log has been deleted, so the count update fails
+	client.exactDuplicates.add(sha256.Sum256([]byte("cache miss")), SearchResult{ID: 42})
+	api.On("Query", mock.Anything, mock.Anything).Return(queryResultSet(nil), errors.New("not found")).Once()
+
+	mockEmbedding.On("GetEmbedding", mock.Anything, "cache miss").
+		Return(make([]float32, 768), nil).Once()
+	api.On("Search", mock.Anything, mock.Anything).
+		Return(searchResultSet(nil, nil), nil).Once()
+	api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(51), nil).Once()
+
+	log := &models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "cache miss"}
+	require.NoError(t, client.StoreLog(context.Background(), log))
+
+	_, ok := client.exactDuplicates.get(sha256.Sum256([]byte("cache miss")))
+	assert.False(t, ok)
+	mockEmbedding.AssertExpectations(t)
+	api.AssertExpectations(t)
+}
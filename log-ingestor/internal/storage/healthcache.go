@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"sync"
+	"time"
+)
+
+// healthCache remembers the last health check result for a short time so
+// frequent probes do not each query Milvus
+type healthCache struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	valid     bool
+	checkedAt time.Time
+	err       error
+}
+
+// get returns the cached result if it is younger than the TTL
+func (c *healthCache) get() (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.valid || time.Since(c.checkedAt) >= c.ttl {
+		return false, nil
+	}
+	return true, c.err
+}
+
+// set caches a health check result
+func (c *healthCache) set(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.valid = true
+	c.checkedAt = time.Now()
+	c.err = err
+}
+
+// invalidate forgets the cached result so the next probe checks Milvus
+func (c *healthCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.valid = false
+}
+
+// SetHealthCheckCacheTTL makes HealthCheck reuse its last result for ttl, so
+// Kubernetes probes hitting /ready and /healthz every few seconds do not each
+// query Milvus. The cached result is dropped when the client is closed or a
+// store fails because Milvus is unreachable. Zero disables caching.
+func (m *MilvusClient) SetHealthCheckCacheTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		m.healthCache = nil
+		return
+	}
+	m.healthCache = &healthCache{ttl: ttl}
+}
+
+// invalidateHealthOnUnavailable drops the cached health result when err shows
+// Milvus cannot be reached
+func (m *MilvusClient) invalidateHealthOnUnavailable(err error) {
+	if m.healthCache != nil && IsUnavailable(err) {
+		m.healthCache.invalidate()
+	}
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+func TestMilvusClient_HealthCheck_CachedWithinTTL(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+	client.SetHealthCheckCacheTTL(time.Minute)
+
+	api.On("HasCollection", mock.Anything, mock.Anything).Return(true, nil).Once()
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, client.HealthCheck(context.Background()))
+	}
+	api.AssertNumberOfCalls(t, "HasCollection", 1)
+}
+
+func TestMilvusClient_HealthCheck_CacheExpires(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+	client.SetHealthCheckCacheTTL(20 * time.Millisecond)
+
+	api.On("HasCollection", mock.Anything, mock.Anything).Return(false, errors.New("milvus unavailable")).Once()
+	api.On("HasCollection", mock.Anything, mock.Anything).Return(true, nil).Once()
+
+	// Failures are cached too, so a struggling Milvus is not probed harder
+	assert.Error(t, client.HealthCheck(context.Background()))
+	assert.Error(t, client.HealthCheck(context.Background()))
+
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, client.HealthCheck(context.Background()))
+	api.AssertNumberOfCalls(t, "HasCollection", 2)
+}
+
+func TestMilvusClient_HealthCheck_InvalidatedOnDisconnect(t *testing.T) {
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newTestMilvusClient(api, mockEmbedding)
+	client.similarityThreshold = 0 // Disable dedup search
+	client.SetHealthCheckCacheTTL(time.Minute)
+
+	api.On("HasCollection", mock.Anything, mock.Anything).Return(true, nil).Twice()
+	mockEmbedding.On("GetEmbedding", mock.Anything, mock.Anything).Return(make([]float32, 768), nil)
+	api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(1), nil).Once()
+	api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(0), errors.New("rpc error: code = Unavailable desc = connection refused")).Once()
+
+	require.NoError(t, client.HealthCheck(context.Background()))
+
+	// A successful store keeps the cached result
+	require.NoError(t, client.StoreLog(context.Background(), &models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "ok"}))
+	require.NoError(t, client.HealthCheck(context.Background()))
+	api.AssertNumberOfCalls(t, "HasCollection", 1)
+
+	// A store failing because Milvus is unreachable forces a fresh check
+	require.Error(t, client.StoreLog(context.Background(), &models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "lost"}))
+	require.NoError(t, client.HealthCheck(context.Background()))
+	api.AssertNumberOfCalls(t, "HasCollection", 2)
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/milvus-io/milvus/client/v2/column"
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+const (
+	// FieldIdempotencyKey holds the per-entry key used to skip resent entries
+	FieldIdempotencyKey = "idempotency_key"
+
+	// idempotencyKeyMaxLength is the VarChar capacity of the idempotency key field;
+	// longer client-provided keys are hashed to fit
+	idempotencyKeyMaxLength = 128
+)
+
+// SetIdempotencyKeys enables storing the client-provided idempotency_key of
+// each entry in a scalar field and skipping entries whose key is already
+// stored, or was recently counted as a duplicate, so clients can safely resend
+// a whole stream after a partial failure. Entries without a key are never
+// skipped. Like scalar fields, the key field is added when the collection is
+// created.
+func (m *MilvusClient) SetIdempotencyKeys(enabled bool) {
+	m.idempotencyKeys = enabled
+}
+
+// normalizeIdempotencyKey hashes an oversized client-provided key to fit the field
+func normalizeIdempotencyKey(log *models.LogEntry) {
+	if len(log.IdempotencyKey) > idempotencyKeyMaxLength {
+		sum := sha256.Sum256([]byte(log.IdempotencyKey))
+		log.IdempotencyKey = hex.EncodeToString(sum[:])
+	}
+}
+
+// storedIdempotencyKeys normalizes the idempotency keys of logs and returns the
+// set of those keys that are already stored, using a single query
+func (m *MilvusClient) storedIdempotencyKeys(ctx context.Context, logs []*models.LogEntry) (map[string]struct{}, error) {
+	if !m.connected {
+		return nil, ErrNotConnected
+	}
+
+	quoted := make([]string, 0, len(logs))
+	for _, log := range logs {
+		if log == nil {
+			continue
+		}
+		normalizeIdempotencyKey(log)
+		// Resends of entries counted as duplicates are skipped by storeLog anyway
+		if log.IdempotencyKey == "" || m.isMergedResend(log) {
+			continue
+		}
+		quoted = append(quoted, strconv.Quote(log.IdempotencyKey))
+	}
+
+	stored := make(map[string]struct{})
+	if len(quoted) == 0 {
+		return stored, nil
+	}
+
+	queryOption := milvusclient.NewQueryOption(m.collection).
+		WithFilter(fmt.Sprintf("%s in [%s]", FieldIdempotencyKey, strings.Join(quoted, ", "))).
+		WithOutputFields(FieldIdempotencyKey)
+
+	result, err := m.client.Query(ctx, queryOption)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query idempotency keys: %w", err)
+	}
+	if result.ResultCount == 0 {
+		return stored, nil
+	}
+
+	keyCol, ok := result.GetColumn(FieldIdempotencyKey).(*column.ColumnVarChar)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract idempotency key column")
+	}
+	for _, key := range keyCol.Data() {
+		stored[key] = struct{}{}
+	}
+	return stored, nil
+}
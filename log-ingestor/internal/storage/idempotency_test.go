@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milvus-io/milvus/client/v2/column"
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+// idempotencyKeyResultSet builds a query result listing stored idempotency keys
+func idempotencyKeyResultSet(keys ...string) milvusclient.ResultSet {
+	return milvusclient.ResultSet{
+		ResultCount: len(keys),
+		Fields:      milvusclient.DataSet{column.NewColumnVarChar(FieldIdempotencyKey, keys)},
+	}
+}
+
+func newIdempotentTestClient(api *MockMilvusAPI, mockEmbedding *MockEmbeddingService) *MilvusClient {
+	client := newTestMilvusClient(api, mockEmbedding)
+	client.similarityThreshold = 0 // Disable dedup search
+	client.SetIdempotencyKeys(true)
+	return client
+}
+
+func TestNormalizeIdempotencyKey(t *testing.T) {
+	// Entries without a client-provided key get none; identical events are not merged
+	keyless := &models.LogEntry{Timestamp: 1700000000000, Source: "api", Message: "started"}
+	normalizeIdempotencyKey(keyless)
+	assert.Empty(t, keyless.IdempotencyKey)
+
+	provided := &models.LogEntry{Timestamp: 1700000000000, Message: "started", IdempotencyKey: "req-42/line-7"}
+	normalizeIdempotencyKey(provided)
+	assert.Equal(t, "req-42/line-7", provided.IdempotencyKey)
+
+	oversized := &models.LogEntry{Timestamp: 1700000000000, Message: "started", IdempotencyKey: strings.Repeat("k", 200)}
+	normalizeIdempotencyKey(oversized)
+	assert.Len(t, oversized.IdempotencyKey, 64)
+}
+
+func TestMilvusClient_StoreBatch_ResendDoesNotDuplicate(t *testing.T) {
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newIdempotentTestClient(api, mockEmbedding)
+
+	now := time.Now().UnixMilli()
+	newBatch := func() []*models.LogEntry {
+		return []*models.LogEntry{
+			{Timestamp: now, Message: "first", Source: "api"},
+			{Timestamp: now, Message: "second", Source: "api", IdempotencyKey: "client-key-2"},
+		}
+	}
+
+	// First attempt: nothing stored yet, both entries are inserted with their keys
+	var insertedKeys []string
+	api.On("Query", mock.Anything, mock.Anything).Return(idempotencyKeyResultSet(), nil).Once()
+	mockEmbedding.On("GetEmbedding", mock.Anything, mock.Anything).Return(make([]float32, 768), nil).Twice()
+	api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(1), nil).Run(func(args mock.Arguments) {
+		req, err := args.Get(1).(milvusclient.InsertOption).InsertRequest(&entity.Collection{Schema: client.collectionSchema()})
+		require.NoError(t, err)
+		for _, field := range req.GetFieldsData() {
+			if field.GetFieldName() == FieldIdempotencyKey {
+				insertedKeys = append(insertedKeys, field.GetScalars().GetStringData().GetData()...)
+			}
+		}
+	}).Twice()
+
+	require.NoError(t, client.StoreBatch(context.Background(), newBatch()))
+	assert.Equal(t, []string{"", "client-key-2"}, insertedKeys)
+
+	// Resend: the keyed entry is already stored and skipped. The keyless one
+	// cannot be told apart from a new identical event, so it is stored again.
+	api.On("Query", mock.Anything, mock.Anything).Return(idempotencyKeyResultSet("client-key-2"), nil).Once()
+	mockEmbedding.On("GetEmbedding", mock.Anything, "first").Return(make([]float32, 768), nil).Once()
+	api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(1), nil).Once()
+
+	require.NoError(t, client.StoreBatch(context.Background(), newBatch()))
+
+	mockEmbedding.AssertNumberOfCalls(t, "GetEmbedding", 3)
+	api.AssertNumberOfCalls(t, "Insert", 3)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_StoreBatch_RepeatedKeyWithinBatch(t *testing.T) {
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newIdempotentTestClient(api, mockEmbedding)
+
+	now := time.Now().UnixMilli()
+	batch := []*models.LogEntry{
+		{Timestamp: now, Message: "payment accepted", IdempotencyKey: "txn-1"},
+		{Timestamp: now, Message: "payment accepted", IdempotencyKey: "txn-1"},
+	}
+
+	api.On("Query", mock.Anything, mock.Anything).Return(idempotencyKeyResultSet(), nil).Once()
+	mockEmbedding.On("GetEmbedding", mock.Anything, "payment accepted").Return(make([]float32, 768), nil).Once()
+	api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(1), nil).Once()
+
+	require.NoError(t, client.StoreBatch(context.Background(), batch))
+
+	mockEmbedding.AssertExpectations(t)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_StoreLog_SkipsStoredIdempotencyKey(t *testing.T) {
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newIdempotentTestClient(api, mockEmbedding)
+
+	api.On("Query", mock.Anything, mock.Anything).Return(idempotencyKeyResultSet("txn-9"), nil).Once()
+
+	log := &models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "refund issued", IdempotencyKey: "txn-9"}
+	require.NoError(t, client.StoreLog(context.Background(), log))
+
+	mockEmbedding.AssertNotCalled(t, "GetEmbedding", mock.Anything, mock.Anything)
+	api.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_StoreLog_ResendOfDeduplicatedEntry(t *testing.T) {
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newTestMilvusClient(api, mockEmbedding)
+	client.SetIdempotencyKeys(true)
+	client.SetDuplicateFlushInterval(time.Minute) // record increments without touching Milvus
+
+	// The entry is counted as a duplicate of log 42, so its key is never inserted
+	api.On("Query", mock.Anything, mock.Anything).Return(idempotencyKeyResultSet(), nil).Once()
+	mockEmbedding.On("GetEmbedding", mock.Anything, "payment declined").Return(make([]float32, 768), nil).Once()
+	api.On("Search", mock.Anything, mock.Anything).
+		Return(searchResultSet([]int64{42, 43, 44}, []float32{0.99, 0.98, 0.97}), nil).Once()
+
+	newLog := func() *models.LogEntry {
+		return &models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "payment declined", Source: "api", IdempotencyKey: "txn-5"}
+	}
+	require.NoError(t, client.StoreLog(context.Background(), newLog()))
+	assert.Equal(t, map[int64]int64{42: 1}, client.pendingDuplicates)
+
+	// Resends are recognized without a lookup and not counted again
+	require.NoError(t, client.StoreLog(context.Background(), newLog()))
+	require.NoError(t, client.StoreBatch(context.Background(), []*models.LogEntry{newLog()}))
+
+	assert.Equal(t, map[int64]int64{42: 1}, client.pendingDuplicates)
+	api.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	mockEmbedding.AssertExpectations(t)
+}
+
+func TestMilvusClient_StoreBatch_IdempotencyQueryFails(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newIdempotentTestClient(api, &MockEmbeddingService{})
+
+	api.On("Query", mock.Anything, mock.Anything).Return(milvusclient.ResultSet{}, assert.AnError).Once()
+
+	batch := []*models.LogEntry{{Timestamp: time.Now().UnixMilli(), Message: "one", IdempotencyKey: "txn-1"}}
+	err := client.StoreBatch(context.Background(), batch)
+
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, batch, batchErr.Failed)
+	api.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+}
+
+func TestMilvusClient_CollectionSchema_IdempotencyKey(t *testing.T) {
+	client := newTestMilvusClient(&MockMilvusAPI{}, &MockEmbeddingService{})
+	for _, field := range client.collectionSchema().Fields {
+		assert.NotEqual(t, FieldIdempotencyKey, field.Name)
+	}
+
+	client.SetIdempotencyKeys(true)
+	fields := client.collectionSchema().Fields
+	last := fields[len(fields)-1]
+	assert.Equal(t, FieldIdempotencyKey, last.Name)
+	assert.Equal(t, "128", last.TypeParams["max_length"])
+	assert.False(t, isValidScalarFieldName(FieldIdempotencyKey))
+}
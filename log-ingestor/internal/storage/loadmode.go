@@ -0,0 +1,19 @@
+package storage
+
+// CollectionLoadMode controls when the collection is loaded into memory for search
+type CollectionLoadMode string
+
+const (
+	// CollectionLoadEager loads the collection during Warmup at startup
+	CollectionLoadEager CollectionLoadMode = "eager"
+	// CollectionLoadLazy loads the collection on the first search that finds it unloaded
+	CollectionLoadLazy CollectionLoadMode = "lazy"
+	// CollectionLoadNone never loads the collection and assumes it is loaded externally
+	CollectionLoadNone CollectionLoadMode = "none"
+)
+
+// SetCollectionLoadMode configures when the collection is loaded. An empty
+// mode behaves like CollectionLoadLazy.
+func (m *MilvusClient) SetCollectionLoadMode(mode CollectionLoadMode) {
+	m.loadMode = mode
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// compressedMetadataKey marks metadata stored gzip-compressed. The metadata
+// field is a Milvus JSON field and cannot hold raw gzip bytes, so compressed
+// metadata is stored as {"_gz": "<base64 gzip of the original JSON>"}.
+const compressedMetadataKey = "_gz"
+
+// compressMetadata gzips serialized metadata into the compressed wrapper.
+// The original bytes are returned when compression would not save space.
+func compressMetadata(raw []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(raw); err != nil {
+		return nil, fmt.Errorf("failed to compress metadata: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress metadata: %w", err)
+	}
+
+	wrapped, err := json.Marshal(map[string]string{
+		compressedMetadataKey: base64.StdEncoding.EncodeToString(buf.Bytes()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode compressed metadata: %w", err)
+	}
+	if len(wrapped) >= len(raw) {
+		return raw, nil
+	}
+	return wrapped, nil
+}
+
+// decodeMetadata parses stored metadata, transparently decompressing records
+// written with metadata compression. Plain JSON records are returned as-is.
+func decodeMetadata(raw []byte) (map[string]interface{}, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(raw, &metadata); err != nil {
+		return nil, err
+	}
+
+	encoded, ok := metadata[compressedMetadataKey].(string)
+	if !ok || len(metadata) != 1 {
+		return metadata, nil
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode compressed metadata: %w", err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress metadata: %w", err)
+	}
+	defer func() { _ = gz.Close() }()
+
+	plain, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress metadata: %w", err)
+	}
+
+	metadata = nil
+	if err := json.Unmarshal(plain, &metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
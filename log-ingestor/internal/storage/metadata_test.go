@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milvus-io/milvus/client/v2/column"
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+func TestMetadataCompression_RoundTrip(t *testing.T) {
+	raw := []byte(`{"kubernetes":{"labels":"` + strings.Repeat("app=checkout,", 50) + `"},"level":"ERROR"}`)
+
+	compressed, err := compressMetadata(raw)
+	require.NoError(t, err)
+	assert.Less(t, len(compressed), len(raw))
+	assert.True(t, strings.HasPrefix(string(compressed), `{"_gz":"`))
+
+	metadata, err := decodeMetadata(compressed)
+	require.NoError(t, err)
+	assert.Equal(t, "ERROR", metadata["level"])
+	assert.Equal(t, strings.Repeat("app=checkout,", 50), metadata["kubernetes"].(map[string]interface{})["labels"])
+}
+
+func TestMetadataCompression_SmallMetadataStaysPlain(t *testing.T) {
+	raw := []byte(`{"level":"INFO"}`)
+
+	compressed, err := compressMetadata(raw)
+	require.NoError(t, err)
+	assert.Equal(t, raw, compressed)
+}
+
+func TestDecodeMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected map[string]interface{}
+		wantErr  bool
+	}{
+		{"Empty", "", nil, false},
+		{"Legacy uncompressed", `{"level":"WARN","pod":"api-1"}`, map[string]interface{}{"level": "WARN", "pod": "api-1"}, false},
+		{"Marker among other keys is plain", `{"_gz":"abc","level":"INFO"}`, map[string]interface{}{"_gz": "abc", "level": "INFO"}, false},
+		{"Invalid base64", `{"_gz":"!!!"}`, nil, true},
+		{"Invalid gzip", `{"_gz":"aGVsbG8="}`, nil, true},
+		{"Invalid JSON", `{`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata, err := decodeMetadata([]byte(tt.raw))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, metadata)
+		})
+	}
+}
+
+func TestMilvusClient_CompressMetadata_StoredAndHydrated(t *testing.T) {
+	client := NewMilvusClient("test:19530", &MockEmbeddingService{}, 2, 0.95, 3, logrus.New())
+	client.SetCompressMetadata(true)
+
+	log := &models.LogEntry{
+		Timestamp: time.Now().UnixMilli(),
+		Message:   "request failed",
+		Metadata:  map[string]interface{}{"trace": strings.Repeat("frame;", 100)},
+	}
+
+	columns, err := client.logColumns(log, []float32{0.1, 0.2})
+	require.NoError(t, err)
+
+	var stored []byte
+	for _, col := range columns {
+		if col.Name() == FieldMetadata {
+			stored = col.(*column.ColumnJSONBytes).Data()[0]
+		}
+	}
+	assert.True(t, strings.HasPrefix(string(stored), `{"_gz":"`))
+
+	// Compressed and legacy records are both readable
+	result := milvusclient.ResultSet{
+		ResultCount: 2,
+		Fields: milvusclient.DataSet{
+			column.NewColumnInt64(FieldID, []int64{1, 2}),
+			column.NewColumnInt64(FieldTimestamp, []int64{log.Timestamp, log.Timestamp}),
+			column.NewColumnVarChar(FieldMessage, []string{"request failed", "legacy"}),
+			column.NewColumnVarChar(FieldSource, []string{"", ""}),
+			column.NewColumnJSONBytes(FieldMetadata, [][]byte{stored, []byte(`{"level":"INFO"}`)}),
+			column.NewColumnInt64(FieldDuplicateCount, []int64{1, 1}),
+		},
+	}
+
+	logs, err := storedLogsFromResultSet(result)
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	assert.Equal(t, log.Metadata, logs[0].Metadata)
+	assert.Equal(t, map[string]interface{}{"level": "INFO"}, logs[1].Metadata)
+}
@@ -2,17 +2,28 @@ package storage
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/milvus-io/milvus/client/v2/column"
 	"github.com/milvus-io/milvus/client/v2/entity"
 	"github.com/milvus-io/milvus/client/v2/index"
 	"github.com/milvus-io/milvus/client/v2/milvusclient"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
+	"github.com/timberline/log-ingestor/internal/dlq"
 	"github.com/timberline/log-ingestor/internal/embedding"
 	"github.com/timberline/log-ingestor/internal/models"
+	"github.com/timberline/log-ingestor/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -31,10 +42,129 @@ const (
 	MetricType          = "COSINE"
 	IndexM              = 16
 	IndexEfConstruction = 200
+
+	// scalarFieldMaxLength is the VarChar capacity of promoted metadata fields;
+	// longer values are truncated on insert
+	scalarFieldMaxLength = 512
 )
 
+// ErrNotConnected is returned by storage operations attempted before Connect succeeds
+var ErrNotConnected = errors.New("not connected to Milvus")
+
+// ErrDimensionMismatch is returned when the collection's embedding dimension
+// differs from the configured one, so every insert would fail
+var ErrDimensionMismatch = errors.New("collection embedding dimension does not match configuration")
+
+// ErrMissingField is returned when an option needs a field the existing
+// collection was created without, since fields are only added at creation
+var ErrMissingField = errors.New("collection is missing a field required by configuration")
+
+// IsUnavailable reports whether a storage error means Milvus cannot currently
+// be reached, as opposed to a validation or internal failure. Callers use it to
+// ask clients to back off and retry later.
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrNotConnected) {
+		return true
+	}
+	errMsg := err.Error()
+	return strings.Contains(errMsg, "connection refused") ||
+		strings.Contains(errMsg, "code = Unavailable") ||
+		strings.Contains(errMsg, "ServiceNotReady")
+}
+
+// recentLogsWindows are the progressively wider time windows scanned by RecentLogs.
+// Milvus queries cannot order results, so we look back over growing windows until
+// enough rows are found and sort them in memory. Each window only scans the rows
+// older than the previous one; a zero window scans the rest of the collection.
+var recentLogsWindows = []time.Duration{time.Minute, time.Hour, 24 * time.Hour, 0}
+
+// recentLogsScanLimit bounds how many rows a single recent-logs query may return.
+// A query hitting it returns an arbitrary subset, so its range is split instead.
+var recentLogsScanLimit = 16384
+
+// milvusAPI is the subset of the Milvus SDK used by MilvusClient. It exists as
+// a seam so tests can substitute a fake for the real gRPC client.
+type milvusAPI interface {
+	HasCollection(ctx context.Context, option milvusclient.HasCollectionOption) (bool, error)
+	CreateCollection(ctx context.Context, option milvusclient.CreateCollectionOption) error
+	DropCollection(ctx context.Context, option milvusclient.DropCollectionOption) error
+	DescribeCollection(ctx context.Context, option milvusclient.DescribeCollectionOption) (*entity.Collection, error)
+	CreateIndex(ctx context.Context, option milvusclient.CreateIndexOption) (milvusTask, error)
+	LoadCollection(ctx context.Context, option milvusclient.LoadCollectionOption) (milvusTask, error)
+	Search(ctx context.Context, option milvusclient.SearchOption) ([]milvusclient.ResultSet, error)
+	Query(ctx context.Context, option milvusclient.QueryOption) (milvusclient.ResultSet, error)
+	Insert(ctx context.Context, option milvusclient.InsertOption) (milvusclient.InsertResult, error)
+	Upsert(ctx context.Context, option milvusclient.UpsertOption) (milvusclient.UpsertResult, error)
+	Close(ctx context.Context) error
+}
+
+// milvusTask is an asynchronous Milvus operation that can be awaited
+type milvusTask interface {
+	Await(ctx context.Context) error
+}
+
+// milvusSDK adapts *milvusclient.Client to the milvusAPI seam
+type milvusSDK struct {
+	client *milvusclient.Client
+}
+
+func (s *milvusSDK) HasCollection(ctx context.Context, option milvusclient.HasCollectionOption) (bool, error) {
+	return s.client.HasCollection(ctx, option)
+}
+
+func (s *milvusSDK) CreateCollection(ctx context.Context, option milvusclient.CreateCollectionOption) error {
+	return s.client.CreateCollection(ctx, option)
+}
+
+func (s *milvusSDK) DropCollection(ctx context.Context, option milvusclient.DropCollectionOption) error {
+	return s.client.DropCollection(ctx, option)
+}
+
+func (s *milvusSDK) DescribeCollection(ctx context.Context, option milvusclient.DescribeCollectionOption) (*entity.Collection, error) {
+	return s.client.DescribeCollection(ctx, option)
+}
+
+func (s *milvusSDK) CreateIndex(ctx context.Context, option milvusclient.CreateIndexOption) (milvusTask, error) {
+	task, err := s.client.CreateIndex(ctx, option)
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (s *milvusSDK) LoadCollection(ctx context.Context, option milvusclient.LoadCollectionOption) (milvusTask, error) {
+	task, err := s.client.LoadCollection(ctx, option)
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (s *milvusSDK) Search(ctx context.Context, option milvusclient.SearchOption) ([]milvusclient.ResultSet, error) {
+	return s.client.Search(ctx, option)
+}
+
+func (s *milvusSDK) Query(ctx context.Context, option milvusclient.QueryOption) (milvusclient.ResultSet, error) {
+	return s.client.Query(ctx, option)
+}
+
+func (s *milvusSDK) Insert(ctx context.Context, option milvusclient.InsertOption) (milvusclient.InsertResult, error) {
+	return s.client.Insert(ctx, option)
+}
+
+func (s *milvusSDK) Upsert(ctx context.Context, option milvusclient.UpsertOption) (milvusclient.UpsertResult, error) {
+	return s.client.Upsert(ctx, option)
+}
+
+func (s *milvusSDK) Close(ctx context.Context) error {
+	return s.client.Close(ctx)
+}
+
 type MilvusClient struct {
-	client                     *milvusclient.Client
+	client                     milvusAPI
 	collection                 string
 	embeddingDim               int
 	embeddingService           embedding.Interface
@@ -42,22 +172,90 @@ type MilvusClient struct {
 	connected                  bool
 	similarityThreshold        float32
 	minExamplesBeforeExclusion int
+	noEmbedSources             map[string]struct{}
+	scalarFields               []string
+	timestampBounds            models.TimestampBounds
+	embedIncludeSource         bool
+	compressMetadata           bool
+	idempotencyKeys            bool
+	storeContentHash           bool
+	storeTemplates             bool
+	recordSimilarCount         bool
+	timestampBucket            time.Duration
+	embedTemplates             bool
+	loadMode                   CollectionLoadMode
+	shardNum                   int32
+	searchEf                   int
+
+	// searchGuard bypasses the dedup search while Milvus is slow; nil disables it
+	searchGuard   *latencyGuard
+	dedupBypassed prometheus.Counter
+
+	// dedupTopScore records the best dedup search score per stored log, for
+	// tuning the similarity threshold
+	dedupTopScore prometheus.Histogram
+
+	// loadGate holds dedup searches after startup until the collection is loaded; nil disables it
+	loadGate *loadGate
+
+	// healthCache reuses recent HealthCheck results; nil disables it
+	healthCache *healthCache
+
+	// coldSink receives logs excluded as duplicates; nil disables it
+	coldSink dlq.Sink
+
+	// lastStore holds the Unix time of the last successful insert or upsert
+	lastStore prometheus.Gauge
+
+	// sourceThresholds overrides similarityThreshold for logs from specific sources
+	sourceThresholds map[string]float32
+
+	// dedupMaxMatchAge stops counting duplicates against matches older than this when > 0
+	dedupMaxMatchAge time.Duration
+
+	// dedupWindow restricts dedup searches to logs newer than now minus the window when > 0
+	dedupWindow time.Duration
+
+	// exactDuplicates short-circuits identical repeats of excluded messages; nil disables it
+	exactDuplicates *exactDuplicateCache
+
+	// mergedResends remembers entries counted as duplicates, by resend identity,
+	// so client resends of them are not counted again
+	mergedResends *exactDuplicateCache
+
+	// duplicateFlushInterval enables batching duplicate-count updates when > 0
+	duplicateFlushInterval time.Duration
+	duplicatesMu           sync.Mutex
+	pendingDuplicates      map[int64]int64
 }
 
 // SearchResult represents a search result with ID and similarity score
 type SearchResult struct {
-	ID    int64   // Log entry ID
-	Score float32 // Similarity score
+	ID        int64   // Log entry ID
+	Score     float32 // Similarity score
+	Timestamp int64   // Log entry timestamp in Unix milliseconds, 0 if unknown
 }
 
 type StorageInterface interface {
 	Connect(ctx context.Context) error
 	Close() error
 	StoreLog(ctx context.Context, log *models.LogEntry) error
+	StoreBatch(ctx context.Context, logs []*models.LogEntry) error
 	HealthCheck(ctx context.Context) error
 	CreateCollection(ctx context.Context) error
 }
 
+// AdminInterface provides destructive maintenance operations on stored logs
+type AdminInterface interface {
+	ResetCollection(ctx context.Context) error
+}
+
+// QueryInterface provides read access to stored logs
+type QueryInterface interface {
+	RecentLogs(ctx context.Context, limit int, source string) ([]*models.StoredLog, error)
+	SearchLogs(ctx context.Context, query string, limit, ef int, filters map[string]interface{}) ([]*models.SearchMatch, error)
+}
+
 func NewMilvusClient(address string, embeddingService embedding.Interface, embeddingDim int, similarityThreshold float32, minExamplesBeforeExclusion int, logger *logrus.Logger) *MilvusClient {
 	return &MilvusClient{
 		collection:                 "timberline_logs",
@@ -67,9 +265,193 @@ func NewMilvusClient(address string, embeddingService embedding.Interface, embed
 		connected:                  false,
 		similarityThreshold:        similarityThreshold,
 		minExamplesBeforeExclusion: minExamplesBeforeExclusion,
+		shardNum:                   DefaultShards,
+		mergedResends:              newExactDuplicateCache(mergedResendCacheSize),
+		lastStore: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "log_ingestor_last_store_timestamp_seconds",
+			Help: "Unix time of the last successful write to Milvus",
+		}),
+		dedupBypassed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "log_ingestor_dedup_bypassed_total",
+			Help: "Total number of logs stored without a dedup search because search latency was too high",
+		}),
+		dedupTopScore: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "log_ingestor_dedup_top_score",
+			Help:    "Similarity score of the closest match found by each dedup search, for tuning SIMILARITY_THRESHOLD",
+			Buckets: []float64{0.5, 0.6, 0.7, 0.8, 0.85, 0.9, 0.92, 0.94, 0.95, 0.96, 0.97, 0.98, 0.99, 0.995, 1},
+		}),
 	}
 }
 
+// RegisterMetrics registers the client's metrics with registerer,
+// ignoring duplicate registration errors
+func (m *MilvusClient) RegisterMetrics(registerer prometheus.Registerer) {
+	_ = registerer.Register(m.lastStore)
+	_ = registerer.Register(m.dedupBypassed)
+	_ = registerer.Register(m.dedupTopScore)
+}
+
+// SetShardNum sets the number of shards used when creating the collection.
+// More shards allow more parallel writes; existing collections are unaffected.
+func (m *MilvusClient) SetShardNum(shards int32) {
+	m.shardNum = shards
+}
+
+// SetNoEmbedSources configures sources whose logs are stored without computing
+// embeddings or running deduplication (metadata-only fast path)
+func (m *MilvusClient) SetNoEmbedSources(sources []string) {
+	m.noEmbedSources = make(map[string]struct{}, len(sources))
+	for _, source := range sources {
+		m.noEmbedSources[source] = struct{}{}
+	}
+}
+
+// SetEmbedIncludeSource makes the embedding input "source: message" so identical
+// messages from different sources are not treated as duplicates. The stored
+// message is unchanged.
+func (m *MilvusClient) SetEmbedIncludeSource(include bool) {
+	m.embedIncludeSource = include
+}
+
+// embeddingText returns the text sent to the embedding service for a log
+func (m *MilvusClient) embeddingText(log *models.LogEntry) string {
+	message := log.Message
+	if m.embedTemplates {
+		message = models.Templatize(message)
+	}
+	if m.embedIncludeSource && log.Source != "" {
+		return log.Source + ": " + message
+	}
+	return message
+}
+
+// SetRecordSimilarCount stores how many similar logs the dedup search found in
+// metadata._similar_count of entries that are stored anyway, for debugging
+// relevance. Entries stored without a dedup search get no count.
+func (m *MilvusClient) SetRecordSimilarCount(enabled bool) {
+	m.recordSimilarCount = enabled
+}
+
+// SetCompressMetadata enables gzip compression of the metadata stored with
+// each log. Records are decompressed transparently on read, and records
+// written without compression remain readable.
+func (m *MilvusClient) SetCompressMetadata(enabled bool) {
+	m.compressMetadata = enabled
+}
+
+// SetSourceSimilarityThresholds overrides the global similarity threshold for
+// logs from the given sources. A threshold of 0 disables deduplication for that source.
+func (m *MilvusClient) SetSourceSimilarityThresholds(thresholds map[string]float32) {
+	m.sourceThresholds = make(map[string]float32, len(thresholds))
+	for source, threshold := range thresholds {
+		m.sourceThresholds[source] = threshold
+	}
+}
+
+// similarityThresholdFor returns the similarity threshold that applies to logs from source
+func (m *MilvusClient) similarityThresholdFor(source string) float32 {
+	if threshold, ok := m.sourceThresholds[source]; ok {
+		return threshold
+	}
+	return m.similarityThreshold
+}
+
+// SetDedupMaxMatchAge stops deduplicating against matched logs whose timestamp
+// is more than maxAge older than the incoming log; such logs are stored as new
+// entries instead of inflating an ancient log's duplicate count. Zero disables the limit.
+func (m *MilvusClient) SetDedupMaxMatchAge(maxAge time.Duration) {
+	m.dedupMaxMatchAge = maxAge
+}
+
+// SetDedupWindow restricts the dedup search to logs whose timestamp is within
+// window of the current time, so a message recurring after a longer quiet
+// period is stored as a new entry instead of counting against its old
+// occurrence. Zero searches all stored logs.
+func (m *MilvusClient) SetDedupWindow(window time.Duration) {
+	m.dedupWindow = window
+}
+
+// dedupFilter returns the search filter limiting dedup candidates to the window
+func (m *MilvusClient) dedupFilter() string {
+	if m.dedupWindow <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s > %d", FieldTimestamp, time.Now().Add(-m.dedupWindow).UnixMilli())
+}
+
+// topScore returns the highest score among results, which must not be empty
+func topScore(results []SearchResult) float32 {
+	top := results[0].Score
+	for _, result := range results[1:] {
+		top = max(top, result.Score)
+	}
+	return top
+}
+
+// matchTooOld reports whether match is too old to count log as its duplicate
+func (m *MilvusClient) matchTooOld(log *models.LogEntry, match SearchResult) bool {
+	if match.Timestamp == 0 {
+		return false
+	}
+	if m.dedupWindow > 0 && match.Timestamp <= time.Now().Add(-m.dedupWindow).UnixMilli() {
+		return true
+	}
+	return m.dedupMaxMatchAge > 0 && log.Timestamp-match.Timestamp > m.dedupMaxMatchAge.Milliseconds()
+}
+
+// SetExactDuplicateCacheSize enables an LRU of the given size remembering
+// messages already excluded as duplicates. Identical repeats of those messages
+// are counted directly, skipping the embedding call and similarity search.
+// Zero disables the cache.
+func (m *MilvusClient) SetExactDuplicateCacheSize(size int) {
+	if size <= 0 {
+		m.exactDuplicates = nil
+		return
+	}
+	m.exactDuplicates = newExactDuplicateCache(size)
+}
+
+// SetTimestampBounds configures how far log timestamps may deviate from now
+func (m *MilvusClient) SetTimestampBounds(bounds models.TimestampBounds) {
+	m.timestampBounds = bounds
+}
+
+// SetScalarFields configures metadata keys that are promoted to dedicated
+// VarChar fields in the collection schema so they can be filtered efficiently.
+// Keys that are not valid field names or clash with built-in fields are ignored.
+// The schema is fixed at creation time, so changing the list requires
+// recreating the collection.
+func (m *MilvusClient) SetScalarFields(keys []string) {
+	m.scalarFields = nil
+	seen := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		if !isValidScalarFieldName(key) {
+			m.logger.WithField("key", key).Warn("Ignoring metadata key that cannot be used as a scalar field")
+			continue
+		}
+		seen[key] = struct{}{}
+		m.scalarFields = append(m.scalarFields, key)
+	}
+}
+
+// isValidScalarFieldName reports whether key can name a promoted metadata field
+func isValidScalarFieldName(key string) bool {
+	switch key {
+	case "", FieldID, FieldTimestamp, FieldMessage, FieldSource, FieldMetadata, FieldEmbedding, FieldDuplicateCount, FieldIdempotencyKey, FieldTemplate, FieldBucketTimestamp, FieldContentHash:
+		return false
+	}
+	for i, r := range key {
+		isLetter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_'
+		if !isLetter && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
 func (m *MilvusClient) Connect(ctx context.Context) error {
 	m.logger.Info("Connecting to Milvus")
 
@@ -80,7 +462,7 @@ func (m *MilvusClient) Connect(ctx context.Context) error {
 		return fmt.Errorf("failed to create Milvus client: %w", err)
 	}
 
-	m.client = c
+	m.client = &milvusSDK{client: c}
 	m.connected = true
 
 	m.logger.Info("Successfully connected to Milvus")
@@ -92,6 +474,9 @@ func (m *MilvusClient) Close() error {
 	if m.client != nil {
 		err := m.client.Close(context.Background())
 		m.connected = false
+		if m.healthCache != nil {
+			m.healthCache.invalidate()
+		}
 		return err
 	}
 	return nil
@@ -101,7 +486,7 @@ func (m *MilvusClient) CreateCollection(ctx context.Context) error {
 	m.logger.WithField("collection", m.collection).Info("Creating Milvus collection")
 
 	if !m.connected {
-		return fmt.Errorf("not connected to Milvus")
+		return ErrNotConnected
 	}
 
 	// Check if collection already exists
@@ -115,7 +500,52 @@ func (m *MilvusClient) CreateCollection(ctx context.Context) error {
 		return nil
 	}
 
-	// Define collection schema
+	// Create collection
+	err = m.client.CreateCollection(ctx, milvusclient.NewCreateCollectionOption(m.collection, m.collectionSchema()).
+		WithShardNum(m.shardNum))
+	if err != nil {
+		return fmt.Errorf("failed to create collection: %w", err)
+	}
+
+	m.logger.WithField("collection", m.collection).Info("Collection created successfully")
+
+	// Create index on embedding field for vector search
+	if err := m.createEmbeddingIndex(ctx); err != nil {
+		m.logger.WithError(err).Warn("Failed to create embedding index, search performance may be affected")
+	}
+
+	// Create inverted indexes on promoted metadata fields for filtering
+	for _, key := range m.scalarFields {
+		if err := m.createScalarIndex(ctx, key); err != nil {
+			m.logger.WithError(err).WithField("field", key).Warn("Failed to create scalar index, filtering may be slower")
+		}
+	}
+	if m.idempotencyKeys {
+		if err := m.createScalarIndex(ctx, FieldIdempotencyKey); err != nil {
+			m.logger.WithError(err).Warn("Failed to create idempotency key index, resend checks may be slower")
+		}
+	}
+	if m.storeTemplates {
+		if err := m.createScalarIndex(ctx, FieldTemplate); err != nil {
+			m.logger.WithError(err).Warn("Failed to create template index, filtering may be slower")
+		}
+	}
+	if m.timestampBucket > 0 {
+		if err := m.createScalarIndex(ctx, FieldBucketTimestamp); err != nil {
+			m.logger.WithError(err).Warn("Failed to create timestamp bucket index, grouping may be slower")
+		}
+	}
+	if m.storeContentHash {
+		if err := m.createScalarIndex(ctx, FieldContentHash); err != nil {
+			m.logger.WithError(err).Warn("Failed to create content hash index, lookups may be slower")
+		}
+	}
+
+	return nil
+}
+
+// collectionSchema defines the log collection, including any promoted metadata fields
+func (m *MilvusClient) collectionSchema() *entity.Schema {
 	schema := &entity.Schema{
 		CollectionName: m.collection,
 		Description:    "Timberline log entries with embeddings for semantic search",
@@ -162,19 +592,175 @@ func (m *MilvusClient) CreateCollection(ctx context.Context) error {
 		},
 	}
 
-	// Create collection
-	err = m.client.CreateCollection(ctx, milvusclient.NewCreateCollectionOption(m.collection, schema))
+	for _, key := range m.scalarFields {
+		schema.Fields = append(schema.Fields, &entity.Field{
+			Name:     key,
+			DataType: entity.FieldTypeVarChar,
+			TypeParams: map[string]string{
+				"max_length": strconv.Itoa(scalarFieldMaxLength),
+			},
+		})
+	}
+	if m.idempotencyKeys {
+		schema.Fields = append(schema.Fields, &entity.Field{
+			Name:     FieldIdempotencyKey,
+			DataType: entity.FieldTypeVarChar,
+			TypeParams: map[string]string{
+				"max_length": strconv.Itoa(idempotencyKeyMaxLength),
+			},
+		})
+	}
+	if m.storeTemplates {
+		schema.Fields = append(schema.Fields, &entity.Field{
+			Name:     FieldTemplate,
+			DataType: entity.FieldTypeVarChar,
+			TypeParams: map[string]string{
+				"max_length": strconv.Itoa(templateMaxLength),
+			},
+		})
+	}
+	if m.timestampBucket > 0 {
+		schema.Fields = append(schema.Fields, &entity.Field{
+			Name:     FieldBucketTimestamp,
+			DataType: entity.FieldTypeInt64,
+		})
+	}
+	if m.storeContentHash {
+		schema.Fields = append(schema.Fields, &entity.Field{
+			Name:     FieldContentHash,
+			DataType: entity.FieldTypeVarChar,
+			TypeParams: map[string]string{
+				"max_length": strconv.Itoa(contentHashLength),
+			},
+		})
+	}
+
+	return schema
+}
+
+// DropCollection permanently deletes the log collection and all stored logs
+func (m *MilvusClient) DropCollection(ctx context.Context) error {
+	m.logger.WithField("collection", m.collection).Warn("Dropping Milvus collection")
+
+	if !m.connected {
+		return ErrNotConnected
+	}
+
+	if err := m.client.DropCollection(ctx, milvusclient.NewDropCollectionOption(m.collection)); err != nil {
+		return fmt.Errorf("failed to drop collection: %w", err)
+	}
+
+	return nil
+}
+
+// ResetCollection drops the collection and recreates it empty and ready for
+// use, loading it unless the load mode is CollectionLoadNone
+func (m *MilvusClient) ResetCollection(ctx context.Context) error {
+	if err := m.DropCollection(ctx); err != nil {
+		return err
+	}
+	if err := m.CreateCollection(ctx); err != nil {
+		return fmt.Errorf("failed to recreate collection: %w", err)
+	}
+	if m.loadMode != CollectionLoadNone {
+		if err := m.loadAndAwait(ctx); err != nil {
+			return fmt.Errorf("failed to load recreated collection: %w", err)
+		}
+	}
+
+	if m.exactDuplicates != nil {
+		m.exactDuplicates.reset()
+	}
+	m.mergedResends.reset()
+
+	m.logger.WithField("collection", m.collection).Info("Collection reset successfully")
+	return nil
+}
+
+// VerifySchema checks that the existing collection's embedding field has the
+// configured dimension. On a mismatch it returns ErrDimensionMismatch, or with
+// autoRecreate drops and recreates the collection, deleting all logs. It then
+// returns ErrMissingField if an enabled option needs a field the collection
+// lacks.
+func (m *MilvusClient) VerifySchema(ctx context.Context, autoRecreate bool) error {
+	if !m.connected {
+		return ErrNotConnected
+	}
+
+	collection, err := m.client.DescribeCollection(ctx, milvusclient.NewDescribeCollectionOption(m.collection))
 	if err != nil {
-		return fmt.Errorf("failed to create collection: %w", err)
+		return fmt.Errorf("failed to describe collection: %w", err)
 	}
 
-	m.logger.WithField("collection", m.collection).Info("Collection created successfully")
+	var dim int64
+	if collection.Schema != nil {
+		for _, field := range collection.Schema.Fields {
+			if field.Name != FieldEmbedding {
+				continue
+			}
+			if dim, err = field.GetDim(); err != nil {
+				return fmt.Errorf("failed to read embedding dimension: %w", err)
+			}
+		}
+	}
+	if dim == int64(m.embeddingDim) {
+		return m.verifyOptionalFields(collection.Schema)
+	}
 
-	// Create index on embedding field for vector search
-	if err := m.createEmbeddingIndex(ctx); err != nil {
-		m.logger.WithError(err).Warn("Failed to create embedding index, search performance may be affected")
+	if !autoRecreate {
+		return fmt.Errorf("%w: collection %s has dimension %d, EMBEDDING_DIMENSION is %d",
+			ErrDimensionMismatch, m.collection, dim, m.embeddingDim)
 	}
 
+	m.logger.WithFields(logrus.Fields{
+		"collection":     m.collection,
+		"collection_dim": dim,
+		"configured_dim": m.embeddingDim,
+	}).Warn("Embedding dimension mismatch, recreating collection")
+	return m.ResetCollection(ctx)
+}
+
+// optionalField is a schema field that only exists when an option enables it
+type optionalField struct {
+	name   string
+	option string
+}
+
+// optionalFields lists the fields collectionSchema adds for the enabled options
+func (m *MilvusClient) optionalFields() []optionalField {
+	var fields []optionalField
+	for _, key := range m.scalarFields {
+		fields = append(fields, optionalField{key, "METADATA_SCALAR_FIELDS, COMPONENT_FIELDS or ENV_SCALAR_FIELD"})
+	}
+	if m.idempotencyKeys {
+		fields = append(fields, optionalField{FieldIdempotencyKey, "IDEMPOTENCY_KEYS"})
+	}
+	if m.storeTemplates {
+		fields = append(fields, optionalField{FieldTemplate, "STORE_MESSAGE_TEMPLATE"})
+	}
+	if m.timestampBucket > 0 {
+		fields = append(fields, optionalField{FieldBucketTimestamp, "TIMESTAMP_BUCKET"})
+	}
+	if m.storeContentHash {
+		fields = append(fields, optionalField{FieldContentHash, "STORE_CONTENT_HASH"})
+	}
+	return fields
+}
+
+// verifyOptionalFields reports the first enabled optional field missing from schema
+func (m *MilvusClient) verifyOptionalFields(schema *entity.Schema) error {
+	existing := make(map[string]struct{})
+	if schema != nil {
+		for _, field := range schema.Fields {
+			existing[field.Name] = struct{}{}
+		}
+	}
+	for _, field := range m.optionalFields() {
+		if _, ok := existing[field.name]; !ok {
+			return fmt.Errorf("%w: collection %s has no %q field, needed by %s; recreate the collection or disable the option",
+				ErrMissingField, m.collection, field.name, field.option)
+		}
+	}
 	return nil
 }
 
@@ -201,45 +787,30 @@ func (m *MilvusClient) createEmbeddingIndex(ctx context.Context) error {
 	return nil
 }
 
+// createScalarIndex creates an inverted index on a promoted metadata field
+func (m *MilvusClient) createScalarIndex(ctx context.Context, field string) error {
+	indexTask, err := m.client.CreateIndex(ctx,
+		milvusclient.NewCreateIndexOption(m.collection, field, index.NewInvertedIndex()))
+	if err != nil {
+		return fmt.Errorf("failed to create index task: %w", err)
+	}
+
+	if err := indexTask.Await(ctx); err != nil {
+		return fmt.Errorf("index creation task failed: %w", err)
+	}
+
+	return nil
+}
+
 // SearchSimilarLogs searches for logs similar to the given embedding
 func (m *MilvusClient) SearchSimilarLogs(ctx context.Context, embedding []float32, topK int) ([]SearchResult, error) {
 	if !m.connected {
-		return nil, fmt.Errorf("not connected to Milvus")
+		return nil, ErrNotConnected
 	}
 
-	// Create search option with the new client API
-	searchOption := milvusclient.NewSearchOption(
-		m.collection,
-		topK,
-		[]entity.Vector{entity.FloatVector(embedding)},
-	).WithOutputFields(FieldID)
-
-	// Perform search
-	results, err := m.client.Search(ctx, searchOption)
+	results, err := m.search(ctx, m.searchOption(embedding, topK, m.searchEf, m.dedupFilter(), FieldID, FieldTimestamp))
 	if err != nil {
-		// Check if error is due to collection not being loaded
-		errMsg := err.Error()
-		if strings.Contains(errMsg, "collection not loaded") || strings.Contains(errMsg, "CollectionNotLoaded") {
-			m.logger.WithField("collection", m.collection).Info("Collection not loaded, loading now")
-
-			// Try to load the collection
-			loadTask, loadErr := m.client.LoadCollection(ctx, milvusclient.NewLoadCollectionOption(m.collection))
-			if loadErr != nil {
-				return nil, fmt.Errorf("failed to load collection: %w", loadErr)
-			}
-			loadErr = loadTask.Await(ctx)
-			if loadErr != nil {
-				return nil, fmt.Errorf("collection load task failed: %w", loadErr)
-			}
-
-			// Retry the search
-			results, err = m.client.Search(ctx, searchOption)
-			if err != nil {
-				return nil, fmt.Errorf("failed to search similar logs after loading collection: %w", err)
-			}
-		} else {
-			return nil, fmt.Errorf("failed to search similar logs: %w", err)
-		}
+		return nil, err
 	}
 
 	if len(results) == 0 {
@@ -260,20 +831,244 @@ func (m *MilvusClient) SearchSimilarLogs(ctx context.Context, embedding []float3
 	scores := result.Scores
 	searchResults := make([]SearchResult, len(idData))
 
+	// Timestamps are informational, so tolerate their absence
+	var timestamps []int64
+	if timestampCol, ok := result.GetColumn(FieldTimestamp).(*column.ColumnInt64); ok {
+		timestamps = timestampCol.Data()
+	}
+
 	for i := range searchResults {
 		searchResults[i] = SearchResult{
 			ID:    idData[i],
 			Score: scores[i],
 		}
+		if i < len(timestamps) {
+			searchResults[i].Timestamp = timestamps[i]
+		}
 	}
 
 	return searchResults, nil
 }
 
+// search runs a search, loading the collection or recreating it when it is
+// not loaded or missing and retrying once
+func (m *MilvusClient) search(ctx context.Context, searchOption milvusclient.SearchOption) ([]milvusclient.ResultSet, error) {
+	results, err := m.client.Search(ctx, searchOption)
+	if err != nil {
+		switch {
+		case isCollectionNotLoaded(err) && m.loadMode == CollectionLoadNone:
+			return nil, fmt.Errorf("failed to search similar logs: collection is not loaded and loading on demand is disabled: %w", err)
+		case isCollectionNotLoaded(err):
+			// Collection exists but is not loaded into memory yet
+			m.logger.WithField("collection", m.collection).Info("Collection not loaded, loading now")
+
+			if loadErr := m.loadAndAwait(ctx); loadErr != nil {
+				return nil, loadErr
+			}
+
+			// Retry the search
+			results, err = m.client.Search(ctx, searchOption)
+			if err != nil {
+				return nil, fmt.Errorf("failed to search similar logs after loading collection: %w", err)
+			}
+		case isCollectionNotFound(err):
+			// Collection is gone (e.g. Milvus was wiped), recreate it and retry once
+			m.logger.WithField("collection", m.collection).Warn("Collection not found, recreating it")
+
+			if createErr := m.CreateCollection(ctx); createErr != nil {
+				return nil, fmt.Errorf("failed to recreate missing collection: %w", createErr)
+			}
+			if m.loadMode == CollectionLoadNone {
+				return nil, errors.New("failed to search similar logs: collection was recreated but loading on demand is disabled")
+			}
+			if loadErr := m.loadAndAwait(ctx); loadErr != nil {
+				return nil, loadErr
+			}
+
+			// Retry the search
+			results, err = m.client.Search(ctx, searchOption)
+			if err != nil {
+				return nil, fmt.Errorf("failed to search similar logs after recreating collection: %w", err)
+			}
+		default:
+			return nil, fmt.Errorf("failed to search similar logs: %w", err)
+		}
+	}
+
+	return results, nil
+}
+
+// loadAndAwait loads the collection into memory and waits for the load to finish
+func (m *MilvusClient) loadAndAwait(ctx context.Context) error {
+	loadTask, err := m.client.LoadCollection(ctx, milvusclient.NewLoadCollectionOption(m.collection))
+	if err != nil {
+		return fmt.Errorf("failed to load collection: %w", err)
+	}
+	if err := loadTask.Await(ctx); err != nil {
+		return fmt.Errorf("collection load task failed: %w", err)
+	}
+	return nil
+}
+
+// isCollectionNotLoaded reports whether a Milvus error indicates the collection is not loaded
+func isCollectionNotLoaded(err error) bool {
+	errMsg := err.Error()
+	return strings.Contains(errMsg, "collection not loaded") || strings.Contains(errMsg, "CollectionNotLoaded")
+}
+
+// isCollectionNotFound reports whether a Milvus error indicates the collection does not exist
+func isCollectionNotFound(err error) bool {
+	errMsg := err.Error()
+	return strings.Contains(errMsg, "collection not found") ||
+		strings.Contains(errMsg, "CollectionNotExists") ||
+		strings.Contains(errMsg, "can't find collection")
+}
+
+// RecentLogs returns the newest logs ordered by timestamp descending, optionally filtered by source
+func (m *MilvusClient) RecentLogs(ctx context.Context, limit int, source string) ([]*models.StoredLog, error) {
+	if !m.connected {
+		return nil, ErrNotConnected
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be greater than 0")
+	}
+
+	now := time.Now()
+	logs := []*models.StoredLog{}
+	upper := int64(math.MaxInt64)
+	for _, window := range recentLogsWindows {
+		var lower int64
+		if window > 0 {
+			lower = now.Add(-window).UnixMilli()
+		}
+		if lower >= upper {
+			continue
+		}
+
+		older, err := m.recentLogsInRange(ctx, lower, upper, now.UnixMilli(), limit-len(logs), source)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, older...)
+		if len(logs) >= limit {
+			break
+		}
+		upper = lower
+	}
+
+	return logs, nil
+}
+
+// recentLogsInRange returns the newest limit logs with lower <= timestamp < upper,
+// newest first. A range holding more rows than recentLogsScanLimit is split in
+// two, at now while it extends into the future, and the newer half searched first.
+func (m *MilvusClient) recentLogsInRange(ctx context.Context, lower, upper, now int64, limit int, source string) ([]*models.StoredLog, error) {
+	conditions := []string{fmt.Sprintf("%s >= %d", FieldTimestamp, lower)}
+	if upper < math.MaxInt64 {
+		conditions = append(conditions, fmt.Sprintf("%s < %d", FieldTimestamp, upper))
+	}
+	if source != "" {
+		conditions = append(conditions, fmt.Sprintf("%s == %s", FieldSource, strconv.Quote(source)))
+	}
+
+	queryOption := milvusclient.NewQueryOption(m.collection).
+		WithFilter(strings.Join(conditions, " && ")).
+		WithLimit(recentLogsScanLimit).
+		WithOutputFields(FieldID, FieldTimestamp, FieldMessage, FieldSource, FieldMetadata, FieldDuplicateCount)
+
+	result, err := m.client.Query(ctx, queryOption)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recent logs: %w", err)
+	}
+	logs, err := storedLogsFromResultSet(result)
+	if err != nil {
+		return nil, err
+	}
+
+	// The result was capped, so it may miss the newest rows; narrow the range
+	if len(logs) >= recentLogsScanLimit && upper-lower > 1 {
+		mid := lower + (upper-lower)/2
+		if lower < now && now < mid {
+			mid = now
+		}
+		newer, err := m.recentLogsInRange(ctx, mid, upper, now, limit, source)
+		if err != nil {
+			return nil, err
+		}
+		if len(newer) >= limit {
+			return newer, nil
+		}
+		older, err := m.recentLogsInRange(ctx, lower, mid, now, limit-len(newer), source)
+		if err != nil {
+			return nil, err
+		}
+		return append(newer, older...), nil
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp > logs[j].Timestamp
+	})
+	if len(logs) > limit {
+		logs = logs[:limit]
+	}
+	return logs, nil
+}
+
+// storedLogsFromResultSet converts query result columns into stored log records
+func storedLogsFromResultSet(result milvusclient.ResultSet) ([]*models.StoredLog, error) {
+	if result.ResultCount == 0 {
+		return []*models.StoredLog{}, nil
+	}
+
+	idCol, ok := result.GetColumn(FieldID).(*column.ColumnInt64)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract ID column")
+	}
+	timestampCol, ok := result.GetColumn(FieldTimestamp).(*column.ColumnInt64)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract timestamp column")
+	}
+	messageCol, ok := result.GetColumn(FieldMessage).(*column.ColumnVarChar)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract message column")
+	}
+	sourceCol, ok := result.GetColumn(FieldSource).(*column.ColumnVarChar)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract source column")
+	}
+	metadataCol, ok := result.GetColumn(FieldMetadata).(*column.ColumnJSONBytes)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract metadata column")
+	}
+	duplicateCountCol, ok := result.GetColumn(FieldDuplicateCount).(*column.ColumnInt64)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract duplicate count column")
+	}
+
+	logs := make([]*models.StoredLog, idCol.Len())
+	for i := range logs {
+		metadata, err := decodeMetadata(metadataCol.Data()[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode metadata for log %d: %w", idCol.Data()[i], err)
+		}
+
+		logs[i] = &models.StoredLog{
+			ID:             idCol.Data()[i],
+			Timestamp:      timestampCol.Data()[i],
+			Message:        messageCol.Data()[i],
+			Source:         sourceCol.Data()[i],
+			Metadata:       metadata,
+			DuplicateCount: duplicateCountCol.Data()[i],
+		}
+	}
+
+	return logs, nil
+}
+
 // UpdateDuplicateCount increments the duplicate count for a specific log entry
 func (m *MilvusClient) UpdateDuplicateCount(ctx context.Context, logID int64) error {
 	if !m.connected {
-		return fmt.Errorf("not connected to Milvus")
+		return ErrNotConnected
 	}
 
 	m.logger.WithField("log_id", logID).Debug("Updating duplicate count for log entry")
@@ -315,6 +1110,7 @@ func (m *MilvusClient) UpdateDuplicateCount(ctx context.Context, logID int64) er
 	if err != nil {
 		return fmt.Errorf("failed to update log entry: %w", err)
 	}
+	m.lastStore.SetToCurrentTime()
 
 	m.logger.WithFields(logrus.Fields{
 		"log_id":       logID,
@@ -328,42 +1124,135 @@ func (m *MilvusClient) UpdateDuplicateCount(ctx context.Context, logID int64) er
 }
 
 func (m *MilvusClient) StoreLog(ctx context.Context, log *models.LogEntry) error {
+	err := m.storeLog(ctx, log, true)
+	m.invalidateHealthOnUnavailable(err)
+	return err
+}
+
+// storeLog validates and stores a single log. checkIdempotency is false when
+// the caller has already checked the entry's idempotency key.
+func (m *MilvusClient) storeLog(ctx context.Context, log *models.LogEntry, checkIdempotency bool) (err error) {
 	if log == nil {
 		return fmt.Errorf("log cannot be nil")
 	}
 
-	if err := log.Validate(); err != nil {
+	// Store within the trace of the request that received the entry
+	if log.SpanContext.IsValid() {
+		ctx = trace.ContextWithSpanContext(ctx, log.SpanContext)
+	}
+	ctx, span := tracing.Tracer().Start(ctx, "storeLog")
+	defer func() { tracing.End(span, err) }()
+
+	if err := log.ValidateWithBounds(m.timestampBounds); err != nil {
 		return fmt.Errorf("log validation failed: %w", err)
 	}
 
 	if !m.connected {
-		return fmt.Errorf("not connected to Milvus")
+		return ErrNotConnected
+	}
+
+	// Skip entries a client resends after they were already counted as duplicates
+	normalizeIdempotencyKey(log)
+	if m.isMergedResend(log) {
+		m.logger.WithField("message", log.Message).Debug("Skipping resent log entry already counted as duplicate")
+		return nil
+	}
+
+	// Skip entries a client resends after they were already stored
+	if m.idempotencyKeys && checkIdempotency {
+		stored, err := m.storedIdempotencyKeys(ctx, []*models.LogEntry{log})
+		if err != nil {
+			return err
+		}
+		if _, ok := stored[log.IdempotencyKey]; ok {
+			m.logger.WithField("idempotency_key", log.IdempotencyKey).Debug("Skipping already stored log entry")
+			return nil
+		}
+	}
+
+	// Without idempotency keys, the content hash identifies resent entries
+	if m.storeContentHash && !m.idempotencyKeys {
+		resend, err := m.isResend(ctx, log)
+		if err != nil {
+			return err
+		}
+		if resend {
+			m.logger.WithField("message", log.Message).Debug("Skipping already stored log entry")
+			return nil
+		}
 	}
 
 	m.logger.WithField("message", log.Message).Debug("Storing log entry to Milvus")
 
+	// Initialize duplicate count to 1 (first occurrence)
+	log.DuplicateCount = 1
+
+	// Metadata-only sources bypass embedding and deduplication, storing a zero placeholder vector
+	if _, ok := m.noEmbedSources[log.Source]; ok {
+		return m.insertLog(ctx, log, make([]float32, m.embeddingDim))
+	}
+
+	text := m.embeddingText(log)
+	threshold := m.similarityThresholdFor(log.Source)
+
+	// Exact repeats of a message already excluded as a duplicate are counted
+	// without embedding or searching again. With per-source thresholds the
+	// source is part of the key so one source's exclusions don't apply to another.
+	var textHash [sha256.Size]byte
+	if m.exactDuplicates != nil && threshold > 0 {
+		key := text
+		if len(m.sourceThresholds) > 0 {
+			key = log.Source + "\x00" + text
+		}
+		textHash = sha256.Sum256([]byte(key))
+		if match, ok := m.exactDuplicates.get(textHash); ok {
+			if m.matchTooOld(log, match) {
+				m.exactDuplicates.remove(textHash)
+			} else {
+				err := m.recordDuplicate(ctx, match.ID)
+				if err == nil {
+					m.rememberMerged(log, match)
+					m.archiveExcluded(ctx, log)
+					m.logger.WithFields(logrus.Fields{
+						"message":    log.Message,
+						"similar_id": match.ID,
+					}).Debug("Exact duplicate of excluded log, count updated")
+					return nil
+				}
+				// The remembered log may be gone; fall back to the full path
+				m.logger.WithError(err).Warn("Failed to update duplicate count for exact duplicate")
+				m.exactDuplicates.remove(textHash)
+			}
+		}
+	}
+
 	// Get embedding for the log message
-	emb, err := m.embeddingService.GetEmbedding(ctx, log.Message)
+	embedCtx, embedSpan := tracing.Tracer().Start(ctx, "embed")
+	emb, err := m.embeddingService.GetEmbedding(embedCtx, text)
+	tracing.End(embedSpan, err)
 	if err != nil {
 		return fmt.Errorf("failed to get embedding: %w", err)
 	}
 
-	// Initialize duplicate count to 1 (first occurrence)
-	log.DuplicateCount = 1
+	// Check for similar logs if similarity threshold is enabled (> 0) and Milvus is responsive
+	searched, similarCount := false, 0
+	if threshold > 0 && m.allowDedupSearch() {
+		m.awaitCollectionLoaded(ctx)
 
-	// Check for similar logs if similarity threshold is enabled (> 0)
-	if m.similarityThreshold > 0 {
 		// Search for similar logs with a reasonable limit to count them and find the most similar
+		searchStart := time.Now()
 		searchResults, err := m.SearchSimilarLogs(ctx, emb, 100)
+		m.observeSearchLatency(time.Since(searchStart))
+		searched = err == nil
 		if err != nil {
 			m.logger.WithError(err).Warn("Failed to search for similar logs, proceeding with insertion")
 		} else if len(searchResults) > 0 {
 			// Count similar logs above threshold and find the most similar
 			var mostSimilarLog *SearchResult
-			similarCount := 0
+			m.dedupTopScore.Observe(float64(topScore(searchResults)))
 
 			for i := range searchResults {
-				if searchResults[i].Score > m.similarityThreshold {
+				if searchResults[i].Score > threshold {
 					similarCount++
 					if mostSimilarLog == nil || searchResults[i].Score > mostSimilarLog.Score {
 						mostSimilarLog = &searchResults[i]
@@ -372,22 +1261,36 @@ func (m *MilvusClient) StoreLog(ctx context.Context, log *models.LogEntry) error
 			}
 
 			if mostSimilarLog != nil {
-				// Check if we have enough examples stored already
-				if similarCount >= m.minExamplesBeforeExclusion {
+				// Counting against an ancient log would look like recent activity, and
+				// otherwise check if we have enough examples stored already
+				if m.matchTooOld(log, *mostSimilarLog) {
+					m.logger.WithFields(logrus.Fields{
+						"message":       log.Message,
+						"similar_id":    mostSimilarLog.ID,
+						"similar_ts":    mostSimilarLog.Timestamp,
+						"max_match_age": m.dedupMaxMatchAge,
+						"similarity":    mostSimilarLog.Score,
+					}).Debug("Most similar log is too old, storing as new entry")
+				} else if similarCount >= m.minExamplesBeforeExclusion {
 					// We have enough examples, just increment duplicate count and don't store
 					m.logger.WithFields(logrus.Fields{
 						"message":       log.Message,
 						"similarity":    mostSimilarLog.Score,
-						"threshold":     m.similarityThreshold,
+						"threshold":     threshold,
 						"similar_id":    mostSimilarLog.ID,
 						"similar_count": similarCount,
 						"min_examples":  m.minExamplesBeforeExclusion,
 					}).Debug("Detected duplicate log with sufficient examples, excluding from storage")
 
 					// Update duplicate count for the most similar existing log
-					if updateErr := m.UpdateDuplicateCount(ctx, mostSimilarLog.ID); updateErr != nil {
+					if updateErr := m.recordDuplicate(ctx, mostSimilarLog.ID); updateErr != nil {
 						m.logger.WithError(updateErr).Warn("Failed to update duplicate count")
 					}
+					if m.exactDuplicates != nil {
+						m.exactDuplicates.add(textHash, *mostSimilarLog)
+					}
+					m.rememberMerged(log, *mostSimilarLog)
+					m.archiveExcluded(ctx, log)
 
 					m.logger.WithFields(logrus.Fields{
 						"message":    log.Message,
@@ -399,7 +1302,7 @@ func (m *MilvusClient) StoreLog(ctx context.Context, log *models.LogEntry) error
 					m.logger.WithFields(logrus.Fields{
 						"message":       log.Message,
 						"similarity":    mostSimilarLog.Score,
-						"threshold":     m.similarityThreshold,
+						"threshold":     threshold,
 						"similar_count": similarCount,
 						"min_examples":  m.minExamplesBeforeExclusion,
 					}).Debug("Detected similar log but storing as additional example")
@@ -408,13 +1311,54 @@ func (m *MilvusClient) StoreLog(ctx context.Context, log *models.LogEntry) error
 		}
 	}
 
+	if m.recordSimilarCount && searched {
+		if log.Metadata == nil {
+			log.Metadata = make(map[string]interface{})
+		}
+		log.Metadata[models.SimilarCountKey] = similarCount
+	}
+
+	return m.insertLog(ctx, log, emb)
+}
+
+// insertLog writes a single log entry and its embedding to the collection
+func (m *MilvusClient) insertLog(ctx context.Context, log *models.LogEntry, emb []float32) error {
+	columns, err := m.logColumns(log, emb)
+	if err != nil {
+		return err
+	}
+
+	// Insert data using the new client API
+	insertCtx, insertSpan := tracing.Tracer().Start(ctx, "insert")
+	insertResult, err := m.client.Insert(insertCtx, milvusclient.NewColumnBasedInsertOption(m.collection).WithColumns(columns...))
+	tracing.End(insertSpan, err)
+	if err != nil {
+		return fmt.Errorf("failed to insert data: %w", err)
+	}
+	m.lastStore.SetToCurrentTime()
+
+	m.logger.WithFields(logrus.Fields{
+		"message":      log.Message,
+		"insert_count": insertResult.InsertCount,
+		"primary_key":  insertResult.IDs.(*column.ColumnInt64).Data()[0],
+	}).Info("Log stored successfully")
+
+	return nil
+}
+
+// logColumns builds the column data for a single record
+func (m *MilvusClient) logColumns(log *models.LogEntry, emb []float32) ([]column.Column, error) {
 	// Serialize metadata as JSON
 	metadataBytes, err := log.MetadataAsJSON()
 	if err != nil {
-		return fmt.Errorf("failed to serialize metadata: %w", err)
+		return nil, fmt.Errorf("failed to serialize metadata: %w", err)
+	}
+	if m.compressMetadata {
+		if metadataBytes, err = compressMetadata(metadataBytes); err != nil {
+			return nil, err
+		}
 	}
 
-	// Create column data for single record
 	columns := []column.Column{
 		column.NewColumnInt64(FieldTimestamp, []int64{log.Timestamp}),
 		column.NewColumnVarChar(FieldMessage, []string{log.Message}),
@@ -423,29 +1367,137 @@ func (m *MilvusClient) StoreLog(ctx context.Context, log *models.LogEntry) error
 		column.NewColumnInt64(FieldDuplicateCount, []int64{log.DuplicateCount}),
 		column.NewColumnFloatVector(FieldEmbedding, m.embeddingDim, [][]float32{emb}),
 	}
+	for _, key := range m.scalarFields {
+		columns = append(columns, column.NewColumnVarChar(key, []string{scalarFieldValue(log.Metadata, key)}))
+	}
+	if m.idempotencyKeys {
+		columns = append(columns, column.NewColumnVarChar(FieldIdempotencyKey, []string{log.IdempotencyKey}))
+	}
+	if m.storeTemplates {
+		columns = append(columns, column.NewColumnVarChar(FieldTemplate, []string{messageTemplate(log.Message)}))
+	}
+	if m.timestampBucket > 0 {
+		columns = append(columns, column.NewColumnInt64(FieldBucketTimestamp, []int64{bucketTimestamp(log.Timestamp, m.timestampBucket)}))
+	}
+	if m.storeContentHash {
+		columns = append(columns, column.NewColumnVarChar(FieldContentHash, []string{ContentHash(log.Source, log.Message)}))
+	}
 
-	// Insert data using the new client API
-	insertResult, err := m.client.Insert(ctx, milvusclient.NewColumnBasedInsertOption(m.collection).WithColumns(columns...))
-	if err != nil {
-		return fmt.Errorf("failed to insert data: %w", err)
+	return columns, nil
+}
+
+// scalarFieldValue extracts a metadata value as a string for a promoted field.
+// Missing keys yield an empty string and long values are truncated to fit.
+func scalarFieldValue(metadata map[string]interface{}, key string) string {
+	raw, ok := metadata[key]
+	if !ok || raw == nil {
+		return ""
 	}
 
-	m.logger.WithFields(logrus.Fields{
-		"message":      log.Message,
-		"insert_count": insertResult.InsertCount,
-		"primary_key":  insertResult.IDs.(*column.ColumnInt64).Data()[0],
-	}).Info("Log stored successfully")
+	var value string
+	switch v := raw.(type) {
+	case string:
+		value = v
+	case map[string]interface{}, []interface{}:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		value = string(encoded)
+	default:
+		value = fmt.Sprint(v)
+	}
+
+	if len(value) > scalarFieldMaxLength {
+		value = strings.ToValidUTF8(value[:scalarFieldMaxLength], "")
+	}
+	return value
+}
+
+// BatchError reports the entries of a batch that could not be stored
+type BatchError struct {
+	Failed []*models.LogEntry
+	Total  int
+	Err    error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("failed to store %d of %d logs: %v", len(e.Failed), e.Total, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// StoreBatch stores each log in the batch, applying deduplication per entry.
+// All entries are attempted; failures are aggregated into a *BatchError.
+// With idempotency keys enabled, entries whose key is already stored (or
+// repeated earlier in the batch) are skipped.
+func (m *MilvusClient) StoreBatch(ctx context.Context, logs []*models.LogEntry) error {
+	err := m.storeBatch(ctx, logs)
+	m.invalidateHealthOnUnavailable(err)
+	return err
+}
+
+// storeBatch implements StoreBatch
+func (m *MilvusClient) storeBatch(ctx context.Context, logs []*models.LogEntry) error {
+	var stored map[string]struct{}
+	if m.idempotencyKeys {
+		var err error
+		if stored, err = m.storedIdempotencyKeys(ctx, logs); err != nil {
+			return &BatchError{Failed: logs, Total: len(logs), Err: err}
+		}
+	}
+
+	var errs []error
+	var failed []*models.LogEntry
+	for _, log := range logs {
+		if stored != nil && log != nil && log.IdempotencyKey != "" {
+			if _, ok := stored[log.IdempotencyKey]; ok {
+				m.logger.WithField("idempotency_key", log.IdempotencyKey).Debug("Skipping already stored log entry")
+				continue
+			}
+		}
+		if err := m.storeLog(ctx, log, false); err != nil {
+			errs = append(errs, err)
+			failed = append(failed, log)
+			continue
+		}
+		if stored != nil && log.IdempotencyKey != "" {
+			stored[log.IdempotencyKey] = struct{}{}
+		}
+	}
 
+	if len(errs) > 0 {
+		return &BatchError{Failed: failed, Total: len(logs), Err: errors.Join(errs...)}
+	}
+
+	m.logger.WithField("batch_size", len(logs)).Debug("Batch stored successfully")
 	return nil
 }
 
+// HealthCheck verifies Milvus is reachable, reusing a recent result when a
+// health check cache TTL is set
 func (m *MilvusClient) HealthCheck(ctx context.Context) error {
-	m.logger.Debug("Performing Milvus health check")
-
 	if !m.connected {
-		return fmt.Errorf("not connected to Milvus")
+		return ErrNotConnected
+	}
+	if m.healthCache == nil {
+		return m.checkHealth(ctx)
 	}
 
+	if cached, err := m.healthCache.get(); cached {
+		return err
+	}
+	err := m.checkHealth(ctx)
+	m.healthCache.set(err)
+	return err
+}
+
+// checkHealth queries Milvus for the collection to confirm it is responsive
+func (m *MilvusClient) checkHealth(ctx context.Context) error {
+	m.logger.Debug("Performing Milvus health check")
+
 	// Check if client is connected and responsive by checking collection
 	// Note: GetVersion is not available in the new client, so we use HasCollection as a health check
 
@@ -465,7 +1517,7 @@ func (m *MilvusClient) HealthCheck(ctx context.Context) error {
 // LoadCollection ensures the collection is loaded into memory for search operations
 func (m *MilvusClient) LoadCollection(ctx context.Context) error {
 	if !m.connected {
-		return fmt.Errorf("not connected to Milvus")
+		return ErrNotConnected
 	}
 
 	m.logger.WithField("collection", m.collection).Info("Loading collection into memory")
@@ -480,5 +1532,30 @@ func (m *MilvusClient) LoadCollection(ctx context.Context) error {
 	return nil
 }
 
-// Ensure MilvusClient implements StorageInterface
+// Warmup loads the collection and waits for the load to finish in
+// CollectionLoadEager mode; otherwise it does nothing
+func (m *MilvusClient) Warmup(ctx context.Context) error {
+	if m.loadMode != CollectionLoadEager {
+		return nil
+	}
+	if !m.connected {
+		return ErrNotConnected
+	}
+
+	start := time.Now()
+	if err := m.loadAndAwait(ctx); err != nil {
+		return err
+	}
+	m.markCollectionLoaded(time.Since(start))
+
+	m.logger.WithFields(logrus.Fields{
+		"collection": m.collection,
+		"load_time":  time.Since(start).String(),
+	}).Info("Collection preloaded")
+	return nil
+}
+
+// Ensure MilvusClient implements StorageInterface, QueryInterface and AdminInterface
 var _ StorageInterface = (*MilvusClient)(nil)
+var _ QueryInterface = (*MilvusClient)(nil)
+var _ AdminInterface = (*MilvusClient)(nil)
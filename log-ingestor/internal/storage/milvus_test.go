@@ -2,9 +2,19 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/milvus-io/milvus/client/v2/column"
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -32,6 +42,98 @@ func (m *MockEmbeddingService) HealthCheck(ctx context.Context) error {
 	return args.Error(0)
 }
 
+// MockMilvusAPI is a mock implementation of the Milvus SDK seam
+type MockMilvusAPI struct {
+	mock.Mock
+}
+
+func (m *MockMilvusAPI) HasCollection(ctx context.Context, option milvusclient.HasCollectionOption) (bool, error) {
+	args := m.Called(ctx, option)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMilvusAPI) CreateCollection(ctx context.Context, option milvusclient.CreateCollectionOption) error {
+	args := m.Called(ctx, option)
+	return args.Error(0)
+}
+
+func (m *MockMilvusAPI) DropCollection(ctx context.Context, option milvusclient.DropCollectionOption) error {
+	args := m.Called(ctx, option)
+	return args.Error(0)
+}
+
+func (m *MockMilvusAPI) DescribeCollection(ctx context.Context, option milvusclient.DescribeCollectionOption) (*entity.Collection, error) {
+	args := m.Called(ctx, option)
+	collection, _ := args.Get(0).(*entity.Collection)
+	return collection, args.Error(1)
+}
+
+func (m *MockMilvusAPI) CreateIndex(ctx context.Context, option milvusclient.CreateIndexOption) (milvusTask, error) {
+	args := m.Called(ctx, option)
+	task, _ := args.Get(0).(milvusTask)
+	return task, args.Error(1)
+}
+
+func (m *MockMilvusAPI) LoadCollection(ctx context.Context, option milvusclient.LoadCollectionOption) (milvusTask, error) {
+	args := m.Called(ctx, option)
+	task, _ := args.Get(0).(milvusTask)
+	return task, args.Error(1)
+}
+
+func (m *MockMilvusAPI) Search(ctx context.Context, option milvusclient.SearchOption) ([]milvusclient.ResultSet, error) {
+	args := m.Called(ctx, option)
+	results, _ := args.Get(0).([]milvusclient.ResultSet)
+	return results, args.Error(1)
+}
+
+func (m *MockMilvusAPI) Query(ctx context.Context, option milvusclient.QueryOption) (milvusclient.ResultSet, error) {
+	args := m.Called(ctx, option)
+	return args.Get(0).(milvusclient.ResultSet), args.Error(1)
+}
+
+func (m *MockMilvusAPI) Insert(ctx context.Context, option milvusclient.InsertOption) (milvusclient.InsertResult, error) {
+	args := m.Called(ctx, option)
+	return args.Get(0).(milvusclient.InsertResult), args.Error(1)
+}
+
+func (m *MockMilvusAPI) Upsert(ctx context.Context, option milvusclient.UpsertOption) (milvusclient.UpsertResult, error) {
+	args := m.Called(ctx, option)
+	return args.Get(0).(milvusclient.UpsertResult), args.Error(1)
+}
+
+func (m *MockMilvusAPI) Close(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+// completedTask is a milvusTask that finishes immediately with the given error
+type completedTask struct {
+	err error
+}
+
+func (t *completedTask) Await(ctx context.Context) error {
+	return t.err
+}
+
+// newTestMilvusClient creates a client wired to a mock Milvus API as if connected
+func newTestMilvusClient(api *MockMilvusAPI, embeddingService *MockEmbeddingService) *MilvusClient {
+	client := NewMilvusClient("test:19530", embeddingService, 768, 0.95, 3, logrus.New())
+	client.client = api
+	client.connected = true
+	return client
+}
+
+// searchResultSet builds a search result set with the given IDs and scores
+func searchResultSet(ids []int64, scores []float32) []milvusclient.ResultSet {
+	return []milvusclient.ResultSet{
+		{
+			ResultCount: len(ids),
+			Fields:      milvusclient.DataSet{column.NewColumnInt64(FieldID, ids)},
+			Scores:      scores,
+		},
+	}
+}
+
 func TestNewMilvusClient(t *testing.T) {
 	address := "localhost:19530"
 	mockEmbedding := &MockEmbeddingService{}
@@ -49,6 +151,740 @@ func TestNewMilvusClient(t *testing.T) {
 	assert.Equal(t, 3, client.minExamplesBeforeExclusion)
 }
 
+func TestMilvusClient_SearchSimilarLogs_RecreatesMissingCollection(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+
+	api.On("Search", mock.Anything, mock.Anything).
+		Return(nil, errors.New("collection not found[collection=timberline_logs]")).Once()
+	api.On("HasCollection", mock.Anything, mock.Anything).Return(false, nil).Once()
+	api.On("CreateCollection", mock.Anything, mock.Anything).Return(nil).Once()
+	api.On("CreateIndex", mock.Anything, mock.Anything).Return(&completedTask{}, nil).Once()
+	api.On("LoadCollection", mock.Anything, mock.Anything).Return(&completedTask{}, nil).Once()
+	api.On("Search", mock.Anything, mock.Anything).
+		Return(searchResultSet([]int64{42}, []float32{0.99}), nil).Once()
+
+	results, err := client.SearchSimilarLogs(context.Background(), []float32{0.1, 0.2}, 10)
+
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, int64(42), results[0].ID)
+	assert.Equal(t, float32(0.99), results[0].Score)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_SearchSimilarLogs_RecreatesMissingCollectionWithoutLoading(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+	client.SetCollectionLoadMode(CollectionLoadNone)
+
+	api.On("Search", mock.Anything, mock.Anything).
+		Return(nil, errors.New("collection not found[collection=timberline_logs]")).Once()
+	api.On("HasCollection", mock.Anything, mock.Anything).Return(false, nil).Once()
+	api.On("CreateCollection", mock.Anything, mock.Anything).Return(nil).Once()
+	api.On("CreateIndex", mock.Anything, mock.Anything).Return(&completedTask{}, nil).Once()
+
+	_, err := client.SearchSimilarLogs(context.Background(), []float32{0.1, 0.2}, 10)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "loading on demand is disabled")
+	api.AssertNotCalled(t, "LoadCollection", mock.Anything, mock.Anything)
+	api.AssertNumberOfCalls(t, "Search", 1)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_SearchSimilarLogs_RecreateFails(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+
+	api.On("Search", mock.Anything, mock.Anything).
+		Return(nil, errors.New("collection not found[collection=timberline_logs]")).Once()
+	api.On("HasCollection", mock.Anything, mock.Anything).Return(false, errors.New("milvus unavailable")).Once()
+
+	_, err := client.SearchSimilarLogs(context.Background(), []float32{0.1, 0.2}, 10)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to recreate missing collection")
+	api.AssertNumberOfCalls(t, "Search", 1)
+}
+
+func TestMilvusClient_SearchSimilarLogs_LoadsUnloadedCollection(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+
+	api.On("Search", mock.Anything, mock.Anything).
+		Return(nil, errors.New("collection not loaded")).Once()
+	api.On("LoadCollection", mock.Anything, mock.Anything).Return(&completedTask{}, nil).Once()
+	api.On("Search", mock.Anything, mock.Anything).
+		Return(searchResultSet([]int64{7}, []float32{0.5}), nil).Once()
+
+	results, err := client.SearchSimilarLogs(context.Background(), []float32{0.1, 0.2}, 10)
+
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, int64(7), results[0].ID)
+	api.AssertNotCalled(t, "CreateCollection", mock.Anything, mock.Anything)
+	api.AssertExpectations(t)
+}
+
+// insertResult builds an insert result reporting the given primary key
+func insertResult(id int64) milvusclient.InsertResult {
+	return milvusclient.InsertResult{
+		InsertCount: 1,
+		IDs:         column.NewColumnInt64(FieldID, []int64{id}),
+	}
+}
+
+func TestMilvusClient_StoreLog_NoEmbedSource(t *testing.T) {
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newTestMilvusClient(api, mockEmbedding)
+	client.SetNoEmbedSources([]string{"metrics-agent"})
+
+	api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(1), nil).Once()
+
+	log := &models.LogEntry{
+		Timestamp: time.Now().UnixMilli(),
+		Message:   "scraped 1200 samples",
+		Source:    "metrics-agent",
+	}
+
+	err := client.StoreLog(context.Background(), log)
+
+	require.NoError(t, err)
+	mockEmbedding.AssertNotCalled(t, "GetEmbedding", mock.Anything, mock.Anything)
+	api.AssertNotCalled(t, "Search", mock.Anything, mock.Anything)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_StoreLog_UpdatesLastStoreTimestamp(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+	client.SetNoEmbedSources([]string{"metrics-agent"})
+
+	api.On("Insert", mock.Anything, mock.Anything).Return(milvusclient.InsertResult{}, errors.New("insert failed")).Once()
+	api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(1), nil).Once()
+
+	log := &models.LogEntry{
+		Timestamp: time.Now().UnixMilli(),
+		Message:   "scraped 1200 samples",
+		Source:    "metrics-agent",
+	}
+	before := float64(time.Now().Unix())
+
+	require.Error(t, client.StoreLog(context.Background(), log))
+	assert.Equal(t, float64(0), testutil.ToFloat64(client.lastStore))
+
+	require.NoError(t, client.StoreLog(context.Background(), log))
+	assert.GreaterOrEqual(t, testutil.ToFloat64(client.lastStore), before)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_StoreLog_EmbedsOtherSources(t *testing.T) {
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newTestMilvusClient(api, mockEmbedding)
+	client.similarityThreshold = 0 // Disable dedup search
+	client.SetNoEmbedSources([]string{"metrics-agent"})
+
+	mockEmbedding.On("GetEmbedding", mock.Anything, "payment failed").
+		Return(make([]float32, 768), nil).Once()
+	api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(2), nil).Once()
+
+	log := &models.LogEntry{
+		Timestamp: time.Now().UnixMilli(),
+		Message:   "payment failed",
+		Source:    "checkout",
+	}
+
+	err := client.StoreLog(context.Background(), log)
+
+	require.NoError(t, err)
+	mockEmbedding.AssertExpectations(t)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_StoreLog_EmbedIncludeSource(t *testing.T) {
+	tests := []struct {
+		name          string
+		includeSource bool
+		expectedText  string
+	}{
+		{"disabled embeds raw message", false, "connection reset"},
+		{"enabled prefixes source", true, "payments: connection reset"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &MockMilvusAPI{}
+			mockEmbedding := &MockEmbeddingService{}
+			client := newTestMilvusClient(api, mockEmbedding)
+			client.similarityThreshold = 0 // Disable dedup search
+			client.SetEmbedIncludeSource(tt.includeSource)
+
+			mockEmbedding.On("GetEmbedding", mock.Anything, tt.expectedText).
+				Return(make([]float32, 768), nil).Once()
+			api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(1), nil).Once()
+
+			log := &models.LogEntry{
+				Timestamp: time.Now().UnixMilli(),
+				Message:   "connection reset",
+				Source:    "payments",
+			}
+
+			err := client.StoreLog(context.Background(), log)
+
+			require.NoError(t, err)
+			assert.Equal(t, "connection reset", log.Message) // Stored message is unchanged
+			mockEmbedding.AssertExpectations(t)
+			api.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMilvusClient_SetScalarFields(t *testing.T) {
+	client := NewMilvusClient("test:19530", &MockEmbeddingService{}, 768, 0.95, 3, logrus.New())
+
+	client.SetScalarFields([]string{"namespace", "pod_name", "namespace", "source", "bad-key", "9lives", "level"})
+
+	assert.Equal(t, []string{"namespace", "pod_name", "level"}, client.scalarFields)
+}
+
+func TestMilvusClient_CollectionSchema_ScalarFields(t *testing.T) {
+	client := NewMilvusClient("test:19530", &MockEmbeddingService{}, 768, 0.95, 3, logrus.New())
+
+	baseFields := len(client.collectionSchema().Fields)
+
+	client.SetScalarFields([]string{"namespace", "level"})
+	schema := client.collectionSchema()
+
+	require.Len(t, schema.Fields, baseFields+2)
+	for i, name := range []string{"namespace", "level"} {
+		field := schema.Fields[baseFields+i]
+		assert.Equal(t, name, field.Name)
+		assert.Equal(t, entity.FieldTypeVarChar, field.DataType)
+		assert.Equal(t, "512", field.TypeParams["max_length"])
+	}
+}
+
+func TestMilvusClient_CreateCollection_IndexesScalarFields(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+	client.SetScalarFields([]string{"namespace", "level"})
+
+	api.On("HasCollection", mock.Anything, mock.Anything).Return(false, nil).Once()
+	api.On("CreateCollection", mock.Anything, mock.Anything).Return(nil).Once()
+	// One HNSW index on the embedding plus one inverted index per scalar field
+	api.On("CreateIndex", mock.Anything, mock.Anything).Return(&completedTask{}, nil).Times(3)
+
+	err := client.CreateCollection(context.Background())
+
+	require.NoError(t, err)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_CreateCollection_ShardNum(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+	client.SetShardNum(4)
+
+	var shards int32
+	api.On("HasCollection", mock.Anything, mock.Anything).Return(false, nil).Once()
+	api.On("CreateCollection", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		shards = args.Get(1).(milvusclient.CreateCollectionOption).Request().GetShardsNum()
+	}).Once()
+	api.On("CreateIndex", mock.Anything, mock.Anything).Return(&completedTask{}, nil).Once()
+
+	require.NoError(t, client.CreateCollection(context.Background()))
+	assert.Equal(t, int32(4), shards)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_LogColumns_ScalarFields(t *testing.T) {
+	client := NewMilvusClient("test:19530", &MockEmbeddingService{}, 2, 0.95, 3, logrus.New())
+	client.SetScalarFields([]string{"namespace", "level"})
+
+	log := &models.LogEntry{
+		Timestamp: time.Now().UnixMilli(),
+		Message:   "pod restarted",
+		Source:    "kubelet",
+		Metadata:  map[string]interface{}{"namespace": "payments"},
+	}
+
+	columns, err := client.logColumns(log, []float32{0.1, 0.2})
+	require.NoError(t, err)
+
+	values := make(map[string][]string)
+	for _, col := range columns {
+		if varchar, ok := col.(*column.ColumnVarChar); ok {
+			values[col.Name()] = varchar.Data()
+		}
+	}
+	assert.Equal(t, []string{"payments"}, values["namespace"])
+	assert.Equal(t, []string{""}, values["level"]) // Missing key stored as empty string
+}
+
+func TestMilvusClient_LogColumns_EnvScalarField(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		expected string
+	}{
+		{"Env tag attached", map[string]interface{}{models.EnvKey: "prod"}, "prod"},
+		{"Env tag unset", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewMilvusClient("test:19530", &MockEmbeddingService{}, 2, 0.95, 3, logrus.New())
+			client.SetScalarFields([]string{models.EnvKey})
+
+			log := &models.LogEntry{
+				Timestamp: time.Now().UnixMilli(),
+				Message:   "deployment rolled out",
+				Source:    "kubelet",
+				Metadata:  tt.metadata,
+			}
+
+			columns, err := client.logColumns(log, []float32{0.1, 0.2})
+			require.NoError(t, err)
+
+			var env []string
+			for _, col := range columns {
+				if col.Name() == models.EnvKey {
+					env = col.(*column.ColumnVarChar).Data()
+				}
+			}
+			assert.Equal(t, []string{tt.expected}, env)
+		})
+	}
+}
+
+func TestScalarFieldValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		expected string
+	}{
+		{"missing key", map[string]interface{}{}, ""},
+		{"nil metadata", nil, ""},
+		{"nil value", map[string]interface{}{"key": nil}, ""},
+		{"string", map[string]interface{}{"key": "payments"}, "payments"},
+		{"number", map[string]interface{}{"key": float64(3)}, "3"},
+		{"bool", map[string]interface{}{"key": true}, "true"},
+		{"object", map[string]interface{}{"key": map[string]interface{}{"app": "web"}}, `{"app":"web"}`},
+		{"truncated", map[string]interface{}{"key": strings.Repeat("a", 600)}, strings.Repeat("a", 512)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, scalarFieldValue(tt.metadata, "key"))
+		})
+	}
+}
+
+func TestMilvusClient_ResetCollection(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+
+	var calls []string
+	record := func(name string) func(mock.Arguments) {
+		return func(mock.Arguments) { calls = append(calls, name) }
+	}
+
+	api.On("DropCollection", mock.Anything, mock.Anything).Return(nil).Run(record("drop")).Once()
+	api.On("HasCollection", mock.Anything, mock.Anything).Return(false, nil).Once()
+	api.On("CreateCollection", mock.Anything, mock.Anything).Return(nil).Run(record("create")).Once()
+	api.On("CreateIndex", mock.Anything, mock.Anything).Return(&completedTask{}, nil).Once()
+	api.On("LoadCollection", mock.Anything, mock.Anything).Return(&completedTask{}, nil).Run(record("load")).Once()
+
+	err := client.ResetCollection(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"drop", "create", "load"}, calls)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_ResetCollection_LoadModeNone(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+	client.SetCollectionLoadMode(CollectionLoadNone)
+
+	api.On("DropCollection", mock.Anything, mock.Anything).Return(nil).Once()
+	api.On("HasCollection", mock.Anything, mock.Anything).Return(false, nil).Once()
+	api.On("CreateCollection", mock.Anything, mock.Anything).Return(nil).Once()
+	api.On("CreateIndex", mock.Anything, mock.Anything).Return(&completedTask{}, nil).Once()
+
+	require.NoError(t, client.ResetCollection(context.Background()))
+	api.AssertNotCalled(t, "LoadCollection", mock.Anything, mock.Anything)
+	api.AssertExpectations(t)
+}
+
+// describedCollection returns a collection whose schema has the given embedding dimension
+func describedCollection(dim int) *entity.Collection {
+	client := NewMilvusClient("test:19530", &MockEmbeddingService{}, dim, 0.95, 3, logrus.New())
+	return &entity.Collection{Name: client.collection, Schema: client.collectionSchema()}
+}
+
+func TestMilvusClient_VerifySchema(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+
+	api.On("DescribeCollection", mock.Anything, mock.Anything).Return(describedCollection(768), nil).Once()
+
+	require.NoError(t, client.VerifySchema(context.Background(), false))
+	api.AssertNotCalled(t, "DropCollection", mock.Anything, mock.Anything)
+}
+
+func TestMilvusClient_VerifySchema_Mismatch(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+
+	api.On("DescribeCollection", mock.Anything, mock.Anything).Return(describedCollection(384), nil).Once()
+
+	err := client.VerifySchema(context.Background(), false)
+
+	require.ErrorIs(t, err, ErrDimensionMismatch)
+	assert.Contains(t, err.Error(), "collection timberline_logs has dimension 384, EMBEDDING_DIMENSION is 768")
+	api.AssertNotCalled(t, "DropCollection", mock.Anything, mock.Anything)
+}
+
+func TestMilvusClient_VerifySchema_MismatchRecreates(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+
+	api.On("DescribeCollection", mock.Anything, mock.Anything).Return(describedCollection(384), nil).Once()
+	api.On("DropCollection", mock.Anything, mock.Anything).Return(nil).Once()
+	api.On("HasCollection", mock.Anything, mock.Anything).Return(false, nil).Once()
+	api.On("CreateCollection", mock.Anything, mock.Anything).Return(nil).Once()
+	api.On("CreateIndex", mock.Anything, mock.Anything).Return(&completedTask{}, nil).Once()
+	api.On("LoadCollection", mock.Anything, mock.Anything).Return(&completedTask{}, nil).Once()
+
+	require.NoError(t, client.VerifySchema(context.Background(), true))
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_VerifySchema_MissingOptionalField(t *testing.T) {
+	tests := []struct {
+		name   string
+		enable func(*MilvusClient)
+		field  string
+		option string
+	}{
+		{"Scalar field", func(m *MilvusClient) { m.SetScalarFields([]string{"level"}) }, "level", "METADATA_SCALAR_FIELDS"},
+		{"Idempotency key", func(m *MilvusClient) { m.SetIdempotencyKeys(true) }, FieldIdempotencyKey, "IDEMPOTENCY_KEYS"},
+		{"Template", func(m *MilvusClient) { m.SetMessageTemplates(true, false) }, FieldTemplate, "STORE_MESSAGE_TEMPLATE"},
+		{"Timestamp bucket", func(m *MilvusClient) { m.SetTimestampBucket(time.Minute) }, FieldBucketTimestamp, "TIMESTAMP_BUCKET"},
+		{"Content hash", func(m *MilvusClient) { m.SetContentHash(true) }, FieldContentHash, "STORE_CONTENT_HASH"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &MockMilvusAPI{}
+			client := newTestMilvusClient(api, &MockEmbeddingService{})
+			tt.enable(client)
+
+			// The collection was created before the option was enabled
+			api.On("DescribeCollection", mock.Anything, mock.Anything).Return(describedCollection(768), nil).Once()
+
+			err := client.VerifySchema(context.Background(), true)
+
+			require.ErrorIs(t, err, ErrMissingField)
+			assert.Contains(t, err.Error(), fmt.Sprintf("%q", tt.field))
+			assert.Contains(t, err.Error(), tt.option)
+			api.AssertNotCalled(t, "DropCollection", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestMilvusClient_VerifySchema_OptionalFieldsPresent(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+	client.SetScalarFields([]string{"level"})
+	client.SetIdempotencyKeys(true)
+	client.SetContentHash(true)
+
+	api.On("DescribeCollection", mock.Anything, mock.Anything).
+		Return(&entity.Collection{Name: client.collection, Schema: client.collectionSchema()}, nil).Once()
+
+	require.NoError(t, client.VerifySchema(context.Background(), false))
+}
+
+func TestMilvusClient_Warmup(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        CollectionLoadMode
+		expectLoads int
+	}{
+		{"Eager loads collection", CollectionLoadEager, 1},
+		{"Lazy skips load", CollectionLoadLazy, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &MockMilvusAPI{}
+			client := newTestMilvusClient(api, &MockEmbeddingService{})
+			client.SetCollectionLoadMode(tt.mode)
+
+			task := &completedTask{}
+			if tt.mode == CollectionLoadEager {
+				api.On("LoadCollection", mock.Anything, mock.Anything).Return(task, nil).Once()
+			}
+
+			require.NoError(t, client.Warmup(context.Background()))
+
+			api.AssertNumberOfCalls(t, "LoadCollection", tt.expectLoads)
+			api.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMilvusClient_CollectionLoadMode(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          CollectionLoadMode
+		warmupLoads   int
+		searchLoads   int
+		expectSuccess bool
+	}{
+		{"Eager loads at warmup and reloads when released", CollectionLoadEager, 1, 1, true},
+		{"Lazy loads on first unloaded search", CollectionLoadLazy, 0, 1, true},
+		{"None never loads", CollectionLoadNone, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &MockMilvusAPI{}
+			client := newTestMilvusClient(api, &MockEmbeddingService{})
+			client.SetCollectionLoadMode(tt.mode)
+
+			api.On("LoadCollection", mock.Anything, mock.Anything).Return(&completedTask{}, nil)
+
+			require.NoError(t, client.Warmup(context.Background()))
+			api.AssertNumberOfCalls(t, "LoadCollection", tt.warmupLoads)
+
+			// A search finding the collection unloaded
+			api.On("Search", mock.Anything, mock.Anything).
+				Return(nil, errors.New("collection not loaded")).Once()
+			api.On("Search", mock.Anything, mock.Anything).
+				Return(searchResultSet([]int64{7}, []float32{0.5}), nil).Maybe()
+
+			_, err := client.SearchSimilarLogs(context.Background(), []float32{0.1, 0.2}, 10)
+
+			if tt.expectSuccess {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "loading on demand is disabled")
+			}
+			api.AssertNumberOfCalls(t, "LoadCollection", tt.warmupLoads+tt.searchLoads)
+		})
+	}
+}
+
+func TestMilvusClient_Warmup_LoadFails(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+	client.SetCollectionLoadMode(CollectionLoadEager)
+
+	api.On("LoadCollection", mock.Anything, mock.Anything).Return(&completedTask{err: assert.AnError}, nil).Once()
+
+	err := client.Warmup(context.Background())
+
+	require.ErrorIs(t, err, assert.AnError)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_ResetCollection_DropFails(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+
+	api.On("DropCollection", mock.Anything, mock.Anything).Return(assert.AnError).Once()
+
+	err := client.ResetCollection(context.Background())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to drop collection")
+	api.AssertNotCalled(t, "CreateCollection", mock.Anything, mock.Anything)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_DropCollection_NotConnected(t *testing.T) {
+	client := NewMilvusClient("test:19530", &MockEmbeddingService{}, 768, 0.95, 3, logrus.New())
+
+	err := client.DropCollection(context.Background())
+
+	assert.ErrorIs(t, err, ErrNotConnected)
+}
+
+func TestMilvusClient_StoreBatch_AggregatesFailures(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+	client.SetNoEmbedSources([]string{"test"})
+
+	api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(1), nil).Once()
+
+	logs := []*models.LogEntry{
+		{Timestamp: time.Now().UnixMilli(), Message: "stored", Source: "test"},
+		{Timestamp: time.Now().UnixMilli(), Source: "test"}, // Missing message
+	}
+
+	err := client.StoreBatch(context.Background(), logs)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to store 1 of 2 logs")
+	assert.Contains(t, err.Error(), "log validation failed")
+
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	require.Len(t, batchErr.Failed, 1)
+	assert.Same(t, logs[1], batchErr.Failed[0])
+	api.AssertExpectations(t)
+}
+
+// queryResultSet builds a query result set holding the given stored logs
+func queryResultSet(logs []*models.StoredLog) milvusclient.ResultSet {
+	var (
+		ids, timestamps, counts []int64
+		messages, sources       []string
+		metadata                [][]byte
+	)
+	for _, log := range logs {
+		ids = append(ids, log.ID)
+		timestamps = append(timestamps, log.Timestamp)
+		messages = append(messages, log.Message)
+		sources = append(sources, log.Source)
+		metadata = append(metadata, []byte(`{"level":"INFO"}`))
+		counts = append(counts, log.DuplicateCount)
+	}
+
+	return milvusclient.ResultSet{
+		ResultCount: len(logs),
+		Fields: milvusclient.DataSet{
+			column.NewColumnInt64(FieldID, ids),
+			column.NewColumnInt64(FieldTimestamp, timestamps),
+			column.NewColumnVarChar(FieldMessage, messages),
+			column.NewColumnVarChar(FieldSource, sources),
+			column.NewColumnJSONBytes(FieldMetadata, metadata),
+			column.NewColumnInt64(FieldDuplicateCount, counts),
+		},
+	}
+}
+
+func TestMilvusClient_RecentLogs_SortsAndLimits(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+
+	now := time.Now().UnixMilli()
+	api.On("Query", mock.Anything, mock.Anything).Return(queryResultSet([]*models.StoredLog{
+		{ID: 1, Timestamp: now - 3000, Message: "oldest", Source: "api", DuplicateCount: 1},
+		{ID: 3, Timestamp: now - 1000, Message: "newest", Source: "api", DuplicateCount: 2},
+		{ID: 2, Timestamp: now - 2000, Message: "middle", Source: "api", DuplicateCount: 1},
+	}), nil).Once()
+
+	logs, err := client.RecentLogs(context.Background(), 2, "api")
+
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	assert.Equal(t, "newest", logs[0].Message)
+	assert.Equal(t, int64(2), logs[0].DuplicateCount)
+	assert.Equal(t, "INFO", logs[0].Metadata["level"])
+	assert.Equal(t, "middle", logs[1].Message)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_RecentLogs_WidensWindow(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+
+	// Too few logs in every window, so all windows are scanned and the old log
+	// is found once by the last one
+	rows := []*models.StoredLog{{ID: 1, Timestamp: 1000, Message: "only", Source: "api"}}
+	onRecentLogsQuery(t, api, rows).Times(len(recentLogsWindows))
+
+	logs, err := client.RecentLogs(context.Background(), 5, "")
+
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "only", logs[0].Message)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_RecentLogs_CappedScanNarrowsRange(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+
+	scanLimit := recentLogsScanLimit
+	recentLogsScanLimit = 8
+	defer func() { recentLogsScanLimit = scanLimit }()
+
+	// A few logs from the last hour among many older ones. A capped query
+	// returns the oldest matches, leaving out the newest rows.
+	now := time.Now().UnixMilli()
+	var rows []*models.StoredLog
+	for i := 0; i < 100; i++ {
+		rows = append(rows, &models.StoredLog{ID: int64(i), Timestamp: now - int64(48*time.Hour/time.Millisecond) - int64(i), Message: "old"})
+	}
+	for i := 0; i < 3; i++ {
+		rows = append(rows, &models.StoredLog{ID: int64(100 + i), Timestamp: now - int64(30*time.Minute/time.Millisecond) + int64(i), Message: "recent"})
+	}
+	onRecentLogsQuery(t, api, rows)
+
+	logs, err := client.RecentLogs(context.Background(), 5, "")
+
+	require.NoError(t, err)
+	require.Len(t, logs, 5)
+	assert.Equal(t, []int64{102, 101, 100, 0, 1}, []int64{logs[0].ID, logs[1].ID, logs[2].ID, logs[3].ID, logs[4].ID})
+	for i := 1; i < len(logs); i++ {
+		assert.GreaterOrEqual(t, logs[i-1].Timestamp, logs[i].Timestamp)
+	}
+}
+
+// onRecentLogsQuery answers Query calls by evaluating the timestamp bounds of
+// the filter against rows and, like Milvus without ordering, returning an
+// arbitrary subset (here the oldest rows) when more than the scan limit match
+func onRecentLogsQuery(t *testing.T, api *MockMilvusAPI, rows []*models.StoredLog) *mock.Call {
+	call := api.On("Query", mock.Anything, mock.Anything)
+	return call.Run(func(args mock.Arguments) {
+		call.ReturnArguments = mock.Arguments{fakeRecentLogsQuery(t, rows, args.Get(1).(milvusclient.QueryOption)), nil}
+	})
+}
+
+func fakeRecentLogsQuery(t *testing.T, rows []*models.StoredLog, option milvusclient.QueryOption) milvusclient.ResultSet {
+	request, err := option.Request()
+	require.NoError(t, err)
+
+	lower, upper := int64(math.MinInt64), int64(math.MaxInt64)
+	for _, condition := range strings.Split(request.GetExpr(), " && ") {
+		var bound int64
+		if _, err := fmt.Sscanf(condition, FieldTimestamp+" >= %d", &bound); err == nil {
+			lower = bound
+		} else if _, err := fmt.Sscanf(condition, FieldTimestamp+" < %d", &bound); err == nil {
+			upper = bound
+		}
+	}
+
+	var matched []*models.StoredLog
+	for _, row := range rows {
+		if row.Timestamp >= lower && row.Timestamp < upper {
+			matched = append(matched, row)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].Timestamp < matched[j].Timestamp })
+	if len(matched) > recentLogsScanLimit {
+		matched = matched[:recentLogsScanLimit]
+	}
+	return queryResultSet(matched)
+}
+
+func TestMilvusClient_RecentLogs_NotConnected(t *testing.T) {
+	client := NewMilvusClient("test:19530", &MockEmbeddingService{}, 768, 0.95, 3, logrus.New())
+
+	_, err := client.RecentLogs(context.Background(), 10, "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not connected to Milvus")
+}
+
 func TestMilvusClient_StoreLog_ValidationErrors(t *testing.T) {
 	mockEmbedding := &MockEmbeddingService{}
 	client := NewMilvusClient("test:19530", mockEmbedding, 768, 0.95, 3, logrus.New())
@@ -98,6 +934,28 @@ func TestMilvusClient_StoreLog_NotConnected(t *testing.T) {
 	err := client.StoreLog(context.Background(), log)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "not connected to Milvus")
+	assert.True(t, IsUnavailable(err))
+}
+
+func TestIsUnavailable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"nil", nil, false},
+		{"not connected", ErrNotConnected, true},
+		{"wrapped not connected", fmt.Errorf("failed to store 1 of 2 logs: %w", errors.Join(ErrNotConnected)), true},
+		{"grpc unavailable", errors.New("rpc error: code = Unavailable desc = connection refused"), true},
+		{"validation error", errors.New("invalid log entry: message is required"), false},
+		{"other error", assert.AnError, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsUnavailable(tt.err))
+		})
+	}
 }
 
 func TestMilvusClient_StoreLog_EmbeddingFailure(t *testing.T) {
@@ -245,6 +1103,217 @@ func TestMilvusClient_DuplicateCountingWorkflow(t *testing.T) {
 	assert.Contains(t, err.Error(), "not connected to Milvus")
 }
 
+func TestMilvusClient_StoreLog_DedupMaxMatchAge(t *testing.T) {
+	now := time.Now().UnixMilli()
+
+	tests := []struct {
+		name         string
+		matchAge     time.Duration
+		expectInsert bool
+	}{
+		{"Match within age increments count", 30 * time.Minute, false},
+		{"Match too old is stored as new entry", 3 * time.Hour, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &MockMilvusAPI{}
+			mockEmbedding := &MockEmbeddingService{}
+			client := newTestMilvusClient(api, mockEmbedding)
+			client.SetDedupMaxMatchAge(time.Hour)
+			client.SetDuplicateFlushInterval(time.Minute) // record increments without touching Milvus
+
+			matchTimestamp := now - tt.matchAge.Milliseconds()
+			results := searchResultSet([]int64{42, 43, 44}, []float32{0.99, 0.98, 0.97})
+			results[0].Fields = append(results[0].Fields,
+				column.NewColumnInt64(FieldTimestamp, []int64{matchTimestamp, matchTimestamp, matchTimestamp}))
+
+			mockEmbedding.On("GetEmbedding", mock.Anything, "queue backlog growing").
+				Return(make([]float32, 768), nil).Once()
+			api.On("Search", mock.Anything, mock.Anything).Return(results, nil).Once()
+			if tt.expectInsert {
+				api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(50), nil).Once()
+			}
+
+			log := &models.LogEntry{Timestamp: now, Message: "queue backlog growing"}
+			require.NoError(t, client.StoreLog(context.Background(), log))
+
+			if tt.expectInsert {
+				assert.Empty(t, client.pendingDuplicates)
+			} else {
+				api.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+				assert.Equal(t, map[int64]int64{42: 1}, client.pendingDuplicates)
+			}
+			mockEmbedding.AssertExpectations(t)
+			api.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMilvusClient_StoreLog_DedupWindow(t *testing.T) {
+	now := time.Now().UnixMilli()
+
+	tests := []struct {
+		name         string
+		matchAge     time.Duration
+		expectInsert bool
+	}{
+		{"Match within window increments count", 30 * time.Minute, false},
+		{"Match outside window is stored as new entry", 3 * time.Hour, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &MockMilvusAPI{}
+			mockEmbedding := &MockEmbeddingService{}
+			client := newTestMilvusClient(api, mockEmbedding)
+			client.SetDedupWindow(time.Hour)
+			client.SetDuplicateFlushInterval(time.Minute) // record increments without touching Milvus
+
+			// Milvus applies the window filter, so matches outside it are not returned
+			results := []milvusclient.ResultSet{}
+			if !tt.expectInsert {
+				matchTimestamp := now - tt.matchAge.Milliseconds()
+				results = searchResultSet([]int64{42, 43, 44}, []float32{0.99, 0.98, 0.97})
+				results[0].Fields = append(results[0].Fields,
+					column.NewColumnInt64(FieldTimestamp, []int64{matchTimestamp, matchTimestamp, matchTimestamp}))
+			}
+
+			var dsl string
+			mockEmbedding.On("GetEmbedding", mock.Anything, "queue backlog growing").
+				Return(make([]float32, 768), nil).Once()
+			api.On("Search", mock.Anything, mock.Anything).Return(results, nil).Run(func(args mock.Arguments) {
+				request, err := args.Get(1).(milvusclient.SearchOption).Request()
+				require.NoError(t, err)
+				dsl = request.GetDsl()
+			}).Once()
+			if tt.expectInsert {
+				api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(50), nil).Once()
+			}
+
+			log := &models.LogEntry{Timestamp: now, Message: "queue backlog growing"}
+			require.NoError(t, client.StoreLog(context.Background(), log))
+
+			assert.True(t, strings.HasPrefix(dsl, FieldTimestamp+" > "), "unexpected filter %q", dsl)
+			if tt.expectInsert {
+				assert.Empty(t, client.pendingDuplicates)
+			} else {
+				api.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+				assert.Equal(t, map[int64]int64{42: 1}, client.pendingDuplicates)
+			}
+			mockEmbedding.AssertExpectations(t)
+			api.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMilvusClient_StoreLog_SourceSimilarityThresholds(t *testing.T) {
+	tests := []struct {
+		name         string
+		source       string
+		expectSearch bool
+		expectInsert bool
+	}{
+		{"Source override excludes lower-scoring match", "nginx", true, false},
+		{"Other sources use global threshold", "app", true, true},
+		{"Zero override disables deduplication", "audit", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &MockMilvusAPI{}
+			mockEmbedding := &MockEmbeddingService{}
+			client := newTestMilvusClient(api, mockEmbedding)
+			client.SetSourceSimilarityThresholds(map[string]float32{"nginx": 0.5, "audit": 0})
+			client.SetDuplicateFlushInterval(time.Minute) // record increments without touching Milvus
+
+			mockEmbedding.On("GetEmbedding", mock.Anything, "GET /health 200").
+				Return(make([]float32, 768), nil).Once()
+			if tt.expectSearch {
+				api.On("Search", mock.Anything, mock.Anything).
+					Return(searchResultSet([]int64{42, 43, 44}, []float32{0.6, 0.6, 0.6}), nil).Once()
+			}
+			if tt.expectInsert {
+				api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(50), nil).Once()
+			}
+
+			log := &models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "GET /health 200", Source: tt.source}
+			require.NoError(t, client.StoreLog(context.Background(), log))
+
+			if tt.expectInsert {
+				assert.Empty(t, client.pendingDuplicates)
+			} else {
+				api.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+				assert.Equal(t, map[int64]int64{42: 1}, client.pendingDuplicates)
+			}
+			if !tt.expectSearch {
+				api.AssertNotCalled(t, "Search", mock.Anything, mock.Anything)
+			}
+			mockEmbedding.AssertExpectations(t)
+			api.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMilvusClient_StoreLog_RecordSimilarCount(t *testing.T) {
+	tests := []struct {
+		name          string
+		record        bool
+		scores        []float32
+		expectedCount interface{}
+	}{
+		{"Stored as additional example", true, []float32{0.99, 0.97, 0.5}, 2},
+		{"No similar logs", true, []float32{0.5}, 0},
+		{"Disabled", false, []float32{0.99, 0.97}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &MockMilvusAPI{}
+			mockEmbedding := &MockEmbeddingService{}
+			client := newTestMilvusClient(api, mockEmbedding)
+			client.SetRecordSimilarCount(tt.record)
+
+			ids := make([]int64, len(tt.scores))
+			for i := range ids {
+				ids[i] = int64(40 + i)
+			}
+			mockEmbedding.On("GetEmbedding", mock.Anything, "cache miss for key").
+				Return(make([]float32, 768), nil).Once()
+			api.On("Search", mock.Anything, mock.Anything).Return(searchResultSet(ids, tt.scores), nil).Once()
+			api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(50), nil).Once()
+
+			log := &models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "cache miss for key"}
+			require.NoError(t, client.StoreLog(context.Background(), log))
+
+			assert.Equal(t, tt.expectedCount, log.Metadata[models.SimilarCountKey])
+			api.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMilvusClient_StoreLog_ObservesTopScore(t *testing.T) {
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newTestMilvusClient(api, mockEmbedding)
+
+	mockEmbedding.On("GetEmbedding", mock.Anything, mock.Anything).Return(make([]float32, 768), nil).Twice()
+	api.On("Search", mock.Anything, mock.Anything).
+		Return(searchResultSet([]int64{40, 41, 42}, []float32{0.91, 0.93, 0.5}), nil).Once()
+	api.On("Search", mock.Anything, mock.Anything).Return(searchResultSet(nil, nil), nil).Once()
+	api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(50), nil).Twice()
+
+	require.NoError(t, client.StoreLog(context.Background(), &models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "near match"}))
+	// An empty collection has no top score to observe
+	require.NoError(t, client.StoreLog(context.Background(), &models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "first of its kind"}))
+
+	var metric dto.Metric
+	require.NoError(t, client.dedupTopScore.Write(&metric))
+	assert.Equal(t, uint64(1), metric.GetHistogram().GetSampleCount())
+	assert.InDelta(t, 0.93, metric.GetHistogram().GetSampleSum(), 0.0001)
+	api.AssertExpectations(t)
+}
+
 func TestSearchResult_Structure(t *testing.T) {
 	result := SearchResult{
 		ID:    12345,
@@ -253,6 +1322,7 @@ func TestSearchResult_Structure(t *testing.T) {
 
 	assert.Equal(t, int64(12345), result.ID)
 	assert.Equal(t, float32(0.95), result.Score)
+	assert.Equal(t, int64(0), result.Timestamp)
 }
 
 func TestStorageInterface_Implementation(t *testing.T) {
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"strconv"
+
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+// mergedResendCacheSize bounds how many entries counted as duplicates are
+// remembered for resend detection
+const mergedResendCacheSize = 10000
+
+// resendIdentity returns the hash identifying log across client resends: its
+// idempotency key or, without idempotency keys, its content hash and timestamp.
+// It returns false when resends of log cannot be recognized.
+func (m *MilvusClient) resendIdentity(log *models.LogEntry) ([sha256.Size]byte, bool) {
+	switch {
+	case m.idempotencyKeys && log.IdempotencyKey != "":
+		return sha256.Sum256([]byte(FieldIdempotencyKey + "\x00" + log.IdempotencyKey)), true
+	case m.storeContentHash && !m.idempotencyKeys:
+		return sha256.Sum256([]byte(FieldContentHash + "\x00" + ContentHash(log.Source, log.Message) + "\x00" + strconv.FormatInt(log.Timestamp, 10))), true
+	}
+	return [sha256.Size]byte{}, false
+}
+
+// isMergedResend reports whether log is a resend of an entry that was counted
+// as a duplicate of a stored log rather than inserted, so its key was never stored
+func (m *MilvusClient) isMergedResend(log *models.LogEntry) bool {
+	identity, ok := m.resendIdentity(log)
+	if !ok {
+		return false
+	}
+	_, merged := m.mergedResends.get(identity)
+	return merged
+}
+
+// rememberMerged records that log was counted as a duplicate of match
+func (m *MilvusClient) rememberMerged(log *models.LogEntry, match SearchResult) {
+	if identity, ok := m.resendIdentity(log); ok {
+		m.mergedResends.add(identity, match)
+	}
+}
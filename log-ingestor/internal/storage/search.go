@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/milvus-io/milvus/client/v2/index"
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+// Filter keys with special meaning in search filters; other keys name source
+// or promoted metadata scalar fields
+const (
+	FilterAfter  = "after"
+	FilterBefore = "before"
+)
+
+// ErrInvalidFilter is returned by SearchLogs for filters that cannot be
+// translated into a Milvus expression
+var ErrInvalidFilter = errors.New("invalid search filter")
+
+// SetSearchEf sets the HNSW ef used by searches that don't override it.
+// Higher values improve recall at the cost of latency; 0 keeps the Milvus default.
+func (m *MilvusClient) SetSearchEf(ef int) {
+	m.searchEf = ef
+}
+
+// searchOption builds a top-K vector search returning outputFields, restricted
+// to entities matching filter when it is not empty. A positive ef is raised to
+// topK when smaller, since HNSW rejects ef below the result count.
+func (m *MilvusClient) searchOption(vector []float32, topK, ef int, filter string, outputFields ...string) milvusclient.SearchOption {
+	option := milvusclient.NewSearchOption(
+		m.collection,
+		topK,
+		[]entity.Vector{entity.FloatVector(vector)},
+	).WithOutputFields(outputFields...)
+
+	if filter != "" {
+		option = option.WithFilter(filter)
+	}
+	if ef > 0 {
+		option = option.WithAnnParam(index.NewHNSWAnnParam(max(ef, topK)))
+	}
+	return option
+}
+
+// filterExpression translates search filters into a Milvus boolean expression.
+// "after" and "before" bound the timestamp (Unix milliseconds, inclusive) and
+// bucket_ts (when stored) selects one timestamp bucket; every other key must
+// be source, template (when stored) or a promoted scalar field and match
+// exactly.
+func (m *MilvusClient) filterExpression(filters map[string]interface{}) (string, error) {
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	conditions := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := filters[key]
+		switch key {
+		case FilterAfter, FilterBefore:
+			timestamp, ok := value.(float64)
+			if !ok || timestamp != math.Trunc(timestamp) {
+				return "", fmt.Errorf("%w: %s must be a timestamp in Unix milliseconds", ErrInvalidFilter, key)
+			}
+			operator := ">="
+			if key == FilterBefore {
+				operator = "<="
+			}
+			conditions = append(conditions, fmt.Sprintf("%s %s %d", FieldTimestamp, operator, int64(timestamp)))
+		case FieldBucketTimestamp:
+			if m.timestampBucket <= 0 {
+				return "", fmt.Errorf("%w: %s is not a filterable field", ErrInvalidFilter, key)
+			}
+			timestamp, ok := value.(float64)
+			if !ok || timestamp != math.Trunc(timestamp) {
+				return "", fmt.Errorf("%w: %s must be a timestamp in Unix milliseconds", ErrInvalidFilter, key)
+			}
+			conditions = append(conditions, fmt.Sprintf("%s == %d", FieldBucketTimestamp, int64(timestamp)))
+		default:
+			if key != FieldSource && !(key == FieldTemplate && m.storeTemplates) &&
+				!(key == FieldContentHash && m.storeContentHash) && !m.isScalarField(key) {
+				return "", fmt.Errorf("%w: %s is not a filterable field", ErrInvalidFilter, key)
+			}
+			text, ok := value.(string)
+			if !ok {
+				return "", fmt.Errorf("%w: %s must be a string", ErrInvalidFilter, key)
+			}
+			if key == FieldTemplate {
+				// Accept an example message as well as its template
+				text = models.Templatize(text)
+			}
+			conditions = append(conditions, fmt.Sprintf("%s == %s", key, strconv.Quote(text)))
+		}
+	}
+
+	return strings.Join(conditions, " && "), nil
+}
+
+// isScalarField reports whether key is a promoted metadata scalar field
+func (m *MilvusClient) isScalarField(key string) bool {
+	for _, field := range m.scalarFields {
+		if field == key {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchLogs returns the stored logs most similar to query, most similar
+// first, restricted to logs matching filters. An ef of 0 uses the configured
+// search ef.
+func (m *MilvusClient) SearchLogs(ctx context.Context, query string, limit, ef int, filters map[string]interface{}) ([]*models.SearchMatch, error) {
+	if !m.connected {
+		return nil, ErrNotConnected
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be greater than 0")
+	}
+	if ef == 0 {
+		ef = m.searchEf
+	}
+	expr, err := m.filterExpression(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.embedTemplates {
+		query = models.Templatize(query)
+	}
+	emb, err := m.embeddingService.GetEmbedding(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get embedding: %w", err)
+	}
+
+	results, err := m.search(ctx, m.searchOption(emb, limit, ef, expr,
+		FieldID, FieldTimestamp, FieldMessage, FieldSource, FieldMetadata, FieldDuplicateCount))
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return []*models.SearchMatch{}, nil
+	}
+
+	logs, err := storedLogsFromResultSet(results[0])
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]*models.SearchMatch, len(logs))
+	for i, log := range logs {
+		matches[i] = &models.SearchMatch{StoredLog: *log}
+		if i < len(results[0].Scores) {
+			matches[i].Score = results[0].Scores[i]
+		}
+	}
+
+	return matches, nil
+}
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+// requestedEf extracts the HNSW ef from a search option, 0 when none is set
+func requestedEf(t *testing.T, option milvusclient.SearchOption) int {
+	request, err := option.Request()
+	require.NoError(t, err)
+
+	for _, kv := range request.GetSearchParams() {
+		if kv.GetKey() != "params" {
+			continue
+		}
+		var params struct {
+			Ef int `json:"ef"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(kv.GetValue()), &params))
+		return params.Ef
+	}
+	return 0
+}
+
+func TestMilvusClient_SearchLogs(t *testing.T) {
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newTestMilvusClient(api, mockEmbedding)
+
+	results := queryResultSet([]*models.StoredLog{
+		{ID: 7, Timestamp: 2000, Message: "connection refused", Source: "api", DuplicateCount: 4},
+		{ID: 3, Timestamp: 1000, Message: "connection reset", Source: "api", DuplicateCount: 1},
+	})
+	results.Scores = []float32{0.93, 0.81}
+
+	mockEmbedding.On("GetEmbedding", mock.Anything, "connection errors").Return(make([]float32, 768), nil).Once()
+	api.On("Search", mock.Anything, mock.Anything).Return([]milvusclient.ResultSet{results}, nil).Once()
+
+	matches, err := client.SearchLogs(context.Background(), "connection errors", 10, 0, nil)
+
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	assert.Equal(t, int64(7), matches[0].ID)
+	assert.Equal(t, "connection refused", matches[0].Message)
+	assert.Equal(t, int64(4), matches[0].DuplicateCount)
+	assert.Equal(t, float32(0.93), matches[0].Score)
+	assert.Equal(t, float32(0.81), matches[1].Score)
+	api.AssertExpectations(t)
+}
+
+func TestMilvusClient_SearchLogs_Ef(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured int
+		requested  int
+		limit      int
+		expectEf   int
+	}{
+		{"Milvus default when unset", 0, 0, 10, 0},
+		{"Configured ef by default", 64, 0, 10, 64},
+		{"Per-request ef overrides configured", 64, 256, 10, 256},
+		{"Ef raised to limit", 0, 5, 20, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &MockMilvusAPI{}
+			mockEmbedding := &MockEmbeddingService{}
+			client := newTestMilvusClient(api, mockEmbedding)
+			client.SetSearchEf(tt.configured)
+
+			var ef int
+			mockEmbedding.On("GetEmbedding", mock.Anything, "timeout").Return(make([]float32, 768), nil).Once()
+			api.On("Search", mock.Anything, mock.Anything).Return([]milvusclient.ResultSet{}, nil).Run(func(args mock.Arguments) {
+				ef = requestedEf(t, args.Get(1).(milvusclient.SearchOption))
+			}).Once()
+
+			matches, err := client.SearchLogs(context.Background(), "timeout", tt.limit, tt.requested, nil)
+
+			require.NoError(t, err)
+			assert.Empty(t, matches)
+			assert.Equal(t, tt.expectEf, ef)
+		})
+	}
+}
+
+func TestMilvusClient_SearchSimilarLogs_UsesConfiguredEf(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+	client.SetSearchEf(128)
+
+	var ef int
+	api.On("Search", mock.Anything, mock.Anything).Return(searchResultSet([]int64{42}, []float32{0.99}), nil).Run(func(args mock.Arguments) {
+		ef = requestedEf(t, args.Get(1).(milvusclient.SearchOption))
+	}).Once()
+
+	_, err := client.SearchSimilarLogs(context.Background(), make([]float32, 768), 100)
+
+	require.NoError(t, err)
+	assert.Equal(t, 128, ef)
+}
+
+func TestMilvusClient_SearchLogs_NotConnected(t *testing.T) {
+	client := newTestMilvusClient(&MockMilvusAPI{}, &MockEmbeddingService{})
+	client.connected = false
+
+	_, err := client.SearchLogs(context.Background(), "timeout", 10, 0, nil)
+	assert.ErrorIs(t, err, ErrNotConnected)
+}
+
+func TestMilvusClient_FilterExpression(t *testing.T) {
+	tests := []struct {
+		name      string
+		filters   map[string]interface{}
+		expected  string
+		expectErr bool
+	}{
+		{"No filters", nil, "", false},
+		{
+			name:     "Scalar fields and time range",
+			filters:  map[string]interface{}{"level": "ERROR", "namespace": "X", "after": float64(1700000000000), "before": float64(1700003600000)},
+			expected: `timestamp >= 1700000000000 && timestamp <= 1700003600000 && level == "ERROR" && namespace == "X"`,
+		},
+		{"Source", map[string]interface{}{"source": "api"}, `source == "api"`, false},
+		{"Quotes are escaped", map[string]interface{}{"level": `ERR" || level != "`}, `level == "ERR\" || level != \""`, false},
+		{"Unpromoted field", map[string]interface{}{"pod": "api-0"}, "", true},
+		{"Non-string value", map[string]interface{}{"level": float64(3)}, "", true},
+		{"Non-integer timestamp", map[string]interface{}{"after": 1.5}, "", true},
+		{"String timestamp", map[string]interface{}{"before": "yesterday"}, "", true},
+	}
+
+	client := newTestMilvusClient(&MockMilvusAPI{}, &MockEmbeddingService{})
+	client.SetScalarFields([]string{"level", "namespace"})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := client.filterExpression(tt.filters)
+
+			if tt.expectErr {
+				assert.ErrorIs(t, err, ErrInvalidFilter)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, expr)
+		})
+	}
+}
+
+func TestMilvusClient_SearchLogs_Filters(t *testing.T) {
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newTestMilvusClient(api, mockEmbedding)
+	client.SetScalarFields([]string{"level"})
+
+	var dsl string
+	mockEmbedding.On("GetEmbedding", mock.Anything, "timeout").Return(make([]float32, 768), nil).Once()
+	api.On("Search", mock.Anything, mock.Anything).Return([]milvusclient.ResultSet{}, nil).Run(func(args mock.Arguments) {
+		request, err := args.Get(1).(milvusclient.SearchOption).Request()
+		require.NoError(t, err)
+		dsl = request.GetDsl()
+	}).Once()
+
+	_, err := client.SearchLogs(context.Background(), "timeout", 10, 0, map[string]interface{}{"level": "ERROR"})
+
+	require.NoError(t, err)
+	assert.Equal(t, `level == "ERROR"`, dsl)
+
+	// Invalid filters are rejected before embedding the query
+	_, err = client.SearchLogs(context.Background(), "timeout", 10, 0, map[string]interface{}{"pod": "api-0"})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+	mockEmbedding.AssertNumberOfCalls(t, "GetEmbedding", 1)
+}
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"strings"
+
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+const (
+	// FieldTemplate holds the message with numbers, UUIDs, IPs and hex values
+	// replaced by placeholders
+	FieldTemplate = "template"
+
+	// templateMaxLength is the VarChar capacity of the template field; longer
+	// templates are truncated to fit
+	templateMaxLength = 4096
+)
+
+// SetMessageTemplates enables storing each message's normalized template (see
+// models.Templatize) in a scalar field that search requests can filter on.
+// With embed set, the template rather than the raw message is embedded, so
+// messages differing only by variable values are deduplicated together and
+// search queries are templatized the same way. Like scalar fields, the
+// template field is added when the collection is created.
+func (m *MilvusClient) SetMessageTemplates(store, embed bool) {
+	m.storeTemplates = store
+	m.embedTemplates = embed
+}
+
+// messageTemplate returns the template stored for a message, truncated to
+// fit the template field
+func messageTemplate(message string) string {
+	template := models.Templatize(message)
+	if len(template) > templateMaxLength {
+		template = strings.ToValidUTF8(template[:templateMaxLength], "")
+	}
+	return template
+}
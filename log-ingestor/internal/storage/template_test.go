@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+func TestMilvusClient_StoreLog_MessageTemplate(t *testing.T) {
+	tests := []struct {
+		name          string
+		embed         bool
+		expectedInput string
+	}{
+		{"Store template, embed message", false, "Connection from 10.0.0.1:5432 refused after 3 retries"},
+		{"Store and embed template", true, "Connection from <IP> refused after <NUM> retries"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &MockMilvusAPI{}
+			mockEmbedding := &MockEmbeddingService{}
+			client := newTestMilvusClient(api, mockEmbedding)
+			client.similarityThreshold = 0 // Disable dedup search
+			client.SetMessageTemplates(true, tt.embed)
+
+			var inserted []string
+			mockEmbedding.On("GetEmbedding", mock.Anything, tt.expectedInput).Return(make([]float32, 768), nil).Once()
+			api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(1), nil).Run(func(args mock.Arguments) {
+				req, err := args.Get(1).(milvusclient.InsertOption).InsertRequest(&entity.Collection{Schema: client.collectionSchema()})
+				require.NoError(t, err)
+				for _, field := range req.GetFieldsData() {
+					if field.GetFieldName() == FieldTemplate {
+						inserted = field.GetScalars().GetStringData().GetData()
+					}
+				}
+			}).Once()
+
+			log := &models.LogEntry{
+				Timestamp: time.Now().UnixMilli(),
+				Message:   "Connection from 10.0.0.1:5432 refused after 3 retries",
+				Source:    "db-proxy",
+			}
+			require.NoError(t, client.StoreLog(context.Background(), log))
+
+			assert.Equal(t, []string{"Connection from <IP> refused after <NUM> retries"}, inserted)
+			assert.Equal(t, "Connection from 10.0.0.1:5432 refused after 3 retries", log.Message, "stored message is unchanged")
+			mockEmbedding.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMilvusClient_CollectionSchema_Template(t *testing.T) {
+	client := newTestMilvusClient(&MockMilvusAPI{}, &MockEmbeddingService{})
+	for _, field := range client.collectionSchema().Fields {
+		assert.NotEqual(t, FieldTemplate, field.Name)
+	}
+
+	client.SetMessageTemplates(true, false)
+	fields := client.collectionSchema().Fields
+	last := fields[len(fields)-1]
+	assert.Equal(t, FieldTemplate, last.Name)
+	assert.Equal(t, entity.FieldTypeVarChar, last.DataType)
+	assert.False(t, isValidScalarFieldName(FieldTemplate))
+}
+
+func TestMilvusClient_SearchLogs_TemplateFilter(t *testing.T) {
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newTestMilvusClient(api, mockEmbedding)
+
+	// The template filter is only available when templates are stored
+	_, err := client.filterExpression(map[string]interface{}{FieldTemplate: "user <NUM> failed"})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+
+	client.SetMessageTemplates(true, true)
+
+	var dsl string
+	mockEmbedding.On("GetEmbedding", mock.Anything, "login failed for user <NUM>").Return(make([]float32, 768), nil).Once()
+	api.On("Search", mock.Anything, mock.Anything).Return([]milvusclient.ResultSet{}, nil).Run(func(args mock.Arguments) {
+		request, err := args.Get(1).(milvusclient.SearchOption).Request()
+		require.NoError(t, err)
+		dsl = request.GetDsl()
+	}).Once()
+
+	// Both the query and an example message used as filter are templatized
+	_, err = client.SearchLogs(context.Background(), "login failed for user 42", 10, 0,
+		map[string]interface{}{FieldTemplate: "user 7 failed"})
+
+	require.NoError(t, err)
+	assert.Equal(t, `template == "user <NUM> failed"`, dsl)
+	mockEmbedding.AssertExpectations(t)
+}
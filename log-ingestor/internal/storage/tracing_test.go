@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMilvusClient_StoreLog_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newTestMilvusClient(api, mockEmbedding)
+	client.similarityThreshold = 0 // Disable dedup search
+
+	mockEmbedding.On("GetEmbedding", mock.Anything, "traced").Return(make([]float32, 768), nil).Once()
+	api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(1), nil).Once()
+
+	// The entry was received within a request span
+	_, requestSpan := provider.Tracer("test").Start(context.Background(), "processStream")
+	requestSpan.End()
+	log := &models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "traced", SpanContext: requestSpan.SpanContext()}
+
+	require.NoError(t, client.StoreLog(context.Background(), log))
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	require.Contains(t, spans, "storeLog")
+	require.Contains(t, spans, "embed")
+	require.Contains(t, spans, "insert")
+
+	storeSpan := spans["storeLog"]
+	assert.Equal(t, requestSpan.SpanContext().TraceID(), storeSpan.SpanContext().TraceID())
+	assert.Equal(t, requestSpan.SpanContext().SpanID(), storeSpan.Parent().SpanID())
+	assert.Equal(t, storeSpan.SpanContext().SpanID(), spans["embed"].Parent().SpanID())
+	assert.Equal(t, storeSpan.SpanContext().SpanID(), spans["insert"].Parent().SpanID())
+	assert.Equal(t, codes.Unset, storeSpan.Status().Code)
+}
+
+func TestMilvusClient_StoreLog_TracingRecordsFailure(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	api := &MockMilvusAPI{}
+	mockEmbedding := &MockEmbeddingService{}
+	client := newTestMilvusClient(api, mockEmbedding)
+
+	mockEmbedding.On("GetEmbedding", mock.Anything, "unembeddable").Return([]float32(nil), assert.AnError).Once()
+
+	log := &models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "unembeddable"}
+	require.Error(t, client.StoreLog(context.Background(), log))
+
+	// Without a request span the store span starts its own trace
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "embed", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "storeLog", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.False(t, spans[1].Parent().IsValid())
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// loadGate holds dedup searches during the startup warmup period until the
+// collection is confirmed loaded, since searches against a collection that is
+// still loading find nothing and let duplicates through
+type loadGate struct {
+	deadline time.Time
+
+	mu     sync.Mutex
+	opened atomic.Bool
+}
+
+// SetDedupWarmup makes dedup searches within warmup of now wait for the
+// collection load to be confirmed first. Once the load is confirmed, or the
+// warmup elapses, searches run without waiting. Zero disables the gate.
+func (m *MilvusClient) SetDedupWarmup(warmup time.Duration) {
+	if warmup <= 0 {
+		m.loadGate = nil
+		return
+	}
+	m.loadGate = &loadGate{deadline: time.Now().Add(warmup)}
+}
+
+// awaitCollectionLoaded blocks a dedup search until the collection load is
+// confirmed, loading it if needed, for at most the rest of the warmup period
+func (m *MilvusClient) awaitCollectionLoaded(ctx context.Context) {
+	gate := m.loadGate
+	if gate == nil || gate.opened.Load() {
+		return
+	}
+	if m.loadMode == CollectionLoadNone {
+		// The collection is assumed to be loaded externally
+		m.markCollectionLoaded(0)
+		return
+	}
+
+	gate.mu.Lock()
+	defer gate.mu.Unlock()
+	if gate.opened.Load() {
+		return
+	}
+
+	remaining := time.Until(gate.deadline)
+	if remaining <= 0 {
+		gate.opened.Store(true)
+		m.logger.WithField("collection", m.collection).Warn("Dedup warmup elapsed before collection load was confirmed, deduplicating anyway")
+		return
+	}
+
+	loadCtx, cancel := context.WithTimeout(ctx, remaining)
+	defer cancel()
+
+	start := time.Now()
+	if err := m.loadAndAwait(loadCtx); err != nil {
+		m.logger.WithError(err).Warn("Collection load not confirmed, searching for duplicates anyway")
+		return
+	}
+	m.markCollectionLoaded(time.Since(start))
+}
+
+// markCollectionLoaded opens the load gate, logging the transition once
+func (m *MilvusClient) markCollectionLoaded(waited time.Duration) {
+	gate := m.loadGate
+	if gate == nil || gate.opened.Swap(true) {
+		return
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"collection": m.collection,
+		"waited":     waited.String(),
+	}).Info("Collection load confirmed, deduplication active")
+}
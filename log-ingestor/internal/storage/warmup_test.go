@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/timberline/log-ingestor/internal/models"
+)
+
+func TestMilvusClient_StoreLog_DedupWarmup(t *testing.T) {
+	tests := []struct {
+		name         string
+		warmup       time.Duration
+		loadErr      error
+		expectLoads  int
+		expectOpened bool
+	}{
+		{"Confirms load before first search", time.Minute, nil, 1, true},
+		{"Retries unconfirmed load on next store", time.Minute, assert.AnError, 2, false},
+		{"Elapsed warmup stops waiting", -time.Second, nil, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &MockMilvusAPI{}
+			mockEmbedding := &MockEmbeddingService{}
+			client := newTestMilvusClient(api, mockEmbedding)
+			client.SetDedupWarmup(time.Minute)
+			client.loadGate.deadline = time.Now().Add(tt.warmup)
+
+			var calls []string
+			record := func(name string) func(mock.Arguments) {
+				return func(mock.Arguments) { calls = append(calls, name) }
+			}
+			mockEmbedding.On("GetEmbedding", mock.Anything, mock.Anything).Return(make([]float32, 768), nil)
+			api.On("LoadCollection", mock.Anything, mock.Anything).Return(&completedTask{err: tt.loadErr}, nil).Run(record("load"))
+			api.On("Search", mock.Anything, mock.Anything).Return([]milvusclient.ResultSet{}, nil).Run(record("search"))
+			api.On("Insert", mock.Anything, mock.Anything).Return(insertResult(1), nil)
+
+			for i := 0; i < 2; i++ {
+				log := &models.LogEntry{Timestamp: time.Now().UnixMilli(), Message: "cache miss"}
+				require.NoError(t, client.StoreLog(context.Background(), log))
+			}
+
+			api.AssertNumberOfCalls(t, "LoadCollection", tt.expectLoads)
+			api.AssertNumberOfCalls(t, "Search", 2)
+			assert.Equal(t, tt.expectOpened, client.loadGate.opened.Load())
+			if tt.expectLoads > 0 {
+				assert.Equal(t, "load", calls[0])
+			}
+		})
+	}
+}
+
+func TestMilvusClient_Warmup_OpensDedupGate(t *testing.T) {
+	api := &MockMilvusAPI{}
+	client := newTestMilvusClient(api, &MockEmbeddingService{})
+	client.SetCollectionLoadMode(CollectionLoadEager)
+	client.SetDedupWarmup(time.Minute)
+
+	api.On("LoadCollection", mock.Anything, mock.Anything).Return(&completedTask{}, nil).Once()
+
+	require.NoError(t, client.Warmup(context.Background()))
+	assert.True(t, client.loadGate.opened.Load())
+
+	// A confirmed load does not make stores load again
+	client.awaitCollectionLoaded(context.Background())
+	api.AssertNumberOfCalls(t, "LoadCollection", 1)
+}
+
+func TestMilvusClient_SetDedupWarmup_Disabled(t *testing.T) {
+	client := newTestMilvusClient(&MockMilvusAPI{}, &MockEmbeddingService{})
+	client.SetDedupWarmup(0)
+	assert.Nil(t, client.loadGate)
+
+	// No gate means no load is attempted
+	client.awaitCollectionLoaded(context.Background())
+}
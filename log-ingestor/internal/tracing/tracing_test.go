@@ -0,0 +1,44 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetup_DisabledWithoutEndpoint(t *testing.T) {
+	provider := otel.GetTracerProvider()
+
+	shutdown, err := Setup(context.Background(), "", "test")
+
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+	assert.Equal(t, provider, otel.GetTracerProvider())
+
+	// Spans are no-ops until a provider is installed
+	_, span := Tracer().Start(context.Background(), "noop")
+	assert.False(t, span.IsRecording())
+}
+
+func TestEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	_, ok := tracer.Start(context.Background(), "ok")
+	End(ok, nil)
+	_, failed := tracer.Start(context.Background(), "failed")
+	End(failed, errors.New("insert failed"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "insert failed", spans[1].Status().Description)
+}